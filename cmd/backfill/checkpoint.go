@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// backfillCheckpoint records how far a backfill has progressed on each network; block
+// numbers of different networks are unrelated, so each has its own watermark
+type backfillCheckpoint struct {
+	Networks  map[string]*networkCheckpoint `json:"networks"`
+	UpdatedAt time.Time                     `json:"updated_at"`
+
+	// LastBlock is the single watermark of checkpoints written before they were kept per network
+	LastBlock uint64 `json:"last_block,omitempty"`
+}
+
+// networkCheckpoint records how far a backfill has progressed on one network
+type networkCheckpoint struct {
+	LastBlock    uint64    `json:"last_block"`
+	Transactions int64     `json:"transactions"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// loadCheckpoint reads the checkpoint file; a missing file means starting from scratch. A
+// checkpoint of an older version holds one watermark for all networks and can only be resumed
+// for the network it was written for.
+func loadCheckpoint(path, network string) (*backfillCheckpoint, error) {
+	checkpoint := &backfillCheckpoint{Networks: make(map[string]*networkCheckpoint)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Networks == nil {
+		checkpoint.Networks = make(map[string]*networkCheckpoint)
+	}
+
+	if len(checkpoint.Networks) == 0 && checkpoint.LastBlock > 0 {
+		if network == "" {
+			return nil, fmt.Errorf("checkpoint %s has no network; resume it with -network or remove it", path)
+		}
+		checkpoint.Networks[entity.NormalizeNetwork(network)] = &networkCheckpoint{
+			LastBlock: checkpoint.LastBlock,
+			UpdatedAt: checkpoint.UpdatedAt,
+		}
+	}
+	checkpoint.LastBlock = 0
+
+	return checkpoint, nil
+}
+
+// saveCheckpoint atomically replaces the checkpoint file
+func saveCheckpoint(path string, checkpoint *backfillCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".backfill-checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkpointTracker advances the per-network watermarks as batches commit and writes the
+// checkpoint file whenever one of them moves
+type checkpointTracker struct {
+	mu         sync.Mutex
+	path       string
+	checkpoint *backfillCheckpoint
+	marks      map[string]*watermark
+}
+
+// newCheckpointTracker creates a tracker resuming from a loaded checkpoint
+func newCheckpointTracker(path string, checkpoint *backfillCheckpoint) *checkpointTracker {
+	return &checkpointTracker{
+		path:       path,
+		checkpoint: checkpoint,
+		marks:      make(map[string]*watermark),
+	}
+}
+
+// resumeAfter returns the last committed block of a network, false if it has none
+func (t *checkpointTracker) resumeAfter(network string) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if progress, ok := t.checkpoint.Networks[network]; ok {
+		return progress.LastBlock, true
+	}
+	return 0, false
+}
+
+// register assigns the next batch ID of a network
+func (t *checkpointTracker) register(network string) int64 {
+	return t.watermark(network).register()
+}
+
+// complete marks a batch as committed and saves the checkpoint if the watermark of its
+// network advanced
+func (t *checkpointTracker) complete(batch *blockBatch) error {
+	block, advanced := t.watermark(batch.network).complete(batch.id, batch.lastBlock, len(batch.transactions))
+	if !advanced {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	progress, ok := t.checkpoint.Networks[batch.network]
+	if !ok {
+		progress = &networkCheckpoint{}
+		t.checkpoint.Networks[batch.network] = progress
+	}
+	progress.LastBlock = block
+	progress.Transactions += t.marks[batch.network].takeCommitted()
+	progress.UpdatedAt = now
+	t.checkpoint.UpdatedAt = now
+
+	return saveCheckpoint(t.path, t.checkpoint)
+}
+
+// watermark returns the watermark of a network, starting it at the checkpoint
+func (t *checkpointTracker) watermark(network string) *watermark {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	mark, ok := t.marks[network]
+	if !ok {
+		var block uint64
+		if progress, found := t.checkpoint.Networks[network]; found {
+			block = progress.LastBlock
+		}
+		mark = newWatermark(block)
+		t.marks[network] = mark
+	}
+	return mark
+}
+
+// watermark tracks batches completing out of order and reports the highest block
+// below which every batch has been committed
+type watermark struct {
+	mu        sync.Mutex
+	nextID    int64
+	lowestID  int64
+	completed map[int64]uint64
+	block     uint64
+	txs       int64
+	txCounts  map[int64]int
+}
+
+// newWatermark creates a watermark starting at the given block
+func newWatermark(block uint64) *watermark {
+	return &watermark{
+		completed: make(map[int64]uint64),
+		txCounts:  make(map[int64]int),
+		block:     block,
+	}
+}
+
+// register assigns the next batch ID
+func (w *watermark) register() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	return id
+}
+
+// complete marks a batch as committed and returns the new watermark block and whether it advanced
+func (w *watermark) complete(id int64, lastBlock uint64, txCount int) (uint64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.completed[id] = lastBlock
+	w.txCounts[id] = txCount
+
+	advanced := false
+	for {
+		block, ok := w.completed[w.lowestID]
+		if !ok {
+			break
+		}
+		w.block = block
+		w.txs += int64(w.txCounts[w.lowestID])
+		delete(w.completed, w.lowestID)
+		delete(w.txCounts, w.lowestID)
+		w.lowestID++
+		advanced = true
+	}
+
+	return w.block, advanced
+}
+
+// takeCommitted returns how many transactions moved below the watermark since the last call
+func (w *watermark) takeCommitted() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	txs := w.txs
+	w.txs = 0
+	return txs
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	app_service "crypto-bubble-map-indexer/internal/application/service"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	domain_service "crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/blockchain"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/database"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+	"crypto-bubble-map-indexer/internal/infrastructure/source"
+
+	"go.uber.org/zap"
+)
+
+// backfillOptions holds the command line options of the backfill command
+type backfillOptions struct {
+	dir              string
+	checkpointPath   string
+	parallelism      int
+	batchSize        int
+	network          string
+	fromBlock        uint64
+	toBlock          uint64
+	retries          int
+	progressInterval time.Duration
+}
+
+// blockBatch is a set of whole blocks of one network handed to a worker
+type blockBatch struct {
+	id           int64
+	network      string
+	transactions []*entity.Transaction
+	firstBlock   uint64
+	lastBlock    uint64
+}
+
+// inputFile is a transaction file with the first block it contains
+type inputFile struct {
+	path       string
+	size       int64
+	firstBlock uint64
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := backfillOptions{}
+	flag.StringVar(&opts.dir, "dir", cfg.Source.Path, "file or directory of JSON-lines (optionally gzip) transaction files")
+	flag.StringVar(&opts.checkpointPath, "checkpoint", "", "checkpoint file (default: <dir>/.backfill-checkpoint.json)")
+	flag.IntVar(&opts.parallelism, "parallelism", cfg.App.WorkerPoolSize, "number of batches indexed concurrently")
+	flag.IntVar(&opts.batchSize, "batch-size", cfg.App.BatchSize, "minimum transactions per batch (batches always hold whole blocks)")
+	flag.StringVar(&opts.network, "network", "", "only backfill transactions of this network")
+	flag.Uint64Var(&opts.fromBlock, "from-block", 0, "first block to index")
+	flag.Uint64Var(&opts.toBlock, "to-block", 0, "last block to index (0 means no limit)")
+	flag.IntVar(&opts.retries, "retries", 3, "attempts per batch before the backfill stops")
+	flag.DurationVar(&opts.progressInterval, "progress-interval", 10*time.Second, "how often progress is reported")
+	flag.Parse()
+
+	if opts.dir == "" {
+		fmt.Println("Usage: backfill -dir <path> [flags]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if opts.checkpointPath == "" {
+		opts.checkpointPath = defaultCheckpointPath(opts.dir)
+	}
+	if opts.parallelism <= 0 {
+		opts.parallelism = 1
+	}
+	if opts.batchSize <= 0 {
+		opts.batchSize = 100
+	}
+
+	// Create logger
+	log, err := logger.NewLogger(cfg.App.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+	log = log.WithComponent("backfill")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, opts, log); err != nil {
+		log.Error("Backfill failed", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+// run wires the indexing service and replays the transaction files
+func run(ctx context.Context, cfg *config.Config, opts backfillOptions, log *logger.Logger) error {
+	neo4jClient := database.NewNeo4JClient(&cfg.Neo4J, log)
+	if err := neo4jClient.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Neo4J: %w", err)
+	}
+	defer neo4jClient.Close(context.Background())
+
+	indexingService := app_service.NewIndexingApplicationService(
+		database.NewNeo4JWalletRepository(neo4jClient, log),
+		database.NewNeo4JTransactionRepository(neo4jClient, log),
+		database.NewNeo4JERC20Repository(neo4jClient, log),
+		database.NewNeo4JProcessedTransactionRepository(neo4jClient, log),
+		database.NewNeo4JEdgeHistoryRepository(neo4jClient, log),
+		blockchain.NewERC20DecoderService(log),
+		app_service.NewReorgApplicationService(database.NewNeo4JBlockRepository(neo4jClient, log), &cfg.App, log),
+		database.NewNeo4JUnitOfWork(neo4jClient, log),
+		log,
+	)
+
+	checkpoint, err := loadCheckpoint(opts.checkpointPath, opts.network)
+	if err != nil {
+		return err
+	}
+	for network, progress := range checkpoint.Networks {
+		log.Info("Resuming backfill from checkpoint",
+			zap.String("network", network),
+			zap.Uint64("last_block", progress.LastBlock),
+			zap.Int64("transactions", progress.Transactions),
+			zap.Time("updated_at", progress.UpdatedAt))
+	}
+	tracker := newCheckpointTracker(opts.checkpointPath, checkpoint)
+
+	files, err := orderInputFiles(opts.dir)
+	if err != nil {
+		return err
+	}
+
+	var totalBytes int64
+	for _, file := range files {
+		totalBytes += file.size
+	}
+
+	log.Info("Starting backfill",
+		zap.String("dir", opts.dir),
+		zap.Int("files", len(files)),
+		zap.Int64("total_bytes", totalBytes),
+		zap.Uint64("from_block", opts.fromBlock),
+		zap.Int("parallelism", opts.parallelism),
+		zap.Int("batch_size", opts.batchSize))
+
+	progress := &progressTracker{totalBytes: totalBytes, started: time.Now()}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan *blockBatch, opts.parallelism)
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failErr  error
+	)
+
+	// Workers index batches concurrently and advance the checkpoint watermark of their network
+	for i := 0; i < opts.parallelism; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for batch := range batches {
+				if err := processWithRetry(runCtx, indexingService, batch, opts.retries, log); err != nil {
+					failOnce.Do(func() {
+						failErr = fmt.Errorf("batch of blocks %d-%d failed: %w", batch.firstBlock, batch.lastBlock, err)
+						cancel()
+					})
+					continue
+				}
+
+				progress.blocksDone(batch.lastBlock-batch.firstBlock+1, len(batch.transactions))
+
+				if err := tracker.complete(batch); err != nil {
+					log.Warn("Failed to save checkpoint",
+						zap.String("network", batch.network),
+						zap.Error(err))
+				}
+			}
+		}(i)
+	}
+
+	// Report progress periodically
+	progressDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(opts.progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				progress.report(log)
+			case <-progressDone:
+				return
+			}
+		}
+	}()
+
+	readErr := readBatches(runCtx, files, opts, tracker, progress, batches, log)
+	close(batches)
+	wg.Wait()
+	close(progressDone)
+	progress.report(log)
+
+	if failErr != nil {
+		return failErr
+	}
+	if readErr != nil && !errors.Is(readErr, context.Canceled) {
+		return readErr
+	}
+	if ctx.Err() != nil {
+		log.Info("Backfill interrupted, progress saved to checkpoint",
+			zap.String("checkpoint", opts.checkpointPath))
+		return nil
+	}
+
+	log.Info("Backfill completed",
+		zap.Int64("transactions", progress.transactions.Load()),
+		zap.Duration("elapsed", time.Since(progress.started)))
+	return nil
+}
+
+// networkReader is the batching state of one network while reading the files
+type networkReader struct {
+	current    *blockBatch
+	startBlock uint64
+	lastBlock  uint64
+	haveLast   bool
+}
+
+// readBatches reads the files in block order and groups whole blocks of each network into
+// batches; blocks of a network going backwards fail the backfill, since the checkpoint of
+// that network could otherwise move past blocks that were never indexed
+func readBatches(
+	ctx context.Context,
+	files []inputFile,
+	opts backfillOptions,
+	tracker *checkpointTracker,
+	progress *progressTracker,
+	batches chan<- *blockBatch,
+	log *logger.Logger,
+) error {
+	networks := make(map[string]*networkReader)
+	filter := ""
+	if opts.network != "" {
+		filter = entity.NormalizeNetwork(opts.network)
+	}
+
+	readerOf := func(network string) *networkReader {
+		state, ok := networks[network]
+		if !ok {
+			state = &networkReader{startBlock: opts.fromBlock}
+			if last, found := tracker.resumeAfter(network); found && last+1 > state.startBlock {
+				state.startBlock = last + 1
+			}
+			networks[network] = state
+		}
+		return state
+	}
+
+	emit := func(state *networkReader) error {
+		if state.current == nil || len(state.current.transactions) == 0 {
+			return nil
+		}
+		state.current.id = tracker.register(state.current.network)
+		select {
+		case batches <- state.current:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		state.current = nil
+		return nil
+	}
+
+	for _, file := range files {
+		reader, err := source.OpenTransactionFile(file.path)
+		if err != nil {
+			return err
+		}
+
+		for {
+			tx, _, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			var decodeErr *source.DecodeError
+			if errors.As(err, &decodeErr) {
+				log.Warn("Skipping undecodable line",
+					zap.String("file", decodeErr.Source),
+					zap.Int("line", decodeErr.Line),
+					zap.Error(decodeErr.Err))
+				continue
+			}
+			if err != nil {
+				reader.Close()
+				return err
+			}
+
+			progress.setBytes(reader.BytesRead())
+
+			network := entity.NormalizeNetwork(tx.Network)
+			if filter != "" && network != filter {
+				continue
+			}
+
+			block, err := tx.BlockNumberUint64()
+			if err != nil {
+				log.Warn("Skipping transaction without a valid block number", zap.Error(err))
+				continue
+			}
+
+			state := readerOf(network)
+			if state.haveLast && block < state.lastBlock {
+				reader.Close()
+				return fmt.Errorf("transaction files are not in block order: %s has block %d of %s after block %d",
+					file.path, block, network, state.lastBlock)
+			}
+			state.lastBlock = block
+			state.haveLast = true
+
+			if block < state.startBlock {
+				continue
+			}
+			if opts.toBlock > 0 && block > opts.toBlock {
+				continue
+			}
+
+			// Only cut a batch on a block boundary so blocks are never split across workers
+			if state.current != nil && block != state.current.lastBlock && len(state.current.transactions) >= opts.batchSize {
+				if err := emit(state); err != nil {
+					reader.Close()
+					return err
+				}
+			}
+
+			if state.current == nil {
+				state.current = &blockBatch{network: network, firstBlock: block}
+			}
+			state.current.transactions = append(state.current.transactions, tx)
+			state.current.lastBlock = block
+		}
+
+		progress.fileDone(file.size)
+		reader.Close()
+	}
+
+	for _, state := range networks {
+		if err := emit(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processWithRetry indexes a batch, retrying with backoff on failure
+func processWithRetry(ctx context.Context, indexingService domain_service.IndexingService, batch *blockBatch, retries int, log *logger.Logger) error {
+	var err error
+	for attempt := 1; attempt <= retries; attempt++ {
+		if _, err = indexingService.ProcessTransactionBatch(ctx, batch.transactions); err == nil {
+			return nil
+		}
+
+		log.Warn("Failed to index batch",
+			zap.Uint64("first_block", batch.firstBlock),
+			zap.Uint64("last_block", batch.lastBlock),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+
+		select {
+		case <-time.After(time.Duration(attempt) * 2 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// orderInputFiles lists the transaction files ordered by the first block they contain
+func orderInputFiles(dir string) ([]inputFile, error) {
+	paths, err := source.ListTransactionFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no transaction files found at %s", dir)
+	}
+
+	files := make([]inputFile, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		firstBlock, err := firstBlockOf(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, inputFile{path: path, size: info.Size(), firstBlock: firstBlock})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].firstBlock < files[j].firstBlock
+	})
+
+	return files, nil
+}
+
+// firstBlockOf returns the block number of the first decodable transaction in a file
+func firstBlockOf(path string) (uint64, error) {
+	reader, err := source.OpenTransactionFile(path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	for {
+		tx, _, err := reader.Next()
+		if err == io.EOF {
+			return 0, nil
+		}
+		var decodeErr *source.DecodeError
+		if errors.As(err, &decodeErr) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if block, err := tx.BlockNumberUint64(); err == nil {
+			return block, nil
+		}
+	}
+}
+
+// defaultCheckpointPath places the checkpoint next to the input
+func defaultCheckpointPath(dir string) string {
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return dir + ".backfill-checkpoint.json"
+	}
+	return filepath.Join(dir, ".backfill-checkpoint.json")
+}
+
+// progressTracker accumulates throughput figures for progress reports
+type progressTracker struct {
+	mu           sync.Mutex
+	totalBytes   int64
+	doneBytes    int64
+	currentBytes int64
+	blocks       uint64
+	transactions atomic.Int64
+	started      time.Time
+}
+
+// setBytes records how far the current file has been read
+func (p *progressTracker) setBytes(read int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.currentBytes = read
+}
+
+// fileDone marks a file as fully read
+func (p *progressTracker) fileDone(size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.doneBytes += size
+	p.currentBytes = 0
+}
+
+// blocksDone records a committed batch
+func (p *progressTracker) blocksDone(blocks uint64, txCount int) {
+	p.mu.Lock()
+	p.blocks += blocks
+	p.mu.Unlock()
+	p.transactions.Add(int64(txCount))
+}
+
+// report logs throughput and an ETA estimated from the share of input read
+func (p *progressTracker) report(log *logger.Logger) {
+	p.mu.Lock()
+	readBytes := p.doneBytes + p.currentBytes
+	blocks := p.blocks
+	p.mu.Unlock()
+
+	elapsed := time.Since(p.started)
+	if elapsed <= 0 {
+		return
+	}
+
+	blocksPerSec := float64(blocks) / elapsed.Seconds()
+	txsPerSec := float64(p.transactions.Load()) / elapsed.Seconds()
+
+	fields := []zap.Field{
+		zap.Uint64("blocks", blocks),
+		zap.Int64("transactions", p.transactions.Load()),
+		zap.Float64("blocks_per_sec", blocksPerSec),
+		zap.Float64("txs_per_sec", txsPerSec),
+		zap.Duration("elapsed", elapsed.Round(time.Second)),
+	}
+
+	if p.totalBytes > 0 && readBytes > 0 {
+		fraction := float64(readBytes) / float64(p.totalBytes)
+		eta := time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+		fields = append(fields,
+			zap.Float64("percent", fraction*100),
+			zap.Duration("eta", eta.Round(time.Second)))
+	}
+
+	log.Info("Backfill progress", fields...)
+}
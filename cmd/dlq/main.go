@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+
+	"github.com/nats-io/nats.go"
+)
+
+const usage = `Usage: dlq <command> [flags]
+
+Commands:
+  inspect   List dead-lettered messages
+  redrive   Publish dead-lettered payloads back to their original subject and remove them from the DLQ
+
+Run "dlq <command> -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "inspect":
+		err = runInspect(cfg, os.Args[2:])
+	case "redrive":
+		err = runRedrive(cfg, os.Args[2:])
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// deadLetterEntry is a dead letter together with its position in the DLQ stream
+type deadLetterEntry struct {
+	Sequence uint64             `json:"sequence"`
+	Letter   *entity.DeadLetter `json:"letter"`
+}
+
+// runInspect prints dead-lettered messages without removing them
+func runInspect(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	limit := fs.Int("limit", 50, "maximum number of entries to show")
+	from := fs.Uint64("from", 0, "first stream sequence to show (default: oldest)")
+	stage := fs.String("stage", "", "only show entries from this stage (DECODE or INDEX)")
+	asJSON := fs.Bool("json", false, "print entries as JSON lines including payloads")
+	fs.Parse(args)
+
+	nc, js, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	count := 0
+	return scanDeadLetters(js, cfg.NATS.DeadLetterStream, *from, func(entry *deadLetterEntry) (bool, error) {
+		if *stage != "" && string(entry.Letter.Stage) != *stage {
+			return true, nil
+		}
+
+		if *asJSON {
+			line, err := json.Marshal(entry)
+			if err != nil {
+				return false, err
+			}
+			fmt.Println(string(line))
+		} else {
+			fmt.Printf("seq=%d stage=%s attempts=%d time=%s network=%s tx=%s subject=%s\n  error: %s\n",
+				entry.Sequence,
+				entry.Letter.Stage,
+				entry.Letter.Attempts,
+				entry.Letter.Timestamp.Format(time.RFC3339),
+				entry.Letter.Network,
+				entry.Letter.TxHash,
+				entry.Letter.Subject,
+				entry.Letter.Error)
+		}
+
+		count++
+		return count < *limit, nil
+	})
+}
+
+// runRedrive republishes dead-lettered payloads to their original subject
+func runRedrive(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("redrive", flag.ExitOnError)
+	limit := fs.Int("limit", 100, "maximum number of entries to re-drive")
+	seq := fs.Uint64("seq", 0, "re-drive only the entry with this stream sequence")
+	stage := fs.String("stage", "", "only re-drive entries from this stage (DECODE or INDEX)")
+	subject := fs.String("subject", "", "publish to this subject instead of the original one")
+	dryRun := fs.Bool("dry-run", false, "show what would be re-driven without publishing")
+	fs.Parse(args)
+
+	nc, js, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	redriven := 0
+	err = scanDeadLetters(js, cfg.NATS.DeadLetterStream, *seq, func(entry *deadLetterEntry) (bool, error) {
+		if *seq != 0 && entry.Sequence != *seq {
+			return false, nil
+		}
+		if *stage != "" && string(entry.Letter.Stage) != *stage {
+			return true, nil
+		}
+
+		target := entry.Letter.Subject
+		if *subject != "" {
+			target = *subject
+		}
+		if target == "" {
+			fmt.Printf("seq=%d has no original subject, use -subject to re-drive it\n", entry.Sequence)
+			return true, nil
+		}
+
+		if *dryRun {
+			fmt.Printf("would re-drive seq=%d tx=%s to %s\n", entry.Sequence, entry.Letter.TxHash, target)
+		} else {
+			if _, err := js.Publish(target, entry.Letter.Payload); err != nil {
+				return false, fmt.Errorf("failed to re-drive seq %d: %w", entry.Sequence, err)
+			}
+			if err := js.DeleteMsg(cfg.NATS.DeadLetterStream, entry.Sequence); err != nil {
+				return false, fmt.Errorf("re-drove seq %d but failed to remove it from the DLQ: %w", entry.Sequence, err)
+			}
+			fmt.Printf("re-drove seq=%d tx=%s to %s\n", entry.Sequence, entry.Letter.TxHash, target)
+		}
+
+		redriven++
+		return redriven < *limit, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d entries re-driven\n", redriven)
+	return nil
+}
+
+// connect opens a NATS connection and JetStream context using the indexer configuration
+func connect(cfg *config.Config) (*nats.Conn, nats.JetStreamContext, error) {
+	if cfg.NATS.DeadLetterStream == "" {
+		return nil, nil, fmt.Errorf("nats.dead_letter_stream is not configured")
+	}
+
+	nc, err := nats.Connect(cfg.NATS.URL,
+		nats.Name("bubble-map-indexer-dlq"),
+		nats.Timeout(cfg.NATS.ConnectTimeout),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	return nc, js, nil
+}
+
+// scanDeadLetters walks the DLQ stream from the given sequence until fn returns false
+func scanDeadLetters(js nats.JetStreamContext, stream string, from uint64, fn func(*deadLetterEntry) (bool, error)) error {
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		return fmt.Errorf("failed to look up dead-letter stream %q: %w", stream, err)
+	}
+
+	if info.State.Msgs == 0 {
+		fmt.Println("dead-letter queue is empty")
+		return nil
+	}
+
+	start := info.State.FirstSeq
+	if from > start {
+		start = from
+	}
+
+	for seq := start; seq <= info.State.LastSeq; seq++ {
+		raw, err := js.GetMsg(stream, seq)
+		if errors.Is(err, nats.ErrMsgNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read seq %d: %w", seq, err)
+		}
+
+		var letter entity.DeadLetter
+		if err := json.Unmarshal(raw.Data, &letter); err != nil {
+			fmt.Printf("seq=%d is not a dead letter: %v\n", seq, err)
+			continue
+		}
+
+		more, err := fn(&deadLetterEntry{Sequence: seq, Letter: &letter})
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -2,12 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
+	"strconv"
 	"time"
 
 	app_service "crypto-bubble-map-indexer/internal/application/service"
@@ -18,6 +17,8 @@ import (
 	"crypto-bubble-map-indexer/internal/infrastructure/database"
 	"crypto-bubble-map-indexer/internal/infrastructure/logger"
 	"crypto-bubble-map-indexer/internal/infrastructure/messaging"
+	"crypto-bubble-map-indexer/internal/infrastructure/source"
+	"crypto-bubble-map-indexer/internal/infrastructure/spool"
 
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
@@ -44,6 +45,7 @@ func main() {
 		// Provide dependencies
 		fx.Supply(cfg),
 		fx.Supply(log),
+		fx.Supply(&cfg.App),
 		fx.Supply(&cfg.NATS),
 		fx.Supply(&cfg.Neo4J),
 		fx.Provide(func() *zap.Logger { return log.Logger }),
@@ -54,13 +56,27 @@ func main() {
 			database.NewNeo4JWalletRepository,
 			database.NewNeo4JTransactionRepository,
 			database.NewNeo4JERC20Repository,
+			database.NewNeo4JProcessedTransactionRepository,
+			database.NewNeo4JBlockRepository,
+			database.NewNeo4JCheckpointRepository,
+			database.NewNeo4JGraphRepository,
+			database.NewNeo4JEdgeHistoryRepository,
+			database.NewNeo4JUnitOfWork,
 			blockchain.NewERC20DecoderService,
 			messaging.NewNATSConsumer,
+			func(consumer *messaging.NATSConsumer) domain_service.DeadLetterPublisher { return consumer },
+			source.NewTransactionSource,
+			spool.NewFileBatchSpool,
+			func(client *database.Neo4JClient) domain_service.StoreHealthChecker { return client },
 		),
 
 		// Application providers
 		fx.Provide(
+			app_service.NewReorgApplicationService,
+			app_service.NewCheckpointApplicationService,
 			app_service.NewIndexingApplicationService,
+			app_service.NewPipelineApplicationService,
+			app_service.NewTraversalApplicationService,
 		),
 
 		// Lifecycle hooks
@@ -80,15 +96,17 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Wait for shutdown signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	// Wait for a shutdown signal, or for a finite source to be exhausted
+	<-app.Done()
 
 	log.Info("Shutting down application...")
 
 	// Stop the application
-	stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownTimeout := cfg.App.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
+	stopCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
 	if err := app.Stop(stopCtx); err != nil {
@@ -102,12 +120,18 @@ func main() {
 // startIndexer starts the indexing service
 func startIndexer(
 	lifecycle fx.Lifecycle,
-	consumer *messaging.NATSConsumer,
-	indexingService domain_service.IndexingService,
+	shutdowner fx.Shutdowner,
+	txSource domain_service.TransactionSource,
+	pipelineService domain_service.PipelineService,
+	batchSpool domain_service.BatchSpool,
 	log *zap.Logger,
 	cfg *config.Config,
 	neo4jClient *database.Neo4JClient,
 ) {
+	// The pipeline outlives the start context; it is only cancelled if draining runs out of time
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	pipelineDone := make(chan struct{})
+
 	lifecycle.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			log.Info("Starting indexing service...")
@@ -124,36 +148,88 @@ func startIndexer(
 				zap.String("url", cfg.NATS.URL),
 				zap.String("stream_name", cfg.NATS.StreamName),
 				zap.String("subject_prefix", cfg.NATS.SubjectPrefix),
+				zap.String("consumer_name", cfg.NATS.ConsumerName),
+				zap.Bool("use_jetstream", cfg.NATS.UseJetStream),
 				zap.Bool("enabled", cfg.NATS.Enabled),
+				zap.Strings("networks", cfg.NATS.NetworkList()),
 			)
 
-			// Connect to NATS
-			if err := consumer.Connect(ctx); err != nil {
-				return fmt.Errorf("failed to connect to NATS: %w", err)
+			// Start the transaction source (connects to NATS for the nats source)
+			log.Info("Starting transaction source", zap.String("type", cfg.Source.Type))
+			if err := txSource.Start(ctx); err != nil {
+				return fmt.Errorf("failed to start transaction source: %w", err)
 			}
 
-			// Start message processing
-			go processMessages(ctx, consumer, indexingService, log, cfg)
+			// Start message processing; finite sources shut the application down once drained
+			go func() {
+				defer close(pipelineDone)
+				pipelineService.Run(runCtx)
+				if cfg.Source.Type != source.TypeNATS && cfg.Source.Type != "" {
+					log.Info("Transaction source exhausted, shutting down")
+					shutdowner.Shutdown()
+				}
+			}()
 
 			log.Info("Indexing service started successfully")
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info("Stopping indexing service...")
-			// Close Neo4J connection
+			defer cancelRun()
+
+			// Stop fetching, then let the pipeline commit and acknowledge what it holds
+			if err := txSource.StopReceiving(); err != nil {
+				log.Error("Failed to stop receiving transactions", zap.Error(err))
+			}
+			drainPipeline(ctx, pipelineDone, cancelRun, log)
+
+			// Only close the connections once every batch is settled
+			stopErr := txSource.Stop()
+			if batchSpool != nil {
+				if err := batchSpool.Close(); err != nil {
+					log.Error("Failed to close batch spool", zap.Error(err))
+				}
+			}
 			if err := neo4jClient.Close(ctx); err != nil {
 				log.Error("Failed to close Neo4J connection", zap.Error(err))
 			}
-			// Disconnect from NATS
-			return consumer.Disconnect()
+			return stopErr
 		},
 	})
 }
 
+// drainGracePeriod is how long the pipeline gets to reject its pending batches once the
+// shutdown timeout has expired
+const drainGracePeriod = 2 * time.Second
+
+// drainPipeline waits for the pipeline to commit and settle its pending batches. When the
+// stop context expires first, the pipeline is cancelled so the remaining batches are
+// rejected for redelivery instead of being written against closed connections.
+func drainPipeline(ctx context.Context, done <-chan struct{}, cancel context.CancelFunc, log *zap.Logger) {
+	log.Info("Draining pending batches")
+	select {
+	case <-done:
+		log.Info("Pending batches drained")
+		return
+	case <-ctx.Done():
+	}
+
+	log.Warn("Shutdown timeout reached before pending batches drained, cancelling them")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(drainGracePeriod):
+		log.Error("Pipeline did not stop after cancellation")
+	}
+}
+
 // startHealthServer starts the health check server
 func startHealthServer(
 	lifecycle fx.Lifecycle,
 	cfg *config.Config,
+	checkpointService domain_service.CheckpointService,
+	pipelineService domain_service.PipelineService,
+	neo4jClient *database.Neo4JClient,
 	logger *logger.Logger,
 ) {
 	lifecycle.Append(fx.Hook{
@@ -163,8 +239,34 @@ func startHealthServer(
 			// Create health check server
 			mux := http.NewServeMux()
 			mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte(`{"status":"ok"}`))
+				status := healthStatus(r.Context(), checkpointService, pipelineService, neo4jClient, cfg, logger)
+
+				w.Header().Set("Content-Type", "application/json")
+				if status.Status == "ok" {
+					w.WriteHeader(http.StatusOK)
+				} else {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+				json.NewEncoder(w).Encode(status)
+			})
+
+			// Operators acknowledge gaps of blocks that will not arrive, e.g. skipped by the publisher
+			mux.HandleFunc("POST /gaps/acknowledge", func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				fromBlock, fromErr := strconv.ParseUint(query.Get("from"), 10, 64)
+				toBlock, toErr := strconv.ParseUint(query.Get("to"), 10, 64)
+				if fromErr != nil || toErr != nil || fromBlock > toBlock {
+					http.Error(w, "from and to must be an ascending range of block numbers", http.StatusBadRequest)
+					return
+				}
+
+				err := checkpointService.AcknowledgeGaps(r.Context(), query.Get("network"), fromBlock, toBlock)
+				if err != nil {
+					logger.Warn("Failed to acknowledge block gaps", zap.Error(err))
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
 			})
 
 			server := &http.Server{
@@ -189,106 +291,54 @@ func startHealthServer(
 	})
 }
 
-// processMessages processes messages from NATS
-func processMessages(
+// healthResponse is the body served by the health endpoint
+type healthResponse struct {
+	Status      string                      `json:"status"`
+	Checkpoints []*entity.IndexerCheckpoint `json:"checkpoints"`
+	Gaps        []*entity.BlockGap          `json:"gaps"`
+	Networks    []*entity.NetworkStats      `json:"networks"`
+	Neo4J       *entity.RetryStats          `json:"neo4j_retries"`
+	Spool       *entity.SpoolStats          `json:"spool"`
+}
+
+// healthStatus reports the per-network checkpoints, open block gaps, throughput and lag,
+// how often Neo4J writes had to be retried, and the batches spooled while it was down
+func healthStatus(
 	ctx context.Context,
-	consumer *messaging.NATSConsumer,
-	indexingService domain_service.IndexingService,
-	logger *zap.Logger,
+	checkpointService domain_service.CheckpointService,
+	pipelineService domain_service.PipelineService,
+	neo4jClient *database.Neo4JClient,
 	cfg *config.Config,
-) {
-	msgChan := consumer.GetMessageChannel()
-	batch := make([]*entity.Transaction, 0, cfg.App.BatchSize)
-	ticker := time.NewTicker(5 * time.Second) // Flush batch every 5 seconds
-	defer ticker.Stop()
-
-	// Create a worker pool for parallel batch processing
-	type batchJob struct {
-		transactions []*entity.Transaction
-	}
-	jobChan := make(chan batchJob, cfg.App.WorkerPoolSize)
-	var wg sync.WaitGroup
-
-	// Start worker pool
-	for i := 0; i < cfg.App.WorkerPoolSize; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			logger.Info("Starting batch processing worker", zap.Int("worker_id", workerID))
-
-			for job := range jobChan {
-				// Process the batch
-				if err := indexingService.ProcessTransactionBatch(ctx, job.transactions); err != nil {
-					logger.Error("Failed to process transaction batch",
-						zap.Error(err),
-						zap.Int("worker_id", workerID),
-						zap.Int("batch_size", len(job.transactions)))
-				} else {
-					logger.Info("Successfully processed batch",
-						zap.Int("worker_id", workerID),
-						zap.Int("batch_size", len(job.transactions)))
-				}
-			}
-		}(i)
+	logger *logger.Logger,
+) *healthResponse {
+	response := &healthResponse{
+		Status: "ok",
+		Spool:  pipelineService.Spool(),
 	}
 
-	// Process incoming messages
-	for {
-		select {
-		case <-ctx.Done():
-			// Process remaining batch
-			if len(batch) > 0 {
-				// Clone the batch to avoid race conditions
-				txBatch := make([]*entity.Transaction, len(batch))
-				copy(txBatch, batch)
-				jobChan <- batchJob{transactions: txBatch}
-			}
-
-			// Close job channel and wait for workers to finish
-			close(jobChan)
-			wg.Wait()
-			return
-
-		case tx := <-msgChan:
-			if tx == nil {
-				// Channel closed, clean up
-				if len(batch) > 0 {
-					// Clone the batch to avoid race conditions
-					txBatch := make([]*entity.Transaction, len(batch))
-					copy(txBatch, batch)
-					jobChan <- batchJob{transactions: txBatch}
-				}
-
-				// Close job channel and wait for workers to finish
-				close(jobChan)
-				wg.Wait()
-				return
-			}
-
-			batch = append(batch, tx)
-
-			// Process batch if it's full
-			if len(batch) >= cfg.App.BatchSize {
-				// Clone the batch to avoid race conditions
-				txBatch := make([]*entity.Transaction, len(batch))
-				copy(txBatch, batch)
-				jobChan <- batchJob{transactions: txBatch}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Health.Timeout)
+	defer cancel()
 
-				// Reset batch
-				batch = batch[:0]
-			}
+	// Progress that cannot be read cannot be vouched for
+	checkpoints, err := checkpointService.Checkpoints(ctx)
+	if err != nil {
+		logger.Warn("Failed to read checkpoints for health check", zap.Error(err))
+		response.Status = "degraded"
+	}
+	response.Checkpoints = checkpoints
 
-		case <-ticker.C:
-			// Flush batch periodically
-			if len(batch) > 0 {
-				// Clone the batch to avoid race conditions
-				txBatch := make([]*entity.Transaction, len(batch))
-				copy(txBatch, batch)
-				jobChan <- batchJob{transactions: txBatch}
+	gaps, err := checkpointService.Gaps(ctx)
+	if err != nil {
+		logger.Warn("Failed to read block gaps for health check", zap.Error(err))
+		response.Status = "degraded"
+	}
+	response.Gaps = gaps
 
-				// Reset batch
-				batch = batch[:0]
-			}
-		}
+	if len(response.Gaps) > 0 || !response.Spool.StoreAvailable || response.Spool.Batches > 0 {
+		response.Status = "degraded"
 	}
+	response.Networks = pipelineService.Stats(ctx)
+	response.Neo4J = neo4jClient.RetryStats()
+
+	return response
 }
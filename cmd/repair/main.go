@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/database"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// repairOptions holds the command line options of the repair command
+type repairOptions struct {
+	types        []string
+	batchSize    int
+	skipWallets  bool
+	skipRollups  bool
+	skipHoldings bool
+	dryRun       bool
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := repairOptions{}
+	var types string
+	flag.StringVar(&types, "types", strings.Join(entity.AggregatedRelationshipTypes, ","), "comma-separated relationship types to repair")
+	flag.IntVar(&opts.batchSize, "batch-size", 1000, "aggregates read and written per transaction")
+	flag.BoolVar(&opts.skipWallets, "skip-wallets", false, "do not recompute wallet total_sent and total_received")
+	flag.BoolVar(&opts.skipRollups, "skip-rollups", false, "do not rebuild the time-bucketed rollups")
+	flag.BoolVar(&opts.skipHoldings, "skip-holdings", false, "do not recompute token holder balances")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "only report the totals that would change")
+	flag.Parse()
+
+	for _, relType := range strings.Split(types, ",") {
+		if relType = strings.ToUpper(strings.TrimSpace(relType)); relType != "" {
+			opts.types = append(opts.types, relType)
+		}
+	}
+	if opts.batchSize <= 0 {
+		opts.batchSize = 1000
+	}
+
+	// Create logger
+	log, err := logger.NewLogger(cfg.App.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+	log = log.WithComponent("repair")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, opts, log); err != nil {
+		log.Error("Repair failed", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+// run recomputes the relationship totals first, since wallet totals and token holdings are
+// derived from them
+func run(ctx context.Context, cfg *config.Config, opts repairOptions, log *logger.Logger) error {
+	neo4jClient := database.NewNeo4JClient(&cfg.Neo4J, log)
+	if err := neo4jClient.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Neo4J: %w", err)
+	}
+	defer neo4jClient.Close(context.Background())
+
+	repairRepo := database.NewNeo4JRepairRepository(neo4jClient, log)
+
+	log.Info("Starting repair",
+		zap.Strings("relationship_types", opts.types),
+		zap.Bool("wallets", !opts.skipWallets),
+		zap.Bool("rollups", !opts.skipRollups && cfg.Neo4J.RollupBucket > 0),
+		zap.Bool("holdings", !opts.skipHoldings),
+		zap.Bool("dry_run", opts.dryRun))
+
+	for _, relType := range opts.types {
+		report, err := repairRepo.RepairEdgeTotals(ctx, relType, opts.batchSize, opts.dryRun)
+		if err != nil {
+			return err
+		}
+		logReport(log, report, opts.dryRun)
+
+		if opts.skipRollups || cfg.Neo4J.RollupBucket <= 0 || !entity.HasRollups(relType) {
+			continue
+		}
+		report, err = repairRepo.RepairEdgeRollups(ctx, relType, opts.batchSize, opts.dryRun)
+		if err != nil {
+			return err
+		}
+		logReport(log, report, opts.dryRun)
+	}
+
+	if !opts.skipWallets {
+		report, err := repairRepo.RepairWalletTotals(ctx, opts.batchSize, opts.dryRun)
+		if err != nil {
+			return err
+		}
+		logReport(log, report, opts.dryRun)
+	}
+
+	if !opts.skipHoldings {
+		report, err := repairRepo.RepairTokenHoldings(ctx, opts.batchSize, opts.dryRun)
+		if err != nil {
+			return err
+		}
+		logReport(log, report, opts.dryRun)
+	}
+
+	log.Info("Repair completed")
+	return nil
+}
+
+// logReport logs the outcome of one repair scope
+func logReport(log *logger.Logger, report *entity.RepairReport, dryRun bool) {
+	message := "Repaired totals"
+	if dryRun {
+		message = "Totals that would be repaired"
+	}
+	log.Info(message,
+		zap.String("scope", report.Scope),
+		zap.Int64("scanned", report.Scanned),
+		zap.Int64("repaired", report.Repaired),
+		zap.Int64("skipped", report.Skipped),
+		zap.Int64("invalid", report.Invalid))
+}
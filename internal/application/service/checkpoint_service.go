@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxCheckpointAttempts bounds how often a checkpoint update is recomputed when concurrent
+// batches of the same network update the checkpoint first
+const maxCheckpointAttempts = 5
+
+// CheckpointApplicationService implements CheckpointService
+type CheckpointApplicationService struct {
+	checkpointRepo repository.CheckpointRepository
+	logger         *logger.Logger
+}
+
+// NewCheckpointApplicationService creates a new checkpoint application service
+func NewCheckpointApplicationService(checkpointRepo repository.CheckpointRepository, logger *logger.Logger) service.CheckpointService {
+	return &CheckpointApplicationService{
+		checkpointRepo: checkpointRepo,
+		logger:         logger.WithComponent("checkpoint-service"),
+	}
+}
+
+// networkProgress summarizes the blocks and stream sequence of a batch for one network
+type networkProgress struct {
+	blocks   map[uint64]string
+	sequence uint64
+}
+
+// RecordBatch advances the checkpoints with a committed batch and reports block gaps
+func (s *CheckpointApplicationService) RecordBatch(ctx context.Context, messages []*entity.TransactionMessage) error {
+	progress := make(map[string]*networkProgress)
+	for _, msg := range messages {
+		tx := msg.Transaction
+		p, ok := progress[tx.Network]
+		if !ok {
+			p = &networkProgress{blocks: make(map[uint64]string)}
+			progress[tx.Network] = p
+		}
+
+		if msg.Sequence > p.sequence {
+			p.sequence = msg.Sequence
+		}
+
+		number, err := tx.BlockNumberUint64()
+		if err != nil {
+			continue
+		}
+		p.blocks[number] = tx.BlockHash
+	}
+
+	for network, p := range progress {
+		if len(p.blocks) == 0 {
+			continue
+		}
+		if err := s.advance(ctx, network, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// advance applies the blocks of a batch to the checkpoint of a network
+func (s *CheckpointApplicationService) advance(ctx context.Context, network string, p *networkProgress) error {
+	return s.update(ctx, network, func(previous *entity.IndexerCheckpoint, gaps []*entity.BlockGap, now time.Time) (*entity.IndexerCheckpoint, []*entity.BlockGap) {
+		return s.applyBlocks(network, previous, gaps, p, now)
+	})
+}
+
+// update applies a change to the checkpoint and gaps of a network. The checkpoint and its gaps
+// are read, changed and written back only if no other batch wrote them in between; otherwise
+// the change is recomputed from the newer state. A change returning a nil checkpoint writes
+// nothing.
+func (s *CheckpointApplicationService) update(
+	ctx context.Context,
+	network string,
+	change func(*entity.IndexerCheckpoint, []*entity.BlockGap, time.Time) (*entity.IndexerCheckpoint, []*entity.BlockGap),
+) error {
+	for attempt := 1; attempt <= maxCheckpointAttempts; attempt++ {
+		previous, err := s.checkpointRepo.GetCheckpoint(ctx, network)
+		if err != nil {
+			return fmt.Errorf("failed to get checkpoint for %s: %w", network, err)
+		}
+		gaps, err := s.checkpointRepo.GetGaps(ctx, network)
+		if err != nil {
+			return fmt.Errorf("failed to get block gaps for %s: %w", network, err)
+		}
+
+		checkpoint, gaps := change(previous, gaps, time.Now().UTC())
+		if checkpoint == nil {
+			return nil
+		}
+
+		saved, err := s.checkpointRepo.SaveCheckpoint(ctx, checkpoint, gaps)
+		if err != nil {
+			return err
+		}
+		if saved {
+			s.logger.Debug("Checkpoint advanced",
+				zap.String("network", network),
+				zap.Uint64("last_block", checkpoint.LastBlock),
+				zap.Uint64("highest_block", checkpoint.HighestBlock),
+				zap.Uint64("stream_sequence", checkpoint.StreamSequence))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to save checkpoint for %s: updated concurrently %d times", network, maxCheckpointAttempts)
+}
+
+// SettleGaps closes the open gaps of a network once every transaction read from its source is
+// settled. Blocks are published in order, so a block still missing then had no transactions.
+func (s *CheckpointApplicationService) SettleGaps(ctx context.Context, network string) error {
+	return s.closeGaps(ctx, network, 0, math.MaxUint64, "Block gap closed, its blocks had no transactions")
+}
+
+// AcknowledgeGaps closes the part of the open gaps of a network between fromBlock and toBlock,
+// for blocks an operator knows will not arrive
+func (s *CheckpointApplicationService) AcknowledgeGaps(ctx context.Context, network string, fromBlock, toBlock uint64) error {
+	if fromBlock > toBlock {
+		return fmt.Errorf("invalid block range %d-%d", fromBlock, toBlock)
+	}
+	return s.closeGaps(ctx, network, fromBlock, toBlock, "Block gap acknowledged")
+}
+
+// closeGaps removes a block range from the open gaps of a network and moves its last block up
+// to the new lowest gap
+func (s *CheckpointApplicationService) closeGaps(ctx context.Context, network string, fromBlock, toBlock uint64, message string) error {
+	return s.update(ctx, network, func(previous *entity.IndexerCheckpoint, gaps []*entity.BlockGap, now time.Time) (*entity.IndexerCheckpoint, []*entity.BlockGap) {
+		if previous == nil || len(gaps) == 0 {
+			return nil, nil
+		}
+
+		closed := false
+		for _, gap := range gaps {
+			if gap.FromBlock <= toBlock && gap.ToBlock >= fromBlock {
+				closed = true
+				s.logger.Info(message,
+					zap.String("network", network),
+					zap.Uint64("from_block", max(gap.FromBlock, fromBlock)),
+					zap.Uint64("to_block", min(gap.ToBlock, toBlock)))
+			}
+		}
+		if !closed {
+			return nil, nil
+		}
+
+		remaining := removeBlocks(gaps, fromBlock, toBlock)
+		checkpoint := *previous
+		checkpoint.UpdatedAt = now
+		checkpoint.LastBlock = lastContiguousBlock(checkpoint.HighestBlock, remaining)
+		return &checkpoint, remaining
+	})
+}
+
+// applyBlocks returns the checkpoint and open gaps of a network after the blocks of a batch:
+// blocks above the highest recorded block open gaps for the ranges they skip, late blocks
+// shrink or split the gap they fall into, and the checkpoint's last block is the last one
+// below the lowest open gap
+func (s *CheckpointApplicationService) applyBlocks(
+	network string,
+	previous *entity.IndexerCheckpoint,
+	gaps []*entity.BlockGap,
+	p *networkProgress,
+	now time.Time,
+) (*entity.IndexerCheckpoint, []*entity.BlockGap) {
+	numbers := make([]uint64, 0, len(p.blocks))
+	for number := range p.blocks {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	checkpoint := &entity.IndexerCheckpoint{Network: network, UpdatedAt: now}
+	if previous != nil {
+		checkpoint.LastBlock = previous.LastBlock
+		checkpoint.HighestBlock = previous.HighestBlock
+		checkpoint.BlockHash = previous.BlockHash
+		checkpoint.StreamSequence = previous.StreamSequence
+		checkpoint.Version = previous.Version
+	} else {
+		// Tracking starts with the first block seen on the network
+		checkpoint.LastBlock = numbers[0]
+		checkpoint.HighestBlock = numbers[0]
+		checkpoint.BlockHash = p.blocks[numbers[0]]
+	}
+	if p.sequence > checkpoint.StreamSequence {
+		checkpoint.StreamSequence = p.sequence
+	}
+
+	for _, number := range numbers {
+		if number > checkpoint.HighestBlock {
+			if number > checkpoint.HighestBlock+1 {
+				gap := &entity.BlockGap{Network: network, FromBlock: checkpoint.HighestBlock + 1, ToBlock: number - 1, DetectedAt: now}
+				gaps = append(gaps, gap)
+				s.logger.Warn("Block gap detected",
+					zap.String("network", network),
+					zap.Uint64("from_block", gap.FromBlock),
+					zap.Uint64("to_block", gap.ToBlock),
+					zap.Uint64("missing_blocks", gap.Size()))
+			}
+			checkpoint.HighestBlock = number
+			checkpoint.BlockHash = p.blocks[number]
+			continue
+		}
+		gaps = s.fillGap(gaps, number)
+	}
+
+	checkpoint.LastBlock = lastContiguousBlock(checkpoint.HighestBlock, gaps)
+
+	return checkpoint, gaps
+}
+
+// lastContiguousBlock returns the last block below the lowest open gap
+func lastContiguousBlock(highest uint64, gaps []*entity.BlockGap) uint64 {
+	last := highest
+	for _, gap := range gaps {
+		if gap.FromBlock-1 < last {
+			last = gap.FromBlock - 1
+		}
+	}
+	return last
+}
+
+// fillGap removes a late block from the gap it falls into; the gap only closes once every
+// block of its range has arrived
+func (s *CheckpointApplicationService) fillGap(gaps []*entity.BlockGap, number uint64) []*entity.BlockGap {
+	for _, gap := range gaps {
+		if gap.FromBlock == number && gap.ToBlock == number {
+			s.logger.Info("Block gap filled by late blocks",
+				zap.String("network", gap.Network),
+				zap.Uint64("from_block", gap.FromBlock),
+				zap.Uint64("to_block", gap.ToBlock))
+		}
+	}
+	return removeBlocks(gaps, number, number)
+}
+
+// removeBlocks removes a block range from the gaps, shrinking or splitting the gaps it overlaps
+func removeBlocks(gaps []*entity.BlockGap, fromBlock, toBlock uint64) []*entity.BlockGap {
+	remaining := make([]*entity.BlockGap, 0, len(gaps)+1)
+	for _, gap := range gaps {
+		if gap.ToBlock < fromBlock || gap.FromBlock > toBlock {
+			remaining = append(remaining, gap)
+			continue
+		}
+		if gap.FromBlock < fromBlock {
+			remaining = append(remaining, &entity.BlockGap{Network: gap.Network, FromBlock: gap.FromBlock, ToBlock: fromBlock - 1, DetectedAt: gap.DetectedAt})
+		}
+		if gap.ToBlock > toBlock {
+			remaining = append(remaining, &entity.BlockGap{Network: gap.Network, FromBlock: toBlock + 1, ToBlock: gap.ToBlock, DetectedAt: gap.DetectedAt})
+		}
+	}
+	return remaining
+}
+
+// Checkpoints returns the current checkpoint of every network
+func (s *CheckpointApplicationService) Checkpoints(ctx context.Context) ([]*entity.IndexerCheckpoint, error) {
+	return s.checkpointRepo.GetCheckpoints(ctx)
+}
+
+// Gaps returns the block gaps detected and not yet filled
+func (s *CheckpointApplicationService) Gaps(ctx context.Context) ([]*entity.BlockGap, error) {
+	return s.checkpointRepo.GetGaps(ctx, "")
+}
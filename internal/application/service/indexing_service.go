@@ -3,7 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"time"
 
 	"crypto-bubble-map-indexer/internal/domain/entity"
 	"crypto-bubble-map-indexer/internal/domain/repository"
@@ -18,7 +18,11 @@ type IndexingApplicationService struct {
 	walletRepo      repository.WalletRepository
 	transactionRepo repository.TransactionRepository
 	erc20Repo       repository.ERC20Repository
+	processedRepo   repository.ProcessedTransactionRepository
+	edgeHistoryRepo repository.EdgeHistoryRepository
 	erc20Decoder    service.ERC20DecoderService
+	reorgService    service.ReorgService
+	unitOfWork      repository.UnitOfWork
 	logger          *logger.Logger
 }
 
@@ -27,62 +31,147 @@ func NewIndexingApplicationService(
 	walletRepo repository.WalletRepository,
 	transactionRepo repository.TransactionRepository,
 	erc20Repo repository.ERC20Repository,
+	processedRepo repository.ProcessedTransactionRepository,
+	edgeHistoryRepo repository.EdgeHistoryRepository,
 	erc20Decoder service.ERC20DecoderService,
+	reorgService service.ReorgService,
+	unitOfWork repository.UnitOfWork,
 	logger *logger.Logger,
 ) service.IndexingService {
 	return &IndexingApplicationService{
 		walletRepo:      walletRepo,
 		transactionRepo: transactionRepo,
 		erc20Repo:       erc20Repo,
+		processedRepo:   processedRepo,
+		edgeHistoryRepo: edgeHistoryRepo,
 		erc20Decoder:    erc20Decoder,
+		reorgService:    reorgService,
+		unitOfWork:      unitOfWork,
 		logger:          logger.WithComponent("indexing-service"),
 	}
 }
 
-// ProcessTransaction processes a transaction event and indexes it
+// ProcessTransaction processes a transaction event and indexes it through the batch path,
+// so single transactions get the same idempotency and reorg handling
 func (s *IndexingApplicationService) ProcessTransaction(ctx context.Context, tx *entity.Transaction) error {
 	s.logger.Info("Processing transaction", zap.String("hash", tx.Hash))
+	_, err := s.ProcessTransactionBatch(ctx, []*entity.Transaction{tx})
+	return err
+}
 
-	// Create or update wallets
-	if err := s.createOrUpdateWallets(ctx, tx); err != nil {
-		return fmt.Errorf("failed to create/update wallets: %w", err)
-	}
+// ProcessTransactionBatch processes multiple transactions in batch. The result tells the
+// caller whether the batch committed, so it can acknowledge or redeliver its messages.
+func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context, transactions []*entity.Transaction) (*entity.BatchResult, error) {
+	s.logger.Info("Processing transaction batch", zap.Int("count", len(transactions)))
+	result := &entity.BatchResult{Received: len(transactions)}
 
-	// Create direct relationship between wallets
-	rel := &entity.TransactionRelationship{
-		FromAddress: tx.From,
-		ToAddress:   tx.To,
-		Value:       tx.Value,
-		GasPrice:    tx.GasPrice,
-		Timestamp:   tx.Timestamp,
-		TxHash:      tx.Hash,
+	if err := validateValues(transactions); err != nil {
+		return result, err
 	}
 
-	if err := s.transactionRepo.CreateTransactionRelationship(ctx, rel); err != nil {
-		return fmt.Errorf("failed to create transaction relationship: %w", err)
+	// Decoding only reads the transactions, so a retried unit of work does not repeat it
+	transfers := s.decodeTransfers(ctx, transactions)
+
+	// Every step of the batch runs in one transaction: the block hashes and processed markers
+	// it checks cannot change before its writes commit, a failure leaves no wallets without
+	// their edges, and the caller redelivers the whole batch
+	var batch *preparedBatch
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Roll back orphaned blocks before applying their replacements
+		pending, err := s.reorgService.Reconcile(ctx, transactions)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile block hashes: %w", err)
+		}
+
+		// Drop duplicates within the batch and transactions indexed by an earlier delivery,
+		// so redelivered or replayed transactions never inflate counters or edge totals
+		pending, err = s.filterUnprocessed(ctx, pending)
+		if err != nil {
+			return err
+		}
+
+		batch, err = s.prepareBatch(pending, transfers)
+		if err != nil {
+			return err
+		}
+		if len(batch.transactions) == 0 {
+			return nil
+		}
+		return s.writeBatch(ctx, batch)
+	})
+	if batch != nil {
+		result.Skipped = result.Received - len(batch.transactions)
+		result.Indexed = len(batch.transactions)
+		result.Wallets = len(batch.walletMap)
+		result.Relationships = len(batch.relationships)
+		result.ERC20Relationships = len(batch.erc20Relationships)
+		result.Contracts = len(batch.contractMap)
+	}
+	if err != nil {
+		return result, err
 	}
+	result.Committed = true
 
-	// Process ERC20 transfers if applicable
-	if err := s.processERC20Transfers(ctx, tx); err != nil {
-		s.logger.Error("Failed to process ERC20 transfers",
-			zap.String("tx_hash", tx.Hash),
-			zap.Error(err))
-		// Don't fail the entire transaction processing for ERC20 errors
+	if result.Indexed == 0 {
+		s.logger.Info("All transactions in batch were already indexed, skipping")
+		return result, nil
 	}
 
-	s.logger.Info("Successfully processed transaction", zap.String("hash", tx.Hash))
+	s.logger.Info("Successfully processed transaction batch",
+		zap.Int("count", result.Indexed),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("erc20_transfers", result.ERC20Relationships),
+		zap.Int("erc20_contracts", result.Contracts))
+	return result, nil
+}
+
+// preparedBatch holds the graph writes of the transactions a batch indexes
+type preparedBatch struct {
+	transactions       []*entity.Transaction
+	indexed            []*entity.IndexedTransaction
+	walletMap          map[string]*entity.Wallet
+	relationships      []*entity.TransactionRelationship
+	erc20Relationships []*entity.ERC20TransferRelationship
+	contractMap        map[string]*entity.ERC20Contract
+}
+
+// validateValues checks that every transaction value is a valid amount; a malformed one fails
+// the batch rather than being indexed with a value it does not carry
+func validateValues(transactions []*entity.Transaction) error {
+	for _, tx := range transactions {
+		if _, err := entity.ParseAmount(tx.Value); err != nil {
+			return fmt.Errorf("transaction %s has an invalid value: %w", tx.Hash, err)
+		}
+	}
 	return nil
 }
 
-// ProcessTransactionBatch processes multiple transactions in batch
-func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context, transactions []*entity.Transaction) error {
-	s.logger.Info("Processing transaction batch", zap.Int("count", len(transactions)))
+// decodeTransfers decodes the ERC20 transfers of each transaction; transactions that fail to
+// decode are still indexed as plain transfers
+func (s *IndexingApplicationService) decodeTransfers(ctx context.Context, transactions []*entity.Transaction) map[*entity.Transaction][]*entity.ERC20Transfer {
+	decoded := make(map[*entity.Transaction][]*entity.ERC20Transfer, len(transactions))
+	for _, tx := range transactions {
+		transfers, err := s.erc20Decoder.DecodeERC20Transfer(ctx, tx)
+		if err != nil {
+			s.logger.Warn("Failed to decode ERC20 transfers",
+				zap.String("tx_hash", tx.Hash),
+				zap.Error(err))
+			continue
+		}
+		decoded[tx] = transfers
+	}
+	return decoded
+}
 
-	// Prepare batch data
-	var relationships []*entity.TransactionRelationship
-	var erc20Relationships []*entity.ERC20TransferRelationship
-	walletMap := make(map[string]*entity.Wallet)
-	contractMap := make(map[string]*entity.ERC20Contract)
+// prepareBatch builds the wallets, relationships, contracts and rollback journal of the
+// transactions to index
+func (s *IndexingApplicationService) prepareBatch(transactions []*entity.Transaction, decoded map[*entity.Transaction][]*entity.ERC20Transfer) (*preparedBatch, error) {
+	batch := &preparedBatch{
+		transactions: transactions,
+		indexed:      make([]*entity.IndexedTransaction, 0, len(transactions)),
+		walletMap:    make(map[string]*entity.Wallet),
+		contractMap:  make(map[string]*entity.ERC20Contract),
+	}
 
 	// Detailed transaction analysis for debugging
 	transactionsWithData := 0
@@ -100,7 +189,13 @@ func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context
 		}
 
 		// Prepare wallet data
-		s.prepareWalletData(tx, walletMap)
+		if err := s.prepareWalletData(tx, batch.walletMap); err != nil {
+			return nil, err
+		}
+
+		// Journal the graph contributions of this transaction so a reorg can roll them back
+		marker := entity.NewIndexedTransaction(tx)
+		batch.indexed = append(batch.indexed, marker)
 
 		// Prepare regular transaction relationship data
 		rel := &entity.TransactionRelationship{
@@ -110,48 +205,48 @@ func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context
 			GasPrice:    tx.GasPrice,
 			Timestamp:   tx.Timestamp,
 			TxHash:      tx.Hash,
+			Network:     tx.Network,
 		}
-		relationships = append(relationships, rel)
+		batch.relationships = append(batch.relationships, rel)
 
 		// Process ERC20 transfers for this transaction
-		transfers, err := s.erc20Decoder.DecodeERC20Transfer(ctx, tx)
-		if err != nil {
-			s.logger.Warn("Failed to decode ERC20 transfers",
-				zap.String("tx_hash", tx.Hash),
-				zap.Error(err))
-		} else if len(transfers) > 0 {
-			for _, transfer := range transfers {
-				// Create relationship for this transfer/interaction
-				relationship := &entity.ERC20TransferRelationship{
-					FromAddress:      transfer.From,
-					ToAddress:        transfer.To,
-					ContractAddress:  transfer.ContractAddress,
-					Value:            transfer.Value,
-					TxHash:           transfer.TxHash,
-					Timestamp:        transfer.Timestamp,
-					Network:          transfer.Network,
-					InteractionType:  transfer.InteractionType,
-					MethodSignature:  transfer.MethodSignature,
-					TotalValue:       transfer.Value,
-					TransactionCount: 1,
-					FirstInteraction: transfer.Timestamp,
-					LastInteraction:  transfer.Timestamp,
-				}
-				erc20Relationships = append(erc20Relationships, relationship)
-
-				// Track unique contracts for creation
-				if _, exists := contractMap[transfer.ContractAddress]; !exists && transfer.ContractAddress != "ETH" {
-					contractMap[transfer.ContractAddress] = s.createEnhancedContract(transfer)
+		for _, transfer := range decoded[tx] {
+			// Create relationship for this transfer/interaction
+			relationship := &entity.ERC20TransferRelationship{
+				FromAddress:      transfer.From,
+				ToAddress:        transfer.To,
+				ContractAddress:  transfer.ContractAddress,
+				Value:            transfer.Value,
+				TxHash:           transfer.TxHash,
+				Timestamp:        transfer.Timestamp,
+				Network:          transfer.Network,
+				InteractionType:  transfer.InteractionType,
+				MethodSignature:  transfer.MethodSignature,
+				TotalValue:       transfer.Value,
+				TransactionCount: 1,
+				FirstInteraction: transfer.Timestamp,
+				LastInteraction:  transfer.Timestamp,
+			}
+			batch.erc20Relationships = append(batch.erc20Relationships, relationship)
+			marker.AddERC20Effect(relationship)
+
+			// Track unique contracts for creation; native transfers reference the network's native asset
+			contractKey := entity.NetworkScopedKey(transfer.Network, transfer.ContractAddress)
+			if _, exists := batch.contractMap[contractKey]; !exists {
+				if entity.IsNativeAsset(transfer.ContractAddress) {
+					batch.contractMap[contractKey] = entity.NewNativeAsset(transfer.Network, transfer.Timestamp)
+				} else {
+					batch.contractMap[contractKey] = s.createEnhancedContract(transfer)
 				}
-
-				s.logger.Debug("Processed contract interaction",
-					zap.String("tx_hash", tx.Hash),
-					zap.String("interaction_type", string(transfer.InteractionType)),
-					zap.String("from", transfer.From),
-					zap.String("to", transfer.To),
-					zap.String("contract", transfer.ContractAddress),
-					zap.String("value", transfer.Value))
 			}
+
+			s.logger.Debug("Processed contract interaction",
+				zap.String("tx_hash", tx.Hash),
+				zap.String("interaction_type", string(transfer.InteractionType)),
+				zap.String("from", transfer.From),
+				zap.String("to", transfer.To),
+				zap.String("contract", transfer.ContractAddress),
+				zap.String("value", transfer.Value))
 		}
 	}
 
@@ -160,11 +255,26 @@ func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context
 		zap.Int("total_transactions", len(transactions)),
 		zap.Int("transactions_with_data", transactionsWithData),
 		zap.Int("transactions_to_contracts", transactionsToContracts),
-		zap.Int("erc20_transfers_found", len(erc20Relationships)),
-		zap.Int("erc20_contracts_found", len(contractMap)))
+		zap.Int("erc20_transfers_found", len(batch.erc20Relationships)),
+		zap.Int("erc20_contracts_found", len(batch.contractMap)))
+
+	return batch, nil
+}
+
+// writeBatch applies the prepared batch; run inside a unit of work it may be retried
+func (s *IndexingApplicationService) writeBatch(ctx context.Context, batch *preparedBatch) error {
+	relationships := batch.relationships
+	erc20Relationships := batch.erc20Relationships
+
+	// Markers are created first and commit together with the writes they guard: a concurrent
+	// delivery of the same transaction fails on the unique constraint instead of applying it a
+	// second time, and its retried unit of work skips the transaction
+	if err := s.processedRepo.MarkProcessed(ctx, batch.indexed); err != nil {
+		return fmt.Errorf("failed to mark transactions as processed: %w", err)
+	}
 
 	// Batch create/update wallets
-	if err := s.batchCreateOrUpdateWallets(ctx, walletMap); err != nil {
+	if err := s.batchCreateOrUpdateWallets(ctx, batch.walletMap); err != nil {
 		return fmt.Errorf("failed to batch create/update wallets: %w", err)
 	}
 
@@ -184,16 +294,10 @@ func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context
 		return fmt.Errorf("failed to batch create relationships: %w", err)
 	}
 
-	// Batch create/update ERC20 contracts
-	contractsCreated := 0
-	for _, contract := range contractMap {
+	// Batch create/update ERC20 contracts; relationships below MATCH on them, so a failure fails the batch
+	for _, contract := range batch.contractMap {
 		if err := s.erc20Repo.CreateOrUpdateERC20Contract(ctx, contract); err != nil {
-			s.logger.Error("Failed to create/update ERC20 contract in batch",
-				zap.String("contract", contract.Address),
-				zap.Error(err))
-			// Continue processing other contracts
-		} else {
-			contractsCreated++
+			return fmt.Errorf("failed to create/update ERC20 contract %s: %w", contract.Address, err)
 		}
 	}
 
@@ -203,124 +307,155 @@ func (s *IndexingApplicationService) ProcessTransactionBatch(ctx context.Context
 			zap.Int("count", len(erc20Relationships)))
 
 		if err := s.erc20Repo.BatchCreateERC20TransferRelationships(ctx, erc20Relationships); err != nil {
-			s.logger.Error("Failed to batch create ERC20 transfer relationships",
-				zap.Int("count", len(erc20Relationships)),
-				zap.Error(err))
-			// Don't return error to avoid failing the entire batch
-		} else {
-			s.logger.Info("Successfully created ERC20 transfer relationships in batch",
-				zap.Int("count", len(erc20Relationships)))
+			return fmt.Errorf("failed to batch create ERC20 transfer relationships: %w", err)
 		}
+
+		s.logger.Info("Successfully created ERC20 transfer relationships in batch",
+			zap.Int("count", len(erc20Relationships)))
 	} else {
 		s.logger.Info("No ERC20 transfer relationships to create in this batch")
 	}
 
-	s.logger.Info("Successfully processed transaction batch",
-		zap.Int("count", len(transactions)),
-		zap.Int("erc20_transfers", len(erc20Relationships)),
-		zap.Int("erc20_contracts_created", contractsCreated))
-	return nil
-}
-
-// GetWalletAnalytics retrieves analytics for a wallet
-func (s *IndexingApplicationService) GetWalletAnalytics(ctx context.Context, address string) (*entity.WalletStats, error) {
-	return s.walletRepo.GetWalletStats(ctx, address)
-}
+	if err := s.walletRepo.LinkSameAddressWallets(ctx, batchWallets(batch.walletMap, erc20Relationships)); err != nil {
+		return fmt.Errorf("failed to link same-address wallets: %w", err)
+	}
 
-// GetBubbleAnalysis retrieves bubble analysis data
-func (s *IndexingApplicationService) GetBubbleAnalysis(ctx context.Context, minConnections int, limit int) ([]*entity.Wallet, error) {
-	return s.walletRepo.GetBubbleWallets(ctx, minConnections, limit)
-}
+	if err := s.reorgService.RecordBlocks(ctx, batch.transactions); err != nil {
+		return err
+	}
 
-// GetTransactionPath finds transaction path between wallets
-func (s *IndexingApplicationService) GetTransactionPath(ctx context.Context, fromAddress, toAddress string, maxHops int) ([]*entity.TransactionNode, error) {
-	return s.transactionRepo.GetTransactionPath(ctx, fromAddress, toAddress, maxHops)
+	return nil
 }
 
-// createOrUpdateWallets creates or updates wallet entities
-func (s *IndexingApplicationService) createOrUpdateWallets(ctx context.Context, tx *entity.Transaction) error {
-	// Create/update sender wallet
-	senderWallet := &entity.Wallet{
-		Address:           tx.From,
-		FirstSeen:         tx.Timestamp,
-		LastSeen:          tx.Timestamp,
-		TotalTransactions: 1,
-		TotalSent:         tx.Value,
-		TotalReceived:     "0",
-		Network:           tx.Network,
+// filterUnprocessed removes in-batch duplicates and transactions that were already indexed
+func (s *IndexingApplicationService) filterUnprocessed(ctx context.Context, transactions []*entity.Transaction) ([]*entity.Transaction, error) {
+	seen := make(map[string]bool, len(transactions))
+	unique := make([]*entity.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		if seen[tx.Key()] {
+			continue
+		}
+		seen[tx.Key()] = true
+		unique = append(unique, tx)
 	}
 
-	if err := s.walletRepo.CreateOrUpdateWallet(ctx, senderWallet); err != nil {
-		return fmt.Errorf("failed to create/update sender wallet: %w", err)
+	unprocessed, err := s.processedRepo.FilterUnprocessed(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check processed transactions: %w", err)
 	}
 
-	// Create/update receiver wallet
-	receiverWallet := &entity.Wallet{
-		Address:           tx.To,
-		FirstSeen:         tx.Timestamp,
-		LastSeen:          tx.Timestamp,
-		TotalTransactions: 1,
-		TotalSent:         "0",
-		TotalReceived:     tx.Value,
-		Network:           tx.Network,
+	if skipped := len(transactions) - len(unprocessed); skipped > 0 {
+		s.logger.Info("Skipping already indexed transactions",
+			zap.Int("skipped", skipped),
+			zap.Int("remaining", len(unprocessed)))
 	}
 
-	if err := s.walletRepo.CreateOrUpdateWallet(ctx, receiverWallet); err != nil {
-		return fmt.Errorf("failed to create/update receiver wallet: %w", err)
-	}
+	return unprocessed, nil
+}
 
-	return nil
+// GetWalletAnalytics retrieves analytics for the wallet of an address on a network
+func (s *IndexingApplicationService) GetWalletAnalytics(ctx context.Context, network, address string) (*entity.WalletStats, error) {
+	return s.walletRepo.GetWalletStats(ctx, network, address)
+}
+
+// GetBubbleAnalysis retrieves bubble analysis data of a network, optionally for a time window
+func (s *IndexingApplicationService) GetBubbleAnalysis(ctx context.Context, network string, minConnections int, window *entity.TimeWindow, limit int) ([]*entity.Wallet, error) {
+	return s.walletRepo.GetBubbleWallets(ctx, network, minConnections, window, limit)
+}
+
+// GetTransactionPath finds the k shortest value-flow paths between wallets
+func (s *IndexingApplicationService) GetTransactionPath(ctx context.Context, query *entity.PathQuery) ([]*entity.TransactionPath, error) {
+	return s.transactionRepo.GetTransactionPath(ctx, query)
+}
+
+// GetEdgeTransactions returns a page of the transactions behind a relationship, newest first
+func (s *IndexingApplicationService) GetEdgeTransactions(ctx context.Context, query *entity.EdgeHistoryQuery) (*entity.EdgeHistoryPage, error) {
+	return s.edgeHistoryRepo.GetEdgeTransactions(ctx, query)
 }
 
 // prepareWalletData prepares wallet data for batch processing
-func (s *IndexingApplicationService) prepareWalletData(tx *entity.Transaction, walletMap map[string]*entity.Wallet) {
+func (s *IndexingApplicationService) prepareWalletData(tx *entity.Transaction, walletMap map[string]*entity.Wallet) error {
+	// Wallets are keyed by network and address: the same address on two networks is two wallets
+	fromKey := entity.NetworkScopedKey(tx.Network, tx.From)
+	toKey := entity.NetworkScopedKey(tx.Network, tx.To)
+
+	value, err := entity.AddAmounts(tx.Value)
+	if err != nil {
+		return fmt.Errorf("failed to parse value of transaction %s: %w", tx.Hash, err)
+	}
+
 	// Prepare sender wallet
-	if wallet, exists := walletMap[tx.From]; exists {
-		wallet.LastSeen = tx.Timestamp
+	if wallet, exists := walletMap[fromKey]; exists {
+		widenSeen(wallet, tx.Timestamp)
 		wallet.TotalTransactions++
-		value, _ := strconv.ParseFloat(wallet.TotalSent, 64)
-		txValue, _ := strconv.ParseFloat(tx.Value, 64)
-		wallet.TotalSent = fmt.Sprintf("%.0f", value+txValue)
+		if wallet.TotalSent, err = entity.AddAmounts(wallet.TotalSent, value); err != nil {
+			return err
+		}
 	} else {
-		walletMap[tx.From] = &entity.Wallet{
+		walletMap[fromKey] = &entity.Wallet{
 			Address:           tx.From,
 			FirstSeen:         tx.Timestamp,
 			LastSeen:          tx.Timestamp,
 			TotalTransactions: 1,
-			TotalSent:         tx.Value,
+			TotalSent:         value,
 			TotalReceived:     "0",
 			Network:           tx.Network,
 		}
 	}
 
 	// Prepare receiver wallet
-	if wallet, exists := walletMap[tx.To]; exists {
-		wallet.LastSeen = tx.Timestamp
+	if wallet, exists := walletMap[toKey]; exists {
+		widenSeen(wallet, tx.Timestamp)
 		wallet.TotalTransactions++
-		value, _ := strconv.ParseFloat(wallet.TotalReceived, 64)
-		txValue, _ := strconv.ParseFloat(tx.Value, 64)
-		wallet.TotalReceived = fmt.Sprintf("%.0f", value+txValue)
+		if wallet.TotalReceived, err = entity.AddAmounts(wallet.TotalReceived, value); err != nil {
+			return err
+		}
 	} else {
-		walletMap[tx.To] = &entity.Wallet{
+		walletMap[toKey] = &entity.Wallet{
 			Address:           tx.To,
 			FirstSeen:         tx.Timestamp,
 			LastSeen:          tx.Timestamp,
 			TotalTransactions: 1,
 			TotalSent:         "0",
-			TotalReceived:     tx.Value,
+			TotalReceived:     value,
 			Network:           tx.Network,
 		}
 	}
+
+	return nil
+}
+
+// widenSeen extends a wallet's first/last seen to include the timestamp; batches are not ordered by time
+func widenSeen(wallet *entity.Wallet, timestamp time.Time) {
+	if timestamp.Before(wallet.FirstSeen) {
+		wallet.FirstSeen = timestamp
+	}
+	if timestamp.After(wallet.LastSeen) {
+		wallet.LastSeen = timestamp
+	}
 }
 
-// batchCreateOrUpdateWallets creates or updates wallets in batch
+// batchCreateOrUpdateWallets adds the batch's wallet counters in a single round-trip
 func (s *IndexingApplicationService) batchCreateOrUpdateWallets(ctx context.Context, walletMap map[string]*entity.Wallet) error {
+	wallets := make([]*entity.Wallet, 0, len(walletMap))
 	for _, wallet := range walletMap {
-		if err := s.walletRepo.CreateOrUpdateWallet(ctx, wallet); err != nil {
-			return fmt.Errorf("failed to create/update wallet %s: %w", wallet.Address, err)
-		}
+		wallets = append(wallets, wallet)
 	}
-	return nil
+	return s.walletRepo.BatchCreateOrUpdateWallets(ctx, wallets)
+}
+
+// batchWallets returns the wallets a batch wrote, including those created by ERC20 relationships
+func batchWallets(walletMap map[string]*entity.Wallet, erc20Relationships []*entity.ERC20TransferRelationship) []*entity.Wallet {
+	wallets := make([]*entity.Wallet, 0, len(walletMap)+len(erc20Relationships))
+	for _, wallet := range walletMap {
+		wallets = append(wallets, wallet)
+	}
+	for _, rel := range erc20Relationships {
+		wallets = append(wallets,
+			&entity.Wallet{Address: rel.FromAddress, Network: rel.Network},
+			&entity.Wallet{Address: rel.ToAddress, Network: rel.Network})
+	}
+	return wallets
 }
 
 // GetERC20TransfersForWallet retrieves ERC20 transfers for a wallet
@@ -333,65 +468,9 @@ func (s *IndexingApplicationService) GetERC20TransfersBetweenWallets(ctx context
 	return s.erc20Repo.GetERC20TransfersBetweenWallets(ctx, fromAddress, toAddress, limit)
 }
 
-// processERC20Transfers processes ERC20 transfers from a transaction
-func (s *IndexingApplicationService) processERC20Transfers(ctx context.Context, tx *entity.Transaction) error {
-	// Decode ERC20 transfers from transaction data
-	transfers, err := s.erc20Decoder.DecodeERC20Transfer(ctx, tx)
-	if err != nil {
-		return fmt.Errorf("failed to decode ERC20 transfers: %w", err)
-	}
-
-	if len(transfers) == 0 {
-		return nil // No ERC20 transfers found
-	}
-
-	s.logger.Info("Found ERC20 transfers",
-		zap.String("tx_hash", tx.Hash),
-		zap.Int("count", len(transfers)))
-
-	// Process each ERC20 transfer
-	for _, transfer := range transfers {
-		// Create ERC20 contract if needed
-		contract := &entity.ERC20Contract{
-			Address:   transfer.ContractAddress,
-			Name:      "Unknown Token",
-			Symbol:    "UNK",
-			Decimals:  18,
-			FirstSeen: transfer.Timestamp,
-			LastSeen:  transfer.Timestamp,
-			TotalTxs:  1,
-			Network:   transfer.Network,
-		}
-
-		if err := s.erc20Repo.CreateOrUpdateERC20Contract(ctx, contract); err != nil {
-			s.logger.Error("Failed to create/update ERC20 contract",
-				zap.String("contract", transfer.ContractAddress),
-				zap.Error(err))
-			// Continue processing other transfers
-		}
-
-		// Create transfer relationship
-		transferRel := &entity.ERC20TransferRelationship{
-			FromAddress:     transfer.From,
-			ToAddress:       transfer.To,
-			ContractAddress: transfer.ContractAddress,
-			Value:           transfer.Value,
-			TxHash:          transfer.TxHash,
-			Timestamp:       transfer.Timestamp,
-			Network:         transfer.Network,
-		}
-
-		if err := s.erc20Repo.CreateERC20TransferRelationship(ctx, transferRel); err != nil {
-			s.logger.Error("Failed to create ERC20 transfer relationship",
-				zap.String("from", transfer.From),
-				zap.String("to", transfer.To),
-				zap.String("contract", transfer.ContractAddress),
-				zap.Error(err))
-			// Continue processing other transfers
-		}
-	}
-
-	return nil
+// GetTokenBubbleMap retrieves the top holders of a token on a network and the transfers among them
+func (s *IndexingApplicationService) GetTokenBubbleMap(ctx context.Context, network, token string, topN int) (*entity.TokenBubbleMap, error) {
+	return s.erc20Repo.GetTokenBubbleMap(ctx, network, token, topN)
 }
 
 // determineContractType determines the contract type based on interaction type and classifier
@@ -426,8 +505,8 @@ func (s *IndexingApplicationService) determineContractType(interactionType entit
 	case entity.InteractionTransfer, entity.InteractionTransferFrom,
 		entity.InteractionApprove, entity.InteractionIncreaseAllowance, entity.InteractionDecreaseAllowance:
 		return "ERC20"
-	case entity.InteractionETHTransfer:
-		return "ETH"
+	case entity.InteractionNativeTransfer:
+		return string(entity.ContractTypeNative)
 	default:
 		return "UNKNOWN"
 	}
@@ -33,12 +33,13 @@ func NewNodeClassificationAppService(
 	}
 }
 
-// ClassifyWalletAddress classifies a wallet address and saves the result
-func (s *NodeClassificationAppService) ClassifyWalletAddress(ctx context.Context, address string) (*entity.NodeClassification, error) {
-	log.Printf("Classifying address: %s", address)
+// ClassifyWalletAddress classifies the wallet of an address on a network and saves the result
+func (s *NodeClassificationAppService) ClassifyWalletAddress(ctx context.Context, network, address string) (*entity.NodeClassification, error) {
+	network = entity.NormalizeNetwork(network)
+	log.Printf("Classifying address: %s on %s", address, network)
 
 	// Get wallet statistics
-	stats, err := s.walletRepo.GetWalletStats(ctx, address)
+	stats, err := s.walletRepo.GetWalletStats(ctx, network, address)
 	if err != nil {
 		log.Printf("Error getting wallet stats for %s: %v", address, err)
 		// Continue with classification even if stats unavailable
@@ -53,9 +54,10 @@ func (s *NodeClassificationAppService) ClassifyWalletAddress(ctx context.Context
 	if err != nil {
 		return nil, fmt.Errorf("failed to classify node %s: %w", address, err)
 	}
+	classification.Network = network
 
 	// Update the wallet with classification info
-	if err := s.updateWalletWithClassification(ctx, address, classification); err != nil {
+	if err := s.updateWalletWithClassification(ctx, network, address, classification); err != nil {
 		log.Printf("Warning: failed to update wallet classification for %s: %v", address, err)
 	}
 
@@ -70,8 +72,8 @@ func (s *NodeClassificationAppService) ClassifyWalletAddress(ctx context.Context
 	return classification, nil
 }
 
-// BulkClassifyAddresses classifies multiple addresses in batch
-func (s *NodeClassificationAppService) BulkClassifyAddresses(ctx context.Context, addresses []string) ([]*entity.NodeClassification, error) {
+// BulkClassifyAddresses classifies multiple addresses of a network in batch
+func (s *NodeClassificationAppService) BulkClassifyAddresses(ctx context.Context, network string, addresses []string) ([]*entity.NodeClassification, error) {
 	log.Printf("Starting bulk classification of %d addresses", len(addresses))
 
 	classifications := make([]*entity.NodeClassification, 0, len(addresses))
@@ -81,7 +83,7 @@ func (s *NodeClassificationAppService) BulkClassifyAddresses(ctx context.Context
 			log.Printf("Processed %d/%d addresses", i, len(addresses))
 		}
 
-		classification, err := s.ClassifyWalletAddress(ctx, address)
+		classification, err := s.ClassifyWalletAddress(ctx, network, address)
 		if err != nil {
 			log.Printf("Failed to classify address %s: %v", address, err)
 			continue
@@ -96,18 +98,18 @@ func (s *NodeClassificationAppService) BulkClassifyAddresses(ctx context.Context
 	return classifications, nil
 }
 
-// ReClassifyAddress re-classifies an address with updated data
-func (s *NodeClassificationAppService) ReClassifyAddress(ctx context.Context, address string) (*entity.NodeClassification, error) {
-	log.Printf("Re-classifying address: %s", address)
+// ReClassifyAddress re-classifies the wallet of an address on a network with updated data
+func (s *NodeClassificationAppService) ReClassifyAddress(ctx context.Context, network, address string) (*entity.NodeClassification, error) {
+	log.Printf("Re-classifying address: %s on %s", address, network)
 
 	// Get existing classification
-	existing, err := s.classificationRepo.GetClassification(ctx, address)
+	existing, err := s.classificationRepo.GetClassification(ctx, network, address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing classification: %w", err)
 	}
 
 	// Perform new classification
-	newClassification, err := s.ClassifyWalletAddress(ctx, address)
+	newClassification, err := s.ClassifyWalletAddress(ctx, network, address)
 	if err != nil {
 		return nil, err
 	}
@@ -215,8 +217,8 @@ func (s *NodeClassificationAppService) AnalyzeSuspiciousCluster(ctx context.Cont
 	return result, nil
 }
 
-// UpdateBlacklist adds an address to the blacklist
-func (s *NodeClassificationAppService) UpdateBlacklist(ctx context.Context, address, reason string) error {
+// UpdateBlacklist adds an address to the blacklist and re-classifies its wallet on a network
+func (s *NodeClassificationAppService) UpdateBlacklist(ctx context.Context, network, address, reason string) error {
 	log.Printf("Adding address to blacklist: %s (reason: %s)", address, reason)
 
 	// Add to blacklist
@@ -228,7 +230,7 @@ func (s *NodeClassificationAppService) UpdateBlacklist(ctx context.Context, addr
 	s.nodeClassifier.UpdateBlacklist(address, reason)
 
 	// Re-classify the address
-	_, err := s.ReClassifyAddress(ctx, address)
+	_, err := s.ReClassifyAddress(ctx, network, address)
 	if err != nil {
 		log.Printf("Warning: failed to re-classify blacklisted address %s: %v", address, err)
 	}
@@ -296,9 +298,9 @@ func (s *NodeClassificationAppService) generateBehavioralPatterns(ctx context.Co
 	return patterns
 }
 
-func (s *NodeClassificationAppService) updateWalletWithClassification(ctx context.Context, address string, classification *entity.NodeClassification) error {
+func (s *NodeClassificationAppService) updateWalletWithClassification(ctx context.Context, network, address string, classification *entity.NodeClassification) error {
 	// Update the wallet entity with classification info
-	wallet, err := s.walletRepo.GetWallet(ctx, address)
+	wallet, err := s.walletRepo.GetWallet(ctx, network, address)
 	if err != nil {
 		return err
 	}
@@ -323,6 +325,11 @@ func (s *NodeClassificationAppService) updateWalletWithClassification(ctx contex
 	wallet.AssociatedProtocols = classification.Protocols
 	wallet.IsContract = classification.PrimaryType.IsContractType()
 
+	// The upsert adds counters to the stored ones; classifying a wallet is not activity
+	wallet.TotalTransactions = 0
+	wallet.TotalSent = "0"
+	wallet.TotalReceived = "0"
+
 	return s.walletRepo.CreateOrUpdateWallet(ctx, wallet)
 }
 
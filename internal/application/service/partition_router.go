@@ -0,0 +1,100 @@
+package service
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+const (
+	// hotWindow is the number of recently routed transactions hot addresses are detected in
+	hotWindow = 1024
+	// hotThreshold is how often an address has to occur within hotWindow to become hot
+	hotThreshold = 16
+	// maxHotAddresses bounds the hot addresses a router remembers
+	maxHotAddresses = 4096
+)
+
+// addressRouter assigns transactions to partitions by a stable hash of one of their addresses.
+// Addresses that occur in many recent transactions, such as exchange deposit wallets and
+// routers, become hot for the lifetime of the pipeline, and every transaction touching a hot
+// address is routed on it, so the writes of a hot wallet are serialized on one partition
+// instead of contending for its node across all of them. Other transactions are routed on
+// their sender. The router is only used by the goroutine reading the pipeline's messages.
+type addressRouter struct {
+	partitions int
+
+	recent []string       // addresses of the last hotWindow transactions, as a ring
+	next   int            // position of the oldest address in recent
+	counts map[string]int // occurrences of each address in recent
+	hot    map[string]struct{}
+}
+
+// newAddressRouter creates a router over the given number of partitions
+func newAddressRouter(partitions int) *addressRouter {
+	return &addressRouter{
+		partitions: max(partitions, 1),
+		counts:     make(map[string]int),
+		hot:        make(map[string]struct{}),
+	}
+}
+
+// route returns the partition of a transaction: that of its sender if the sender is hot or the
+// receiver is not, otherwise that of its receiver. Transactions without a sender follow their
+// receiver. Transactions between two hot addresses follow the sender and still share the
+// receiver with another partition; their writes lock wallets in address order, so they wait
+// for each other rather than deadlock.
+func (r *addressRouter) route(tx *entity.Transaction) int {
+	if r.partitions == 1 {
+		return 0
+	}
+
+	from := strings.ToLower(tx.From)
+	to := strings.ToLower(tx.To)
+	r.observe(from)
+	r.observe(to)
+
+	address := from
+	if address == "" || (!r.isHot(from) && r.isHot(to)) {
+		address = to
+	}
+	return r.partition(address)
+}
+
+// observe records an occurrence of an address and marks it hot once it reaches hotThreshold
+// within the last hotWindow transactions
+func (r *addressRouter) observe(address string) {
+	if address == "" || r.isHot(address) {
+		return
+	}
+
+	if len(r.recent) < 2*hotWindow {
+		r.recent = append(r.recent, address)
+	} else {
+		oldest := r.recent[r.next]
+		if r.counts[oldest]--; r.counts[oldest] <= 0 {
+			delete(r.counts, oldest)
+		}
+		r.recent[r.next] = address
+		r.next = (r.next + 1) % len(r.recent)
+	}
+
+	r.counts[address]++
+	if r.counts[address] >= hotThreshold && len(r.hot) < maxHotAddresses {
+		r.hot[address] = struct{}{}
+	}
+}
+
+// isHot reports whether transactions touching an address are routed on it
+func (r *addressRouter) isHot(address string) bool {
+	_, ok := r.hot[address]
+	return ok
+}
+
+// partition returns the partition owning an address
+func (r *addressRouter) partition(address string) int {
+	h := fnv.New32a()
+	h.Write([]byte(address))
+	return int(h.Sum32() % uint32(r.partitions))
+}
@@ -0,0 +1,711 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultFlushInterval is how often a partial batch is handed to the workers when unset
+	defaultFlushInterval = 5 * time.Second
+	// throughputWindow is the period over which the per-network throughput is averaged
+	throughputWindow = time.Minute
+	// partitionQueueDepth is the number of full batches per partition a network pipeline buffers
+	// before it stops reading from its source
+	partitionQueueDepth = 4
+	// defaultNetwork names the pipeline of transactions without a network
+	defaultNetwork = "default"
+)
+
+// PipelineApplicationService implements PipelineService
+type PipelineApplicationService struct {
+	source            service.TransactionSource
+	deadLetters       service.DeadLetterPublisher
+	indexingService   service.IndexingService
+	checkpointService service.CheckpointService
+	config            *config.Config
+	logger            *logger.Logger
+
+	// inFlight limits the batches written at once across all networks; nil is unlimited
+	inFlight chan struct{}
+
+	// Batches are spooled to local disk while the store is unavailable; nil disables spooling
+	spool          service.BatchSpool
+	store          service.StoreHealthChecker
+	storeAvailable atomic.Bool
+	spooled        atomic.Uint64
+	replayed       atomic.Uint64
+
+	mu        sync.RWMutex
+	pipelines map[string]*networkPipeline
+}
+
+// NewPipelineApplicationService creates a new pipeline application service
+func NewPipelineApplicationService(
+	source service.TransactionSource,
+	deadLetters service.DeadLetterPublisher,
+	indexingService service.IndexingService,
+	checkpointService service.CheckpointService,
+	spool service.BatchSpool,
+	store service.StoreHealthChecker,
+	cfg *config.Config,
+	logger *logger.Logger,
+) service.PipelineService {
+	s := &PipelineApplicationService{
+		source:            source,
+		deadLetters:       deadLetters,
+		indexingService:   indexingService,
+		checkpointService: checkpointService,
+		spool:             spool,
+		store:             store,
+		config:            cfg,
+		logger:            logger.WithComponent("pipeline-service"),
+		pipelines:         make(map[string]*networkPipeline),
+	}
+	if cfg.App.MaxInFlightBatches > 0 {
+		s.inFlight = make(chan struct{}, cfg.App.MaxInFlightBatches)
+	}
+	s.storeAvailable.Store(true)
+	return s
+}
+
+// Run consumes the transaction source until it is exhausted or the context is cancelled.
+// Partitioned sources feed every network pipeline directly; other sources are routed by
+// the network of each transaction. Once the source closes its stream, the pending batches
+// are committed and acknowledged before Run returns.
+func (s *PipelineApplicationService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	// Replay spooled batches in the background until every pipeline has stopped
+	replayCtx, stopReplay := context.WithCancel(ctx)
+	replayDone := make(chan struct{})
+	go func() {
+		defer close(replayDone)
+		s.replay(replayCtx)
+	}()
+	defer func() {
+		stopReplay()
+		<-replayDone
+	}()
+
+	if partitioned, ok := s.source.(service.NetworkPartitionedSource); ok {
+		if streams := partitioned.NetworkMessages(); len(streams) > 0 {
+			for network, stream := range streams {
+				p := s.pipeline(network)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p.run(ctx, stream)
+				}()
+			}
+			wg.Wait()
+			return
+		}
+	}
+
+	s.route(ctx, &wg)
+	wg.Wait()
+}
+
+// route dispatches the transactions of a single stream to the pipeline of their network
+func (s *PipelineApplicationService) route(ctx context.Context, wg *sync.WaitGroup) {
+	inputs := make(map[string]chan *entity.TransactionMessage)
+	defer func() {
+		for _, in := range inputs {
+			close(in)
+		}
+	}()
+
+	input := func(network string) chan *entity.TransactionMessage {
+		if in, ok := inputs[network]; ok {
+			return in
+		}
+		p := s.pipeline(network)
+		in := make(chan *entity.TransactionMessage, p.batchSize)
+		inputs[network] = in
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx, in)
+		}()
+		return in
+	}
+
+	// Start the configured networks up front so their settings show up in the stats
+	for _, network := range s.config.NATS.NetworkList() {
+		input(network)
+	}
+
+	msgChan := s.source.Messages()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgChan:
+			if !ok {
+				return
+			}
+			in := input(pipelineKey(msg.Transaction.Network))
+			select {
+			case in <- msg:
+			case <-ctx.Done():
+				// Not handed to a worker; let the source redeliver it
+				msg.Handle.Nak(s.config.NATS.NakDelay)
+				return
+			}
+		}
+	}
+}
+
+// pipeline returns the pipeline of a network, creating it on first use
+func (s *PipelineApplicationService) pipeline(network string) *networkPipeline {
+	network = pipelineKey(network)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.pipelines[network]; ok {
+		return p
+	}
+
+	settings := s.config.PipelineFor(network)
+	flushInterval := s.config.App.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	p := &networkPipeline{
+		service:       s,
+		network:       network,
+		batchSize:     max(settings.BatchSize, 1),
+		maxBatchBytes: max(s.config.App.MaxBatchBytes, 0),
+		flushInterval: flushInterval,
+		workers:       max(settings.WorkerPoolSize, 1),
+		logger:        s.logger.WithFields(map[string]interface{}{"network": network}),
+	}
+	p.unsettled.Store(true)
+	s.pipelines[network] = p
+
+	s.logger.Info("Created network pipeline",
+		zap.String("network", network),
+		zap.Int("batch_size", p.batchSize),
+		zap.Int("max_batch_bytes", p.maxBatchBytes),
+		zap.Duration("flush_interval", p.flushInterval),
+		zap.Int("workers", p.workers))
+
+	return p
+}
+
+// Stats returns the throughput and lag of every network
+func (s *PipelineApplicationService) Stats(ctx context.Context) []*entity.NetworkStats {
+	var lags map[string]uint64
+	if partitioned, ok := s.source.(service.NetworkPartitionedSource); ok {
+		var err error
+		lags, err = partitioned.NetworkLag(ctx)
+		if err != nil {
+			s.logger.Warn("Failed to read network lag", zap.Error(err))
+		}
+	}
+
+	s.mu.RLock()
+	pipelines := make([]*networkPipeline, 0, len(s.pipelines))
+	for _, p := range s.pipelines {
+		pipelines = append(pipelines, p)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].network < pipelines[j].network })
+
+	stats := make([]*entity.NetworkStats, 0, len(pipelines))
+	for _, p := range pipelines {
+		stat := p.stats()
+		if lag, ok := lags[p.network]; ok {
+			// The source lag already includes its own buffer; add what the pipeline holds
+			stat.Lag = lag + uint64(stat.Buffered)
+		} else {
+			stat.Lag = uint64(stat.Buffered)
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// markUnsettled makes every network settle its block gaps again, for batches committed outside
+// of its pipeline
+func (s *PipelineApplicationService) markUnsettled() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, p := range s.pipelines {
+		p.unsettled.Store(true)
+	}
+}
+
+// pipelineKey normalizes a network name into the key of its pipeline
+func pipelineKey(network string) string {
+	network = strings.ToLower(strings.TrimSpace(network))
+	if network == "" {
+		return defaultNetwork
+	}
+	return network
+}
+
+// networkPipeline batches the transactions of one network and indexes them with its own worker pool
+type networkPipeline struct {
+	service       *PipelineApplicationService
+	network       string
+	batchSize     int
+	maxBatchBytes int
+	flushInterval time.Duration
+	workers       int
+	logger        *logger.Logger
+
+	transactions  atomic.Uint64
+	batches       atomic.Uint64
+	failedBatches atomic.Uint64
+	buffered      atomic.Int64
+
+	// unsettled is set by every commit until the network's gaps are settled; settling guards
+	// the one settlement running at a time
+	unsettled atomic.Bool
+	settling  atomic.Bool
+
+	mu          sync.Mutex
+	samples     []throughputSample
+	lastBatchAt time.Time
+}
+
+// throughputSample records the size of a committed batch
+type throughputSample struct {
+	at    time.Time
+	count int
+}
+
+// run batches the messages of the network until the stream closes or the context is cancelled.
+// Each worker owns one partition: transactions are sharded by their hot address, or else by
+// their sender, so the writes of a hot wallet are serialized on one worker, and each partition
+// commits its batches in order.
+// Partitions queue their batches independently, so a slow partition does not hold up the
+// others; the pipeline only stops reading once it buffers partitionQueueDepth batches per
+// partition.
+func (p *networkPipeline) run(ctx context.Context, msgChan <-chan *entity.TransactionMessage) {
+	router := newAddressRouter(p.workers)
+	partitions := make([]*pipelinePartition, p.workers)
+	maxBuffered := int64(p.workers * p.batchSize * partitionQueueDepth)
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+
+	// committed is signalled whenever a worker settles a batch, so reading can resume
+	committed := make(chan struct{}, 1)
+
+	// Start one worker per partition
+	for i := range partitions {
+		part := newPipelinePartition(p.batchSize)
+		partitions[i] = part
+
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			p.logger.Info("Starting batch processing worker", zap.Int("worker_id", workerID))
+
+			for {
+				messages, ok := part.next()
+				if !ok {
+					return
+				}
+				p.service.acquire()
+				p.processBatch(ctx, workerID, messages)
+				p.service.release()
+
+				select {
+				case committed <- struct{}{}:
+				default:
+				}
+			}
+		}(i)
+	}
+
+	// flushAll hands the batch of every partition to its worker
+	flushAll := func() {
+		for _, part := range partitions {
+			part.flush()
+		}
+	}
+
+	// stop flushes the remaining batches and waits for the workers to commit and settle them
+	stop := func() {
+		flushAll()
+		for _, part := range partitions {
+			part.close()
+		}
+		wg.Wait()
+	}
+
+	for {
+		// Stop reading while the buffer is full; partial batches are handed over so that the
+		// workers can free it
+		input := msgChan
+		if p.buffered.Load() >= maxBuffered {
+			flushAll()
+			input = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+
+		case <-committed:
+
+		case msg, ok := <-input:
+			if !ok {
+				stop()
+				return
+			}
+
+			p.buffered.Add(1)
+			part := partitions[router.route(msg.Transaction)]
+			part.batch = append(part.batch, msg)
+			part.bytes += messageSize(msg)
+
+			// Process batch if it's full
+			if len(part.batch) >= p.batchSize || (p.maxBatchBytes > 0 && part.bytes >= p.maxBatchBytes) {
+				part.flush()
+			}
+
+		case <-ticker.C:
+			// Flush batches periodically
+			flushAll()
+			if p.buffered.Load() == 0 && len(msgChan) == 0 {
+				p.settleGaps(ctx)
+			}
+		}
+	}
+}
+
+// settleGaps closes the block gaps of the network in the background once the pipeline is idle
+// and its source has settled every transaction, so blocks without transactions do not hold
+// the checkpoint back
+func (p *networkPipeline) settleGaps(ctx context.Context) {
+	s := p.service
+	reporter, ok := s.source.(service.SettlementReporter)
+	if !ok || !p.unsettled.Load() || s.spooling() || !p.settling.CompareAndSwap(false, true) {
+		return
+	}
+
+	network := p.network
+	if network == defaultNetwork {
+		network = ""
+	}
+	go func() {
+		defer p.settling.Store(false)
+
+		settled, err := reporter.Settled(ctx, network)
+		if err != nil {
+			p.logger.Warn("Failed to check whether the source is settled", zap.Error(err))
+			return
+		}
+		if !settled {
+			return
+		}
+
+		// A batch committed from here on marks the network again
+		p.unsettled.Store(false)
+		if err := s.checkpointService.SettleGaps(ctx, network); err != nil {
+			p.unsettled.Store(true)
+			p.logger.Warn("Failed to settle block gaps", zap.Error(err))
+		}
+	}()
+}
+
+// pipelinePartition buffers the transactions routed to one worker and queues its batches
+type pipelinePartition struct {
+	batch []*entity.TransactionMessage
+	bytes int
+
+	mu     sync.Mutex
+	queue  [][]*entity.TransactionMessage
+	closed bool
+	ready  chan struct{} // signalled when a batch is queued or the partition is closed
+}
+
+// newPipelinePartition creates an empty partition
+func newPipelinePartition(batchSize int) *pipelinePartition {
+	return &pipelinePartition{
+		batch: make([]*entity.TransactionMessage, 0, batchSize),
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// flush queues the buffered batch for the partition's worker, ordered by block number; it
+// does not wait for the worker
+func (part *pipelinePartition) flush() {
+	if len(part.batch) == 0 {
+		return
+	}
+
+	// Clone the batch to avoid race conditions
+	messages := make([]*entity.TransactionMessage, len(part.batch))
+	copy(messages, part.batch)
+	sort.SliceStable(messages, func(i, j int) bool {
+		return blockNumber(messages[i].Transaction) < blockNumber(messages[j].Transaction)
+	})
+	part.mu.Lock()
+	part.queue = append(part.queue, messages)
+	part.mu.Unlock()
+	part.signal()
+
+	// Reset batch
+	part.batch = part.batch[:0]
+	part.bytes = 0
+}
+
+// close lets the worker return once the queued batches are processed
+func (part *pipelinePartition) close() {
+	part.mu.Lock()
+	part.closed = true
+	part.mu.Unlock()
+	part.signal()
+}
+
+// signal wakes up the worker if it is waiting
+func (part *pipelinePartition) signal() {
+	select {
+	case part.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next blocks until a batch is queued and returns it, in queue order; it returns false once
+// the partition is closed and its queue is empty
+func (part *pipelinePartition) next() ([]*entity.TransactionMessage, bool) {
+	for {
+		part.mu.Lock()
+		if len(part.queue) > 0 {
+			messages := part.queue[0]
+			part.queue[0] = nil
+			part.queue = part.queue[1:]
+			part.mu.Unlock()
+			return messages, true
+		}
+		closed := part.closed
+		part.mu.Unlock()
+
+		if closed {
+			return nil, false
+		}
+		<-part.ready
+	}
+}
+
+// messageSize returns the size a message adds to a batch: its raw payload, or the call
+// data of the transaction when the source kept no payload
+func messageSize(msg *entity.TransactionMessage) int {
+	if len(msg.Payload) > 0 {
+		return len(msg.Payload)
+	}
+	return len(msg.Transaction.Data)
+}
+
+// acquire waits for a free in-flight batch slot
+func (s *PipelineApplicationService) acquire() {
+	if s.inFlight != nil {
+		s.inFlight <- struct{}{}
+	}
+}
+
+// release frees an in-flight batch slot
+func (s *PipelineApplicationService) release() {
+	if s.inFlight != nil {
+		<-s.inFlight
+	}
+}
+
+// blockNumber returns the block number of a transaction, or 0 if it cannot be parsed
+func blockNumber(tx *entity.Transaction) uint64 {
+	number, _ := tx.BlockNumberUint64()
+	return number
+}
+
+// processBatch indexes a batch and settles its messages once the outcome is known
+func (p *networkPipeline) processBatch(ctx context.Context, workerID int, messages []*entity.TransactionMessage) {
+	s := p.service
+	defer p.buffered.Add(-int64(len(messages)))
+
+	// Keep the spooled batches first in line until they are replayed
+	if s.spooling() {
+		p.spoolBatch(workerID, messages)
+		return
+	}
+
+	transactions := make([]*entity.Transaction, len(messages))
+	for i, msg := range messages {
+		transactions[i] = msg.Transaction
+	}
+
+	result, err := s.indexingService.ProcessTransactionBatch(ctx, transactions)
+	if err == nil && !result.Committed {
+		err = fmt.Errorf("batch was not committed")
+	}
+	if err != nil && s.spool != nil && !s.storeReachable(ctx) {
+		s.markStoreUnavailable(err)
+		p.spoolBatch(workerID, messages)
+		return
+	}
+	if err != nil {
+		p.failedBatches.Add(1)
+		p.logger.Error("Failed to process transaction batch",
+			zap.Error(err),
+			zap.Int("worker_id", workerID),
+			zap.Int("batch_size", len(messages)))
+		rejectBatch(ctx, messages, err, s.deadLetters, s.config, p.logger)
+		return
+	}
+
+	p.record(len(messages))
+	p.logger.Info("Successfully processed batch",
+		zap.Int("worker_id", workerID),
+		zap.Int("batch_size", len(messages)),
+		zap.Int("indexed", result.Indexed),
+		zap.Int("skipped", result.Skipped))
+
+	// The batch is committed; a failed checkpoint only delays progress reporting
+	if err := s.checkpointService.RecordBatch(ctx, messages); err != nil {
+		p.logger.Warn("Failed to record checkpoint",
+			zap.Int("worker_id", workerID),
+			zap.Error(err))
+	}
+	ackBatch(messages, p.logger)
+}
+
+// record accounts a committed batch
+func (p *networkPipeline) record(count int) {
+	p.unsettled.Store(true)
+	p.transactions.Add(uint64(count))
+	p.batches.Add(1)
+
+	now := time.Now()
+	p.mu.Lock()
+	p.samples = append(p.samples, throughputSample{at: now, count: count})
+	p.lastBatchAt = now
+	p.pruneSamples(now)
+	p.mu.Unlock()
+}
+
+// pruneSamples drops samples older than the throughput window; callers hold p.mu
+func (p *networkPipeline) pruneSamples(now time.Time) {
+	cutoff := now.Add(-throughputWindow)
+	i := 0
+	for i < len(p.samples) && p.samples[i].at.Before(cutoff) {
+		i++
+	}
+	p.samples = p.samples[i:]
+}
+
+// stats returns a snapshot of the pipeline counters
+func (p *networkPipeline) stats() *entity.NetworkStats {
+	stat := &entity.NetworkStats{
+		Network:       p.network,
+		BatchSize:     p.batchSize,
+		Workers:       p.workers,
+		Transactions:  p.transactions.Load(),
+		Batches:       p.batches.Load(),
+		FailedBatches: p.failedBatches.Load(),
+		Buffered:      int(max(p.buffered.Load(), 0)),
+	}
+
+	now := time.Now()
+	p.mu.Lock()
+	p.pruneSamples(now)
+	count := 0
+	for _, sample := range p.samples {
+		count += sample.count
+	}
+	stat.LastBatchAt = p.lastBatchAt
+	p.mu.Unlock()
+
+	stat.TxPerSecond = float64(count) / throughputWindow.Seconds()
+	return stat
+}
+
+// ackBatch acknowledges every message of a persisted batch
+func ackBatch(messages []*entity.TransactionMessage, logger *logger.Logger) {
+	for _, msg := range messages {
+		if err := msg.Handle.Ack(); err != nil {
+			logger.Warn("Failed to acknowledge message",
+				zap.String("hash", msg.Transaction.Hash),
+				zap.Error(err))
+		}
+	}
+}
+
+// rejectBatch asks for redelivery of a failed batch, dead-lettering messages that ran out of
+// attempts or whose source cannot redeliver them
+func rejectBatch(
+	ctx context.Context,
+	messages []*entity.TransactionMessage,
+	batchErr error,
+	deadLetters service.DeadLetterPublisher,
+	cfg *config.Config,
+	logger *logger.Logger,
+) {
+	for _, msg := range messages {
+		attempts := msg.Handle.NumDelivered()
+		if cfg.NATS.MaxDeliver <= 0 || attempts < uint64(cfg.NATS.MaxDeliver) {
+			err := msg.Handle.Nak(cfg.NATS.NakDelay)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, entity.ErrRedeliveryUnsupported) {
+				logger.Warn("Failed to nak message",
+					zap.String("hash", msg.Transaction.Hash),
+					zap.Error(err))
+				continue
+			}
+			logger.Error("Message cannot be redelivered, dead-lettering",
+				zap.String("hash", msg.Transaction.Hash))
+		} else {
+			logger.Error("Message exhausted delivery attempts, dead-lettering",
+				zap.String("hash", msg.Transaction.Hash),
+				zap.Uint64("deliveries", attempts))
+		}
+
+		letter := &entity.DeadLetter{
+			Subject:   msg.Subject,
+			Payload:   msg.Payload,
+			Error:     batchErr.Error(),
+			Stage:     entity.DeadLetterStageIndex,
+			Attempts:  attempts,
+			Timestamp: time.Now().UTC(),
+			TxHash:    msg.Transaction.Hash,
+			Network:   msg.Transaction.Network,
+		}
+		if err := deadLetters.PublishDeadLetter(ctx, letter); err != nil {
+			// Keep the message in the stream rather than lose it
+			logger.Error("Failed to dead-letter message",
+				zap.String("hash", msg.Transaction.Hash),
+				zap.Error(err))
+			msg.Handle.Nak(cfg.NATS.NakDelay)
+			continue
+		}
+
+		if err := msg.Handle.Term(); err != nil {
+			logger.Warn("Failed to terminate message",
+				zap.String("hash", msg.Transaction.Hash),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultProbeInterval is how often the store is probed before replaying when unset
+	defaultProbeInterval = 5 * time.Second
+	// maxReplayFailures is how often a spooled batch may fail against a reachable store
+	// before it is dead-lettered, so a poisoned batch cannot hold back the spool forever
+	maxReplayFailures = 5
+)
+
+// spooling reports whether new batches must go to the spool: the store is unavailable, or
+// older batches are still waiting to be replayed
+func (s *PipelineApplicationService) spooling() bool {
+	return s.spool != nil && (!s.storeAvailable.Load() || s.spool.Len() > 0)
+}
+
+// storeReachable probes the store within the health check timeout
+func (s *PipelineApplicationService) storeReachable(ctx context.Context) bool {
+	if s.store == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.config.Health.Timeout)
+	defer cancel()
+	return s.store.IsConnected(ctx)
+}
+
+// markStoreUnavailable switches the pipelines to spooling
+func (s *PipelineApplicationService) markStoreUnavailable(err error) {
+	if s.storeAvailable.CompareAndSwap(true, false) {
+		s.logger.Warn("Neo4J is unavailable, spooling batches to local disk", zap.Error(err))
+	}
+}
+
+// markStoreAvailable lets the pipelines write directly once the spool is drained
+func (s *PipelineApplicationService) markStoreAvailable() {
+	if s.storeAvailable.CompareAndSwap(false, true) {
+		s.logger.Info("Neo4J is available again, replaying spooled batches",
+			zap.Int("batches", s.spool.Len()))
+	}
+}
+
+// spoolBatch parks a batch on local disk and acknowledges it once it is durable there;
+// a batch that does not fit is nak'ed so the source keeps it instead
+func (p *networkPipeline) spoolBatch(workerID int, messages []*entity.TransactionMessage) {
+	s := p.service
+
+	batch := &entity.SpooledBatch{
+		Network:      p.network,
+		SpooledAt:    time.Now().UTC(),
+		Transactions: make([]entity.SpooledTransaction, len(messages)),
+	}
+	for i, msg := range messages {
+		batch.Transactions[i] = entity.SpooledTransaction{
+			Transaction: msg.Transaction,
+			Subject:     msg.Subject,
+			Sequence:    msg.Sequence,
+		}
+	}
+
+	if err := s.spool.Append(batch); err != nil {
+		p.failedBatches.Add(1)
+		if errors.Is(err, service.ErrSpoolFull) {
+			p.logger.Warn("Spool is full, leaving batch with the source",
+				zap.Int("worker_id", workerID),
+				zap.Int("batch_size", len(messages)),
+				zap.Int64("spool_bytes", s.spool.Bytes()))
+		} else {
+			p.logger.Error("Failed to spool batch",
+				zap.Int("worker_id", workerID),
+				zap.Error(err))
+		}
+		nakBatch(messages, s.config.NATS.NakDelay, p.logger)
+		return
+	}
+
+	s.spooled.Add(1)
+	p.logger.Info("Spooled batch",
+		zap.Int("worker_id", workerID),
+		zap.Int("batch_size", len(messages)),
+		zap.Int("spooled_batches", s.spool.Len()))
+	ackBatch(messages, p.logger)
+}
+
+// replay probes the store while it is unavailable or batches are spooled, and writes the
+// spooled batches back in order once it is reachable
+func (s *PipelineApplicationService) replay(ctx context.Context) {
+	if s.spool == nil {
+		return
+	}
+
+	interval := s.config.Spool.ProbeInterval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if s.storeAvailable.Load() && s.spool.Len() == 0 {
+			continue
+		}
+		if !s.storeReachable(ctx) {
+			s.markStoreUnavailable(fmt.Errorf("connectivity check failed"))
+			continue
+		}
+		s.markStoreAvailable()
+		failures = s.replaySpool(ctx, failures)
+	}
+}
+
+// replaySpool writes spooled batches until the spool is empty or a batch fails, and returns
+// the number of consecutive failures of the batch at the head of the spool
+func (s *PipelineApplicationService) replaySpool(ctx context.Context, failures int) int {
+	for ctx.Err() == nil {
+		batch, err := s.spool.Peek()
+		if err != nil {
+			s.logger.Error("Failed to read spooled batch", zap.Error(err))
+			return failures
+		}
+		if batch == nil {
+			return 0
+		}
+
+		messages := batch.Messages()
+		transactions := make([]*entity.Transaction, len(messages))
+		for i, msg := range messages {
+			transactions[i] = msg.Transaction
+		}
+
+		result, err := s.indexingService.ProcessTransactionBatch(ctx, transactions)
+		if err == nil && !result.Committed {
+			err = fmt.Errorf("batch was not committed")
+		}
+		if err != nil {
+			if !s.storeReachable(ctx) {
+				s.markStoreUnavailable(err)
+				return failures
+			}
+
+			failures++
+			s.logger.Error("Failed to replay spooled batch",
+				zap.String("network", batch.Network),
+				zap.Int("batch_size", len(transactions)),
+				zap.Int("failures", failures),
+				zap.Error(err))
+			if failures < maxReplayFailures || !s.deadLetterSpooled(ctx, batch, err) {
+				return failures
+			}
+		} else {
+			if err := s.checkpointService.RecordBatch(ctx, messages); err != nil {
+				s.logger.Warn("Failed to record checkpoint of replayed batch", zap.Error(err))
+			}
+			s.markUnsettled()
+		}
+
+		if err := s.spool.Commit(); err != nil {
+			s.logger.Error("Failed to remove replayed batch from spool", zap.Error(err))
+			return 0
+		}
+		failures = 0
+		s.replayed.Add(1)
+	}
+	return failures
+}
+
+// deadLetterSpooled parks the transactions of a spooled batch that keeps failing and
+// reports whether all of them were parked
+func (s *PipelineApplicationService) deadLetterSpooled(ctx context.Context, batch *entity.SpooledBatch, batchErr error) bool {
+	s.logger.Error("Spooled batch exhausted replay attempts, dead-lettering",
+		zap.String("network", batch.Network),
+		zap.Int("batch_size", len(batch.Transactions)))
+
+	for i, tx := range batch.Transactions {
+		payload, err := json.Marshal(tx.Transaction)
+		if err != nil {
+			s.logger.Error("Failed to encode spooled transaction", zap.Error(err))
+			continue
+		}
+
+		letter := &entity.DeadLetter{
+			Subject:   tx.Subject,
+			Payload:   payload,
+			Error:     batchErr.Error(),
+			Stage:     entity.DeadLetterStageIndex,
+			Attempts:  maxReplayFailures,
+			Timestamp: time.Now().UTC(),
+			TxHash:    tx.Transaction.Hash,
+			Network:   tx.Transaction.Network,
+		}
+		if err := s.deadLetters.PublishDeadLetter(ctx, letter); err != nil {
+			// Keep the batch spooled rather than lose it; letters already published are
+			// published again on the next attempt
+			s.logger.Error("Failed to dead-letter spooled transaction",
+				zap.String("hash", tx.Transaction.Hash),
+				zap.Int("index", i),
+				zap.Error(err))
+			return false
+		}
+	}
+	return true
+}
+
+// Spool returns the state of the local spool
+func (s *PipelineApplicationService) Spool() *entity.SpoolStats {
+	stats := &entity.SpoolStats{
+		Enabled:        s.spool != nil,
+		StoreAvailable: s.storeAvailable.Load(),
+		Spooled:        s.spooled.Load(),
+		Replayed:       s.replayed.Load(),
+	}
+	if s.spool != nil {
+		stats.Batches = s.spool.Len()
+		stats.Bytes = s.spool.Bytes()
+		stats.MaxBytes = s.spool.MaxBytes()
+	}
+	return stats
+}
+
+// nakBatch asks for redelivery of every message of a batch that could not be persisted
+func nakBatch(messages []*entity.TransactionMessage, delay time.Duration, logger *logger.Logger) {
+	for _, msg := range messages {
+		if err := msg.Handle.Nak(delay); err != nil {
+			logger.Warn("Failed to nak message",
+				zap.String("hash", msg.Transaction.Hash),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrReorgBeyondConfirmationDepth is returned when a block that is already final gets a different hash
+var ErrReorgBeyondConfirmationDepth = errors.New("reorg deeper than confirmation depth")
+
+// ReorgApplicationService implements ReorgService on top of recorded block hashes
+type ReorgApplicationService struct {
+	blockRepo         repository.BlockRepository
+	confirmationDepth uint64
+	logger            *logger.Logger
+}
+
+// NewReorgApplicationService creates a new reorg application service
+func NewReorgApplicationService(blockRepo repository.BlockRepository, cfg *config.AppConfig, logger *logger.Logger) service.ReorgService {
+	return &ReorgApplicationService{
+		blockRepo:         blockRepo,
+		confirmationDepth: cfg.ConfirmationDepth,
+		logger:            logger.WithComponent("reorg-service"),
+	}
+}
+
+// batchBlocks holds the block hashes seen in a batch, per network and block number
+type batchBlocks map[string]map[uint64]string
+
+// Reconcile compares the block hashes of a batch with the recorded canonical hashes, rolls back
+// orphaned blocks within the confirmation depth and returns the transactions to index. A block
+// only replaces the recorded one when the batch reaches the recorded tip of its network; stale
+// deliveries of orphaned blocks are dropped instead of rolling back the canonical chain.
+func (s *ReorgApplicationService) Reconcile(ctx context.Context, transactions []*entity.Transaction) ([]*entity.Transaction, error) {
+	blocks := collectBlocks(transactions)
+
+	stale := make(map[string]map[uint64]bool, len(blocks))
+	for network, hashes := range blocks {
+		dropped, err := s.reconcileNetwork(ctx, network, hashes)
+		if err != nil {
+			return nil, err
+		}
+		stale[network] = dropped
+	}
+
+	// A batch carrying two hashes for the same height keeps the last one seen
+	filtered := make([]*entity.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		number, err := tx.BlockNumberUint64()
+		if err != nil || tx.BlockHash == "" {
+			filtered = append(filtered, tx)
+			continue
+		}
+		if hash := blocks[tx.Network][number]; hash != tx.BlockHash {
+			s.logger.Warn("Dropping transaction from a competing block in the same batch",
+				zap.String("tx_hash", tx.Hash),
+				zap.String("network", tx.Network),
+				zap.Uint64("block_number", number),
+				zap.String("block_hash", tx.BlockHash),
+				zap.String("kept_block_hash", hash))
+			continue
+		}
+		if stale[tx.Network][number] {
+			continue
+		}
+		filtered = append(filtered, tx)
+	}
+
+	return filtered, nil
+}
+
+// reconcileNetwork rolls back a network from the lowest block whose recorded hash differs from
+// the batch and returns the block numbers whose transactions are stale and must be dropped
+func (s *ReorgApplicationService) reconcileNetwork(ctx context.Context, network string, hashes map[uint64]string) (map[uint64]bool, error) {
+	numbers := make([]uint64, 0, len(hashes))
+	for number := range hashes {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	// A block rolled back earlier can only come back through a redelivery
+	stale, err := s.blockRepo.FindOrphanedBlocks(ctx, network, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up orphaned blocks: %w", err)
+	}
+	for number := range stale {
+		s.logger.Warn("Dropping transactions of an orphaned block",
+			zap.String("network", network),
+			zap.Uint64("block_number", number),
+			zap.String("block_hash", hashes[number]))
+	}
+
+	recorded, err := s.blockRepo.GetBlockHashes(ctx, network, numbers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up block hashes: %w", err)
+	}
+
+	var (
+		mismatched   []uint64
+		batchHighest uint64
+	)
+	for _, number := range numbers {
+		if stale[number] {
+			continue
+		}
+		batchHighest = number
+		if hash, ok := recorded[number]; ok && hash != hashes[number] {
+			mismatched = append(mismatched, number)
+		}
+	}
+	if len(mismatched) == 0 {
+		return stale, nil
+	}
+
+	highest, _, err := s.blockRepo.GetHighestBlock(ctx, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get highest indexed block: %w", err)
+	}
+
+	// A replacement chain is at least as long as the one it replaces; a batch that stays
+	// below the recorded tip carries blocks that lost the race, delivered late
+	if batchHighest < highest {
+		for _, number := range mismatched {
+			s.logger.Warn("Dropping transactions of a block below the recorded tip with a different hash",
+				zap.String("network", network),
+				zap.Uint64("block_number", number),
+				zap.String("recorded_hash", recorded[number]),
+				zap.String("block_hash", hashes[number]),
+				zap.Uint64("highest_block", highest))
+			stale[number] = true
+		}
+		return stale, nil
+	}
+
+	forkNumber := mismatched[0]
+	if s.confirmationDepth > 0 && highest >= forkNumber && highest-forkNumber >= s.confirmationDepth {
+		s.logger.Error("Block hash changed below confirmation depth, refusing to roll back",
+			zap.String("network", network),
+			zap.Uint64("block_number", forkNumber),
+			zap.String("recorded_hash", recorded[forkNumber]),
+			zap.String("new_hash", hashes[forkNumber]),
+			zap.Uint64("highest_block", highest),
+			zap.Uint64("confirmation_depth", s.confirmationDepth))
+		return nil, fmt.Errorf("%w: %s block %d has %d confirmations",
+			ErrReorgBeyondConfirmationDepth, network, forkNumber, highest-forkNumber)
+	}
+
+	s.logger.Warn("Chain reorganization detected, rolling back orphaned blocks",
+		zap.String("network", network),
+		zap.Uint64("block_number", forkNumber),
+		zap.String("recorded_hash", recorded[forkNumber]),
+		zap.String("new_hash", hashes[forkNumber]),
+		zap.Uint64("highest_block", highest))
+
+	orphaned, err := s.blockRepo.RollbackFrom(ctx, network, forkNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back reorganized blocks: %w", err)
+	}
+
+	s.logger.Info("Rolled back reorganized blocks",
+		zap.String("network", network),
+		zap.Uint64("from_block", forkNumber),
+		zap.Uint64("to_block", highest),
+		zap.Int("transactions", len(orphaned)))
+
+	return stale, nil
+}
+
+// RecordBlocks records the block hashes of an indexed batch as canonical and prunes
+// rollback journals of blocks that are now final
+func (s *ReorgApplicationService) RecordBlocks(ctx context.Context, transactions []*entity.Transaction) error {
+	now := time.Now().UTC()
+
+	for network, hashes := range collectBlocks(transactions) {
+		blocks := make([]*entity.Block, 0, len(hashes))
+		var highest uint64
+		for number, hash := range hashes {
+			blocks = append(blocks, &entity.Block{Network: network, Number: number, Hash: hash, IndexedAt: now})
+			if number > highest {
+				highest = number
+			}
+		}
+
+		if err := s.blockRepo.SaveBlocks(ctx, blocks); err != nil {
+			return fmt.Errorf("failed to record block hashes: %w", err)
+		}
+
+		if s.confirmationDepth > 0 && highest > s.confirmationDepth {
+			if err := s.blockRepo.PruneJournal(ctx, network, highest-s.confirmationDepth); err != nil {
+				// Pruning only reclaims space; a failure is retried with the next batch
+				s.logger.Warn("Failed to prune rollback journal",
+					zap.String("network", network),
+					zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectBlocks returns the last block hash seen per network and block number
+func collectBlocks(transactions []*entity.Transaction) batchBlocks {
+	blocks := make(batchBlocks)
+	for _, tx := range transactions {
+		if tx.BlockHash == "" {
+			continue
+		}
+		number, err := tx.BlockNumberUint64()
+		if err != nil {
+			continue
+		}
+		if blocks[tx.Network] == nil {
+			blocks[tx.Network] = make(map[uint64]string)
+		}
+		blocks[tx.Network][number] = tx.BlockHash
+	}
+	return blocks
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultTraversalDepth is the number of hops expanded when the query does not set it
+	defaultTraversalDepth = 2
+	// defaultTraversalFanOut is the number of edges followed per wallet and level when unset
+	defaultTraversalFanOut = 25
+	// defaultTraversalMaxNodes is the size of the subgraph when the query does not bound it
+	defaultTraversalMaxNodes = 500
+)
+
+// TraversalApplicationService implements TraversalService by expanding one level per query
+type TraversalApplicationService struct {
+	graphRepo repository.GraphRepository
+	logger    *logger.Logger
+}
+
+// NewTraversalApplicationService creates a new traversal application service
+func NewTraversalApplicationService(graphRepo repository.GraphRepository, logger *logger.Logger) service.TraversalService {
+	return &TraversalApplicationService{
+		graphRepo: graphRepo,
+		logger:    logger.WithComponent("traversal-service"),
+	}
+}
+
+// Traverse expands the graph breadth first from the root. Each level reads at most FanOut
+// edges per frontier wallet; hubs are kept as leaves, left out or expanded according to the
+// hub policy; the expansion stops at MaxDepth or once MaxNodes wallets were reached.
+func (s *TraversalApplicationService) Traverse(ctx context.Context, query *entity.TraversalQuery) (*entity.Subgraph, error) {
+	q := *query
+	if q.MaxDepth < 1 {
+		q.MaxDepth = defaultTraversalDepth
+	}
+	if q.MaxDepth > entity.MaxTraversalDepth {
+		q.MaxDepth = entity.MaxTraversalDepth
+	}
+	if q.FanOut < 1 {
+		q.FanOut = defaultTraversalFanOut
+	}
+	if q.MaxNodes < 1 {
+		q.MaxNodes = defaultTraversalMaxNodes
+	}
+	if q.HubPolicy == "" {
+		q.HubPolicy = entity.HubPolicyCollapse
+	}
+	switch q.HubPolicy {
+	case entity.HubPolicyCollapse, entity.HubPolicySkip, entity.HubPolicyExpand:
+	default:
+		return nil, fmt.Errorf("unknown hub policy %s", q.HubPolicy)
+	}
+	hubTypes := make(map[entity.NodeType]bool)
+	if len(q.HubTypes) == 0 {
+		q.HubTypes = entity.DefaultHubNodeTypes
+	}
+	for _, nodeType := range q.HubTypes {
+		hubTypes[nodeType] = true
+	}
+
+	network := entity.NormalizeNetwork(q.Network)
+	root := &entity.SubgraphNode{Address: q.Root, Network: network}
+	graph := &entity.Subgraph{
+		Root:    q.Root,
+		Network: network,
+		Nodes:   []*entity.SubgraphNode{root},
+	}
+	nodes := map[string]*entity.SubgraphNode{strings.ToLower(q.Root): root}
+	edges := make(map[string]bool)
+
+	frontier := []string{q.Root}
+	for depth := 1; depth <= q.MaxDepth && len(frontier) > 0; depth++ {
+		neighbours, err := s.graphRepo.GetNeighbours(ctx, &q, frontier, q.FanOut)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand level %d: %w", depth, err)
+		}
+		for _, address := range frontier {
+			nodes[strings.ToLower(address)].Expanded = true
+		}
+
+		var next []string
+		followed := make(map[string]int)
+		for _, neighbour := range neighbours {
+			source := nodes[strings.ToLower(neighbour.Source)]
+			if source == nil {
+				continue
+			}
+			if followed[neighbour.Source] == q.FanOut {
+				// The repository reads one edge past the cap to tell that there are more
+				source.Capped = true
+				continue
+			}
+
+			candidate := neighbour.Node
+			candidate.Hub = hubTypes[candidate.NodeType] || (q.HubDegree > 0 && candidate.Degree > q.HubDegree)
+			if candidate.Hub && q.HubPolicy == entity.HubPolicySkip {
+				// The repository already leaves skipped hubs out of the edges it ranks
+				continue
+			}
+
+			// Only edges added to the subgraph count towards the fan-out
+			key := strings.ToLower(candidate.Address)
+			if _, ok := nodes[key]; !ok {
+				if len(graph.Nodes) >= q.MaxNodes {
+					graph.Truncated = true
+					continue
+				}
+				candidate.Depth = depth
+				nodes[key] = candidate
+				graph.Nodes = append(graph.Nodes, candidate)
+				if !candidate.Hub || q.HubPolicy == entity.HubPolicyExpand {
+					next = append(next, candidate.Address)
+				}
+			}
+
+			followed[neighbour.Source]++
+
+			edge := neighbour.Edge
+			edgeKey := strings.ToLower(strings.Join([]string{edge.RelType, edge.From, edge.To, edge.ContractAddress}, "|"))
+			if !edges[edgeKey] {
+				edges[edgeKey] = true
+				graph.Edges = append(graph.Edges, edge)
+			}
+		}
+
+		s.logger.Debug("Expanded traversal level",
+			zap.String("root", q.Root),
+			zap.Int("depth", depth),
+			zap.Int("frontier", len(frontier)),
+			zap.Int("neighbours", len(neighbours)),
+			zap.Int("nodes", len(graph.Nodes)))
+
+		if graph.Truncated {
+			break
+		}
+		frontier = next
+	}
+
+	return graph, nil
+}
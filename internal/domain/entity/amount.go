@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MaxAmount is the largest amount a transfer can carry, 2^256-1
+var MaxAmount = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ParseAmount parses a decimal or 0x-prefixed hexadecimal uint256 amount; an empty amount is
+// zero. The float notation of aggregates written by older versions is truncated to an integer.
+// Negative, non-finite and out of range amounts are errors.
+func ParseAmount(amount string) (*big.Int, error) {
+	value, err := parseInteger(amount, false)
+	if err != nil {
+		return nil, err
+	}
+	if value.Cmp(MaxAmount) > 0 {
+		return nil, fmt.Errorf("amount %q exceeds uint256", amount)
+	}
+	return value, nil
+}
+
+// ParseTotal parses a stored sum of amounts like ParseAmount, except that a sum of many
+// amounts may exceed uint256
+func ParseTotal(total string) (*big.Int, error) {
+	return parseInteger(total, false)
+}
+
+// ParseBalance parses a stored token balance, which goes negative while the history of a
+// token is only partly indexed
+func ParseBalance(balance string) (*big.Int, error) {
+	return parseInteger(balance, true)
+}
+
+// parseInteger parses a decimal, 0x-prefixed hexadecimal or finite float integer; only a
+// signed one may be negative
+func parseInteger(amount string, signed bool) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return new(big.Int), nil
+	}
+	if !signed && strings.HasPrefix(amount, "-") {
+		return nil, fmt.Errorf("negative amount %q", amount)
+	}
+
+	if strings.HasPrefix(amount, "0x") || strings.HasPrefix(amount, "0X") {
+		if len(amount) == 2 {
+			return new(big.Int), nil
+		}
+		// SetString accepts a sign after the prefix
+		digits := amount[2:]
+		if strings.ContainsAny(digits, "+-") {
+			return nil, fmt.Errorf("invalid hexadecimal amount %q", amount)
+		}
+		value, ok := new(big.Int).SetString(digits, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid hexadecimal amount %q", amount)
+		}
+		return value, nil
+	}
+
+	if value, ok := new(big.Int).SetString(amount, 10); ok {
+		return value, nil
+	}
+
+	f, _, err := big.ParseFloat(amount, 10, 256, big.ToZero)
+	if err != nil || f.IsInf() {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	value, _ := f.Int(nil)
+	return value, nil
+}
+
+// AddAmounts returns the exact decimal sum of amounts and totals
+func AddAmounts(amounts ...string) (string, error) {
+	sum := new(big.Int)
+	for _, amount := range amounts {
+		value, err := ParseTotal(amount)
+		if err != nil {
+			return "", err
+		}
+		sum.Add(sum, value)
+	}
+	return sum.String(), nil
+}
+
+// SubtractAmount subtracts a delta from a decimal total, clamping at zero
+func SubtractAmount(total string, delta *big.Int) (string, error) {
+	value, err := ParseTotal(total)
+	if err != nil {
+		return "", err
+	}
+	result := value.Sub(value, delta)
+	if result.Sign() < 0 {
+		result.SetInt64(0)
+	}
+	return result.String(), nil
+}
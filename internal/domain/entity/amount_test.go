@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", amount: "", want: "0"},
+		{name: "decimal", amount: "1000000000000000000", want: "1000000000000000000"},
+		{name: "padded decimal", amount: " 42 ", want: "42"},
+		{name: "hexadecimal", amount: "0xde0b6b3a7640000", want: "1000000000000000000"},
+		{name: "uppercase prefix", amount: "0XFF", want: "255"},
+		{name: "bare prefix", amount: "0x", want: "0"},
+		{name: "legacy float", amount: "1.5e+21", want: "1500000000000000000000"},
+		{name: "truncated float", amount: "12.9", want: "12"},
+		{name: "max uint256", amount: "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", want: MaxAmount.String()},
+		{name: "above uint256", amount: "0x10000000000000000000000000000000000000000000000000000000000000000", wantErr: true},
+		{name: "decimal above uint256", amount: "115792089237316195423570985008687907853269984665640564039457584007913129639936", wantErr: true},
+		{name: "float above uint256", amount: "1e78", wantErr: true},
+		{name: "negative decimal", amount: "-5", wantErr: true},
+		{name: "negative zero fraction", amount: "-0.5", wantErr: true},
+		{name: "negative hexadecimal", amount: "0x-1", wantErr: true},
+		{name: "signed hexadecimal", amount: "0x+1", wantErr: true},
+		{name: "infinity", amount: "inf", wantErr: true},
+		{name: "negative infinity", amount: "-inf", wantErr: true},
+		{name: "signed infinity", amount: "+Inf", wantErr: true},
+		{name: "not a number", amount: "NaN", wantErr: true},
+		{name: "garbage", amount: "abc", wantErr: true},
+		{name: "invalid hexadecimal", amount: "0xzz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.amount)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseAmount(%q) = %v, want an error", tt.amount, got)
+				}
+				if got != nil {
+					t.Fatalf("ParseAmount(%q) returned %v with an error", tt.amount, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q) returned error: %v", tt.amount, err)
+			}
+			if got.String() != tt.want {
+				t.Fatalf("ParseAmount(%q) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAmounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		amounts []string
+		want    string
+		wantErr bool
+	}{
+		{name: "no amounts", want: "0"},
+		{name: "decimal and hexadecimal", amounts: []string{"1", "0x10", ""}, want: "17"},
+		{name: "exact beyond float precision", amounts: []string{"9007199254740993", "1"}, want: "9007199254740994"},
+		{name: "total beyond uint256", amounts: []string{MaxAmount.String(), "1"}, want: "115792089237316195423570985008687907853269984665640564039457584007913129639936"},
+		{name: "infinity", amounts: []string{"1", "inf"}, wantErr: true},
+		{name: "negative infinity", amounts: []string{"-inf"}, wantErr: true},
+		{name: "negative", amounts: []string{"10", "-5"}, wantErr: true},
+		{name: "negative hexadecimal", amounts: []string{"0x-1"}, wantErr: true},
+		{name: "garbage", amounts: []string{"1", "abc"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddAmounts(tt.amounts...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("AddAmounts(%q) = %s, want an error", tt.amounts, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddAmounts(%q) returned error: %v", tt.amounts, err)
+			}
+			if got != tt.want {
+				t.Fatalf("AddAmounts(%q) = %s, want %s", tt.amounts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance string
+		want    string
+		wantErr bool
+	}{
+		{name: "positive", balance: "25", want: "25"},
+		{name: "negative", balance: "-25", want: "-25"},
+		{name: "negative infinity", balance: "-inf", wantErr: true},
+		{name: "garbage", balance: "-abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBalance(tt.balance)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseBalance(%q) = %v, want an error", tt.balance, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBalance(%q) returned error: %v", tt.balance, err)
+			}
+			if got.String() != tt.want {
+				t.Fatalf("ParseBalance(%q) = %s, want %s", tt.balance, got, tt.want)
+			}
+		})
+	}
+}
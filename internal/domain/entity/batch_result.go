@@ -0,0 +1,13 @@
+package entity
+
+// BatchResult reports the outcome of indexing a batch of transactions
+type BatchResult struct {
+	Received           int  `json:"received"`            // transactions handed to the batch
+	Skipped            int  `json:"skipped"`             // duplicates and transactions indexed by an earlier delivery
+	Indexed            int  `json:"indexed"`             // transactions written by this batch
+	Wallets            int  `json:"wallets"`             // wallets created or updated
+	Relationships      int  `json:"relationships"`       // SENT_TO contributions
+	ERC20Relationships int  `json:"erc20_relationships"` // token and contract interaction contributions
+	Contracts          int  `json:"contracts"`           // contracts created or updated
+	Committed          bool `json:"committed"`           // every write of the batch committed together
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"time"
+)
+
+// Block represents the canonical hash recorded for a block height on a network
+type Block struct {
+	Network   string    `json:"network"`
+	Number    uint64    `json:"number"`
+	Hash      string    `json:"hash"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// GraphEffect is one contribution of an indexed transaction to an aggregated relationship,
+// journaled so that the contribution can be rolled back when its block is orphaned
+type GraphEffect struct {
+	RelType         string `json:"rel_type"`
+	FromAddress     string `json:"from_address"`
+	TargetAddress   string `json:"target_address"`             // Wallet or contract node at the end of the relationship
+	ContractAddress string `json:"contract_address,omitempty"` // Relationship contract_address key, if any
+	Spender         string `json:"spender,omitempty"`          // Relationship spender key, approvals only
+	Value           string `json:"value"`
+}
+
+// IndexedTransaction is the processed marker of a transaction together with its rollback journal
+type IndexedTransaction struct {
+	Network     string        `json:"network"`
+	Hash        string        `json:"hash"`
+	BlockNumber uint64        `json:"block_number"`
+	BlockHash   string        `json:"block_hash"`
+	FromAddress string        `json:"from_address"`
+	ToAddress   string        `json:"to_address"`
+	Value       string        `json:"value"`
+	Effects     []GraphEffect `json:"effects"`
+}
+
+// NewIndexedTransaction creates the processed marker for a transaction
+func NewIndexedTransaction(tx *Transaction) *IndexedTransaction {
+	blockNumber, _ := tx.BlockNumberUint64()
+	return &IndexedTransaction{
+		Network:     tx.Network,
+		Hash:        tx.Hash,
+		BlockNumber: blockNumber,
+		BlockHash:   tx.BlockHash,
+		FromAddress: tx.From,
+		ToAddress:   tx.To,
+		Value:       tx.Value,
+		Effects: []GraphEffect{{
+			RelType:       "SENT_TO",
+			FromAddress:   tx.From,
+			TargetAddress: tx.To,
+			Value:         tx.Value,
+		}},
+	}
+}
+
+// Key returns the network-scoped identity of the indexed transaction
+func (t *IndexedTransaction) Key() string {
+	return (&Transaction{Network: t.Network, Hash: t.Hash}).Key()
+}
+
+// AddERC20Effect journals the contribution of a decoded contract interaction
+func (t *IndexedTransaction) AddERC20Effect(rel *ERC20TransferRelationship) {
+	relType := rel.InteractionType.GetRelationshipType()
+	effect := GraphEffect{
+		RelType:         relType,
+		FromAddress:     rel.FromAddress,
+		TargetAddress:   rel.ContractAddress,
+		ContractAddress: rel.ContractAddress,
+		Value:           rel.Value,
+	}
+
+	switch relType {
+	case "ERC20_TRANSFER", "NATIVE_TRANSFER":
+		effect.TargetAddress = rel.ToAddress
+	case "ERC20_APPROVAL":
+		effect.Spender = rel.ToAddress
+	}
+
+	t.Effects = append(t.Effects, effect)
+}
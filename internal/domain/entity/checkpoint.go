@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+)
+
+// IndexerCheckpoint records how far the indexer has progressed on a network: every block up
+// to LastBlock is indexed, blocks between LastBlock and HighestBlock may still be missing
+type IndexerCheckpoint struct {
+	Network        string    `json:"network"`
+	LastBlock      uint64    `json:"last_block"`
+	HighestBlock   uint64    `json:"highest_block"`
+	BlockHash      string    `json:"block_hash"` // hash of the highest block
+	StreamSequence uint64    `json:"stream_sequence"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Version        int64     `json:"-"` // incremented by every write, for conditional updates
+}
+
+// BlockGap is a range of block numbers for which no transaction has been indexed
+type BlockGap struct {
+	Network    string    `json:"network"`
+	FromBlock  uint64    `json:"from_block"`
+	ToBlock    uint64    `json:"to_block"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Size returns the number of missing blocks
+func (g *BlockGap) Size() uint64 {
+	return g.ToBlock - g.FromBlock + 1
+}
+
+// Contains reports whether the block number falls in the gap
+func (g *BlockGap) Contains(number uint64) bool {
+	return number >= g.FromBlock && number <= g.ToBlock
+}
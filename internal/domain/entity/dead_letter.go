@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+)
+
+// DeadLetterStage identifies where in the pipeline a message failed
+type DeadLetterStage string
+
+const (
+	// DeadLetterStageDecode marks payloads that could not be decoded into a valid transaction
+	DeadLetterStageDecode DeadLetterStage = "DECODE"
+	// DeadLetterStageIndex marks transactions whose batch kept failing to persist
+	DeadLetterStageIndex DeadLetterStage = "INDEX"
+)
+
+// DeadLetter represents a message that was removed from the pipeline after failing permanently
+type DeadLetter struct {
+	Subject   string          `json:"subject"` // subject the payload was originally consumed from
+	Payload   []byte          `json:"payload"`
+	Error     string          `json:"error"`
+	Stage     DeadLetterStage `json:"stage"`
+	Attempts  uint64          `json:"attempts"`
+	Timestamp time.Time       `json:"timestamp"`
+	TxHash    string          `json:"tx_hash,omitempty"`
+	Network   string          `json:"network,omitempty"`
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// EdgeRef identifies an aggregated relationship by the properties it is merged on
+type EdgeRef struct {
+	RelType         string `json:"rel_type"`
+	Network         string `json:"network"`
+	From            string `json:"from"`
+	To              string `json:"to"` // receiving wallet, spender of an approval, or contract of an interaction
+	ContractAddress string `json:"contract_address,omitempty"`
+}
+
+// Key returns the identity under which the relationship and its transactions are stored
+func (e EdgeRef) Key() string {
+	return strings.Join([]string{e.RelType, NormalizeNetwork(e.Network), e.From, e.To, e.ContractAddress}, "|")
+}
+
+// Edge returns the SENT_TO relationship a transaction contributes to
+func (r *TransactionRelationship) Edge() EdgeRef {
+	return EdgeRef{RelType: "SENT_TO", Network: r.Network, From: r.FromAddress, To: r.ToAddress}
+}
+
+// Edge returns the relationship a contract interaction contributes to
+func (r *ERC20TransferRelationship) Edge() EdgeRef {
+	edge := EdgeRef{
+		RelType:         r.InteractionType.GetRelationshipType(),
+		Network:         r.Network,
+		From:            r.FromAddress,
+		To:              r.ContractAddress,
+		ContractAddress: r.ContractAddress,
+	}
+	switch edge.RelType {
+	case "ERC20_TRANSFER", "ERC20_APPROVAL", "NATIVE_TRANSFER":
+		edge.To = r.ToAddress
+	}
+	return edge
+}
+
+// Edge returns the relationship a journaled contribution was made to
+func (e GraphEffect) Edge(network string) EdgeRef {
+	edge := EdgeRef{
+		RelType:         e.RelType,
+		Network:         network,
+		From:            e.FromAddress,
+		To:              e.TargetAddress,
+		ContractAddress: e.ContractAddress,
+	}
+	if e.Spender != "" {
+		edge.To = e.Spender
+	}
+	if edge.RelType == LegacyNativeTransferType {
+		// Journaled before native transfers were migrated; the asset address was not recorded
+		edge.RelType = "NATIVE_TRANSFER"
+		edge.ContractAddress = NativeAssetAddress
+	}
+	return edge
+}
+
+// EdgeEvent is one transaction's contribution to an aggregated relationship
+type EdgeEvent struct {
+	TxHash          string    `json:"tx_hash"`
+	Value           string    `json:"value"`
+	Timestamp       time.Time `json:"timestamp"`
+	InteractionType string    `json:"interaction_type,omitempty"`
+	MethodSignature string    `json:"method_signature,omitempty"`
+}
+
+// EdgeHistoryQuery selects a page of the transactions of a relationship, newest first
+type EdgeHistoryQuery struct {
+	Edge   EdgeRef
+	Since  time.Time // inclusive lower bound; zero for none
+	Until  time.Time // exclusive upper bound; zero for none
+	Cursor string    // NextCursor of the previous page; empty for the first page
+	Limit  int
+}
+
+// EdgeHistoryPage is a page of the transactions of a relationship
+type EdgeHistoryPage struct {
+	Events     []*EdgeEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"` // empty on the last page
+}
@@ -24,7 +24,7 @@ const (
 	InteractionMulticall       ContractInteractionType = "MULTICALL"
 
 	// Special Cases
-	InteractionETHTransfer     ContractInteractionType = "ETH_TRANSFER"
+	InteractionNativeTransfer  ContractInteractionType = "NATIVE_TRANSFER" // value in the network's native currency
 	InteractionUnknownContract ContractInteractionType = "UNKNOWN_CONTRACT_CALL"
 )
 
@@ -41,6 +41,7 @@ type ERC20Transfer struct {
 	InteractionType ContractInteractionType `json:"interaction_type"` // New field for interaction type
 	MethodSignature string                  `json:"method_signature"` // New field for method signature
 	Success         bool                    `json:"success"`          // New field for transaction success
+	LogIndex        uint64                  `json:"log_index"`        // Index of the emitting event log (receipt-decoded transfers only)
 }
 
 // ERC20Contract represents an ERC20 contract
@@ -101,8 +102,8 @@ func (c ContractInteractionType) GetRelationshipType() string {
 		return "DEFI_OPERATION"
 	case InteractionMulticall:
 		return "MULTICALL_OPERATION"
-	case InteractionETHTransfer:
-		return "ETH_TRANSFER"
+	case InteractionNativeTransfer:
+		return "NATIVE_TRANSFER"
 	case InteractionUnknownContract:
 		return "CONTRACT_INTERACTION"
 	default:
@@ -148,6 +149,9 @@ const (
 	ContractTypeProxy     ContractType = "PROXY"
 	ContractTypeWETH      ContractType = "WETH"
 
+	// Native currency of a network, modelled as an asset alongside tokens
+	ContractTypeNative ContractType = "NATIVE"
+
 	// Unknown/Generic
 	ContractTypeUnknown ContractType = "UNKNOWN"
 	ContractTypeGeneric ContractType = "GENERIC_CONTRACT"
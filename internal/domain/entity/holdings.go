@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"math/big"
+	"strings"
+)
+
+// ZeroAddress is the sender of minted and the receiver of burned tokens. It is not a holder:
+// its balance would only mirror the supply.
+const ZeroAddress = "0x0000000000000000000000000000000000000000"
+
+// DefaultBubbleMapHolders is the number of holders of a token bubble map when none is requested
+const DefaultBubbleMapHolders = 100
+
+// IsHolderAddress reports whether token balances are tracked for an address
+func IsHolderAddress(address string) bool {
+	return address != "" && !strings.EqualFold(address, ZeroAddress)
+}
+
+// TokenHolder is a wallet's balance of a token, as derived from the indexed transfers
+type TokenHolder struct {
+	Address    string  `json:"address"`
+	NodeType   string  `json:"node_type,omitempty"`
+	Balance    string  `json:"balance"`    // exact decimal amount in the token's smallest unit
+	Percentage float64 `json:"percentage"` // share of the indexed supply, 0-100
+}
+
+// TokenHolderLink is the aggregated transfer relationship between two top holders of a token
+type TokenHolderLink struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	TotalValue string `json:"total_value"`
+	TxCount    int64  `json:"tx_count"`
+}
+
+// TokenBubbleMap is the top holders of a token and the transfers among them
+type TokenBubbleMap struct {
+	Network     string             `json:"network"`
+	Token       string             `json:"token"`
+	Symbol      string             `json:"symbol,omitempty"`
+	Decimals    int                `json:"decimals"`
+	Supply      string             `json:"supply"`       // sum of the positive balances of all holders
+	HolderCount int64              `json:"holder_count"` // holders with a positive balance
+	Holders     []*TokenHolder     `json:"holders"`
+	Links       []*TokenHolderLink `json:"links"`
+}
+
+// SupplyPercentage returns balance as a percentage of supply, or 0 without a valid supply
+func SupplyPercentage(balance, supply string) float64 {
+	total, err := ParseTotal(supply)
+	if err != nil || total.Sign() <= 0 {
+		return 0
+	}
+	value, err := ParseTotal(balance)
+	if err != nil {
+		return 0
+	}
+	share := new(big.Rat).SetFrac(new(big.Int).Mul(value, big.NewInt(100)), total)
+	percentage, _ := share.Float64()
+	return percentage
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"strings"
+)
+
+// Log represents an event log emitted during transaction execution, as found in the receipt
+type Log struct {
+	Address  string   `json:"address"`
+	Topics   []string `json:"topics"`
+	Data     string   `json:"data"`
+	LogIndex uint64   `json:"log_index"`
+	Removed  bool     `json:"removed"`
+}
+
+// Transaction receipt status values
+const (
+	TransactionStatusSuccess = "success"
+	TransactionStatusFailed  = "failed"
+)
+
+// HasReceipt reports whether the transaction carries receipt data (status and logs)
+func (t *Transaction) HasReceipt() bool {
+	return t.Status != "" || len(t.Logs) > 0
+}
+
+// Failed reports whether the receipt marks the transaction as reverted
+func (t *Transaction) Failed() bool {
+	switch strings.ToLower(strings.TrimSpace(t.Status)) {
+	case "0", "0x0", TransactionStatusFailed, "reverted":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRedeliveryUnsupported is returned by Nak when the source cannot redeliver a message,
+// e.g. core NATS; the caller dead-letters the message instead of losing it
+var ErrRedeliveryUnsupported = errors.New("source cannot redeliver messages")
+
+// MessageHandle settles the delivery of a consumed transaction with its source
+type MessageHandle interface {
+	// Ack confirms the transaction has been persisted
+	Ack() error
+
+	// Nak asks the source to redeliver the transaction after the given delay; it returns
+	// ErrRedeliveryUnsupported when the source cannot
+	Nak(delay time.Duration) error
+
+	// Term tells the source to stop redelivering the transaction
+	Term() error
+
+	// NumDelivered returns how many times the transaction has been delivered
+	NumDelivered() uint64
+}
+
+// TransactionMessage pairs a decoded transaction with the handle used to settle its delivery
+type TransactionMessage struct {
+	Transaction *Transaction
+	Handle      MessageHandle
+	Subject     string // subject or source the message was read from
+	Payload     []byte // raw payload, kept for dead-lettering
+	Sequence    uint64 // JetStream stream sequence, 0 for other sources
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// NativeAssetAddress stands for the native currency of a network wherever a token address
+	// is expected (EIP-7528), so native and token flows share one model
+	NativeAssetAddress = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+	// NativeAssetDecimals is the number of decimals of the native currency of EVM networks
+	NativeAssetDecimals = 18
+
+	// LegacyNativeTransferType is the relationship type native transfers used to be stored as
+	LegacyNativeTransferType = "ETH_TRANSFER"
+)
+
+// nativeSymbols maps networks to the symbol of their native currency
+var nativeSymbols = map[string]string{
+	"ethereum":  "ETH",
+	"arbitrum":  "ETH",
+	"optimism":  "ETH",
+	"base":      "ETH",
+	"bsc":       "BNB",
+	"polygon":   "POL",
+	"avalanche": "AVAX",
+	"fantom":    "FTM",
+	"gnosis":    "XDAI",
+}
+
+// IsNativeAsset reports whether an asset address designates the native currency; the
+// "ETH" placeholder of older decoders is accepted too
+func IsNativeAsset(address string) bool {
+	return strings.EqualFold(address, NativeAssetAddress) || strings.EqualFold(address, "ETH")
+}
+
+// NativeAssetSymbol returns the symbol of the native currency of a network
+func NativeAssetSymbol(network string) string {
+	if symbol, ok := nativeSymbols[NormalizeNetwork(network)]; ok {
+		return symbol
+	}
+	return strings.ToUpper(NormalizeNetwork(network))
+}
+
+// NewNativeAsset returns the asset node of the native currency of a network
+func NewNativeAsset(network string, seen time.Time) *ERC20Contract {
+	symbol := NativeAssetSymbol(network)
+	return &ERC20Contract{
+		Address:      NativeAssetAddress,
+		Name:         symbol,
+		Symbol:       symbol,
+		Decimals:     NativeAssetDecimals,
+		FirstSeen:    seen,
+		LastSeen:     seen,
+		TotalTxs:     1,
+		Network:      NormalizeNetwork(network),
+		ContractType: string(ContractTypeNative),
+		IsVerified:   true,
+	}
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"strings"
+)
+
+// DefaultNetwork is the network of transactions and graph nodes that do not name one
+const DefaultNetwork = "ethereum"
+
+// NormalizeNetwork returns the canonical form of a network name; wallets and contracts
+// are identified by the normalized network together with their address
+func NormalizeNetwork(network string) string {
+	network = strings.ToLower(strings.TrimSpace(network))
+	if network == "" {
+		return DefaultNetwork
+	}
+	return network
+}
+
+// NetworkScopedKey returns the identity of an address on a network
+func NetworkScopedKey(network, address string) string {
+	return NormalizeNetwork(network) + ":" + address
+}
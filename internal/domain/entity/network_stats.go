@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+)
+
+// NetworkStats reports the throughput and backlog of the processing pipeline of one network
+type NetworkStats struct {
+	Network       string    `json:"network"`
+	BatchSize     int       `json:"batch_size"`
+	Workers       int       `json:"workers"`
+	Transactions  uint64    `json:"transactions"`   // transactions indexed since start
+	Batches       uint64    `json:"batches"`        // batches committed since start
+	FailedBatches uint64    `json:"failed_batches"` // batches rejected for redelivery
+	TxPerSecond   float64   `json:"tx_per_second"`  // throughput over the recent window
+	Buffered      int       `json:"buffered"`       // transactions received but not yet committed
+	Lag           uint64    `json:"lag"`            // transactions waiting upstream plus buffered
+	LastBatchAt   time.Time `json:"last_batch_at,omitempty"`
+}
@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxPathHops bounds the length of value-flow path searches
+const MaxPathHops = 6
+
+// PathQuery describes a value-flow path search between two wallets
+type PathQuery struct {
+	Network string `json:"network,omitempty"` // empty: the networks both wallets exist on
+	From    string `json:"from"`
+	To      string `json:"to"`
+	MaxHops int    `json:"max_hops"` // clamped to [1, MaxPathHops]
+
+	// Token restricts the search to transfers of one asset; NativeAssetAddress follows
+	// native transfers. Empty follows SENT_TO and ERC20_TRANSFER edges of any token.
+	Token string `json:"token,omitempty"`
+
+	// Causal only keeps paths whose hops can be ordered in time: every hop has a transaction
+	// at or after the earliest usable transaction of the hop before it
+	Causal bool `json:"causal,omitempty"`
+
+	K       int `json:"k"`        // number of shortest paths to return
+	TxLimit int `json:"tx_limit"` // transaction hashes returned per hop
+}
+
+// RelationshipTypes returns the relationship types the search follows
+func (q *PathQuery) RelationshipTypes() []string {
+	switch {
+	case q.Token == "":
+		return []string{"SENT_TO", "ERC20_TRANSFER"}
+	case IsNativeAsset(q.Token):
+		return []string{"NATIVE_TRANSFER"}
+	default:
+		return []string{"ERC20_TRANSFER"}
+	}
+}
+
+// TokenAddress returns the contract address hops must carry, or empty for any
+func (q *PathQuery) TokenAddress() string {
+	if IsNativeAsset(q.Token) {
+		return NativeAssetAddress
+	}
+	return strings.TrimSpace(q.Token)
+}
+
+// PathHop is one aggregated relationship of a path
+type PathHop struct {
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	RelType         string    `json:"rel_type"`
+	ContractAddress string    `json:"contract_address,omitempty"`
+	TotalValue      string    `json:"total_value"`
+	TxCount         int64     `json:"tx_count"`
+	FirstTx         time.Time `json:"first_tx"`
+	LastTx          time.Time `json:"last_tx"`
+	TxHashes        []string  `json:"tx_hashes"` // causal paths: the earliest usable transactions
+}
+
+// TransactionPath is a path of value flows between two wallets
+type TransactionPath struct {
+	Network string     `json:"network"`
+	Hops    []*PathHop `json:"hops"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+// RepairReport summarizes a recomputation of stored aggregates
+type RepairReport struct {
+	Scope    string `json:"scope"`    // relationship type, "Wallet" for wallet totals or "ERC20Contract" for token supply
+	Scanned  int64  `json:"scanned"`  // aggregates read
+	Repaired int64  `json:"repaired"` // aggregates that differed from their recomputed value
+	Skipped  int64  `json:"skipped"`  // aggregates changed by the indexer while being repaired
+	Invalid  int64  `json:"invalid"`  // aggregates left unchanged because a value they are built from is malformed
+}
+
+// AggregatedRelationshipTypes lists the relationship types whose total_value is built from TxEvent nodes
+var AggregatedRelationshipTypes = []string{
+	"SENT_TO",
+	"ERC20_TRANSFER",
+	"ERC20_APPROVAL",
+	"DEX_SWAP",
+	"LIQUIDITY_OPERATION",
+	"DEFI_OPERATION",
+	"MULTICALL_OPERATION",
+	"NATIVE_TRANSFER",
+	"CONTRACT_INTERACTION",
+}
@@ -0,0 +1,9 @@
+package entity
+
+// RetryStats counts the outcomes of retried database writes since start
+type RetryStats struct {
+	Attempts  uint64 `json:"attempts"`  // write attempts, including retries
+	Retries   uint64 `json:"retries"`   // attempts repeated after a transient error
+	Exhausted uint64 `json:"exhausted"` // writes that still failed transiently after the last attempt
+	Permanent uint64 `json:"permanent"` // writes that failed with a non-transient error
+}
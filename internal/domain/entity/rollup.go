@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// RollupRelationshipTypes lists the wallet-to-wallet flows that are also aggregated per time
+// bucket (ROLLUP relationships), so bubble maps can be rendered for any period
+var RollupRelationshipTypes = []string{
+	"SENT_TO",
+	"ERC20_TRANSFER",
+	"NATIVE_TRANSFER",
+}
+
+// HasRollups reports whether relationships of the type are aggregated per time bucket
+func HasRollups(relType string) bool {
+	for _, rolled := range RollupRelationshipTypes {
+		if relType == rolled {
+			return true
+		}
+	}
+	return false
+}
+
+// RollupBucketStart returns the start of the time bucket a timestamp falls into; buckets are
+// aligned to UTC, so daily buckets start at midnight
+func RollupBucketStart(timestamp time.Time, bucket time.Duration) time.Time {
+	return timestamp.UTC().Truncate(bucket)
+}
+
+// TimeWindow restricts a query to the rollup buckets starting in [Since, Until); a zero bound
+// leaves that side open
+type TimeWindow struct {
+	Since time.Time `json:"since,omitempty"`
+	Until time.Time `json:"until,omitempty"`
+
+	// Token restricts the window to the transfers of one asset; NativeAssetAddress selects
+	// native transfers. Empty uses the SENT_TO rollups.
+	Token string `json:"token,omitempty"`
+}
+
+// IsZero reports whether the window is unbounded and covers every asset, in which case
+// lifetime totals are used
+func (w *TimeWindow) IsZero() bool {
+	return w == nil || (w.Since.IsZero() && w.Until.IsZero() && w.Token == "")
+}
+
+// RelationshipTypes returns the relationship types whose rollups the window covers
+func (w *TimeWindow) RelationshipTypes() []string {
+	switch {
+	case w == nil || w.Token == "":
+		return []string{"SENT_TO"}
+	case IsNativeAsset(w.Token):
+		return []string{"NATIVE_TRANSFER"}
+	default:
+		return []string{"ERC20_TRANSFER"}
+	}
+}
+
+// TokenAddress returns the contract address the rollups must carry, or empty for any
+func (w *TimeWindow) TokenAddress() string {
+	if w == nil {
+		return ""
+	}
+	if IsNativeAsset(w.Token) {
+		return NativeAssetAddress
+	}
+	return strings.ToLower(strings.TrimSpace(w.Token))
+}
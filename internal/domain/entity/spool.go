@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+)
+
+// SpooledBatch is a decoded batch parked on local disk while the graph store is unavailable
+type SpooledBatch struct {
+	Network      string               `json:"network"`
+	SpooledAt    time.Time            `json:"spooled_at"`
+	Transactions []SpooledTransaction `json:"transactions"`
+}
+
+// SpooledTransaction is a transaction of a spooled batch with the delivery details its
+// checkpoint is recorded from
+type SpooledTransaction struct {
+	Transaction *Transaction `json:"transaction"`
+	Subject     string       `json:"subject"`
+	Sequence    uint64       `json:"sequence,omitempty"`
+}
+
+// Messages returns the transactions of the batch as messages without a delivery handle
+func (b *SpooledBatch) Messages() []*TransactionMessage {
+	messages := make([]*TransactionMessage, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		messages[i] = &TransactionMessage{
+			Transaction: tx.Transaction,
+			Subject:     tx.Subject,
+			Sequence:    tx.Sequence,
+		}
+	}
+	return messages
+}
+
+// SpoolStats reports the state of the local spool
+type SpoolStats struct {
+	Enabled        bool   `json:"enabled"`
+	StoreAvailable bool   `json:"store_available"`
+	Batches        int    `json:"batches"`
+	Bytes          int64  `json:"bytes"`
+	MaxBytes       int64  `json:"max_bytes"`
+	Spooled        uint64 `json:"spooled"`  // batches spooled since startup
+	Replayed       uint64 `json:"replayed"` // batches replayed since startup
+}
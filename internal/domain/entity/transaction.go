@@ -1,6 +1,9 @@
 package entity
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,6 +20,36 @@ type Transaction struct {
 	GasUsed     string    `json:"gas_used"`
 	GasPrice    string    `json:"gas_price"`
 	Network     string    `json:"network"`
+	Status      string    `json:"status,omitempty"` // Receipt status: "0x1"/"1"/"success" or "0x0"/"0"/"failed"
+	Logs        []Log     `json:"logs,omitempty"`   // Receipt event logs
+}
+
+// Key returns the network-scoped identity of the transaction used for idempotent indexing
+func (t *Transaction) Key() string {
+	return strings.ToLower(t.Network) + ":" + strings.ToLower(t.Hash)
+}
+
+// BlockNumberUint64 parses the block number, which crawlers emit either as decimal or 0x-prefixed hex
+func (t *Transaction) BlockNumberUint64() (uint64, error) {
+	raw := strings.TrimSpace(t.BlockNumber)
+	if raw == "" {
+		return 0, fmt.Errorf("transaction %s has no block number", t.Hash)
+	}
+
+	var (
+		number uint64
+		err    error
+	)
+	if strings.HasPrefix(raw, "0x") || strings.HasPrefix(raw, "0X") {
+		number, err = strconv.ParseUint(raw[2:], 16, 64)
+	} else {
+		number, err = strconv.ParseUint(raw, 10, 64)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid block number %q for transaction %s: %w", t.BlockNumber, t.Hash, err)
+	}
+
+	return number, nil
 }
 
 // TransactionNode represents a transaction node in Neo4J
@@ -38,4 +71,5 @@ type TransactionRelationship struct {
 	GasPrice    string    `json:"gas_price"`
 	Timestamp   time.Time `json:"timestamp"`
 	TxHash      string    `json:"tx_hash"`
+	Network     string    `json:"network"`
 }
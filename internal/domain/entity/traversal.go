@@ -0,0 +1,106 @@
+package entity
+
+import "time"
+
+// MaxTraversalDepth bounds the number of hops a traversal expands
+const MaxTraversalDepth = 4
+
+// TraversalDirection selects which relationships of a wallet a traversal follows
+type TraversalDirection string
+
+const (
+	TraversalOutgoing TraversalDirection = "OUT"  // relationships the wallet sent
+	TraversalIncoming TraversalDirection = "IN"   // relationships the wallet received
+	TraversalBoth     TraversalDirection = "BOTH" // both
+)
+
+// HubPolicy decides how a traversal treats hub wallets such as exchanges and routers
+type HubPolicy string
+
+const (
+	HubPolicyCollapse HubPolicy = "COLLAPSE" // keep hubs as leaves: their edges are shown, they are not expanded
+	HubPolicySkip     HubPolicy = "SKIP"     // leave hubs and their edges out of the subgraph
+	HubPolicyExpand   HubPolicy = "EXPAND"   // expand hubs like any other wallet
+)
+
+// DefaultHubNodeTypes lists the classifications whose wallets connect large numbers of
+// unrelated users, so expanding through them links everyone to everyone
+var DefaultHubNodeTypes = []NodeType{
+	NodeTypeExchangeWallet,
+	NodeTypeExchangeHotWallet,
+	NodeTypeExchangeColdWallet,
+	NodeTypeCEXDeposit,
+	NodeTypeCEXWithdrawal,
+	NodeTypeCEXSettlement,
+	NodeTypeDEXContract,
+	NodeTypeBridgeWallet,
+	NodeTypeBridgeContract,
+	NodeTypeMixerWallet,
+	NodeTypePrivacyContract,
+	NodeTypeTokenContract,
+}
+
+// TraversalQuery describes a bounded expansion of the graph around a wallet
+type TraversalQuery struct {
+	Network   string             `json:"network,omitempty"` // empty: the default network
+	Root      string             `json:"root"`
+	MaxDepth  int                `json:"max_depth"`           // clamped to [1, MaxTraversalDepth]
+	Direction TraversalDirection `json:"direction,omitempty"` // empty: BOTH
+
+	// FanOut caps the edges followed per wallet and level, strongest first; MaxNodes caps
+	// the subgraph
+	FanOut   int `json:"fan_out"`
+	MaxNodes int `json:"max_nodes"`
+
+	// Edge filters; RelTypes defaults to the wallet-to-wallet flows (RollupRelationshipTypes)
+	RelTypes []string `json:"rel_types,omitempty"`
+	Token    string   `json:"token,omitempty"`     // contract address edges must carry
+	MinValue string   `json:"min_value,omitempty"` // minimum total_value, in base units
+
+	// Hubs are wallets classified as one of HubTypes (DefaultHubNodeTypes when empty), or
+	// with more than HubDegree SENT_TO relationships when set
+	HubTypes  []NodeType `json:"hub_types,omitempty"`
+	HubDegree int64      `json:"hub_degree,omitempty"`
+	HubPolicy HubPolicy  `json:"hub_policy,omitempty"` // empty: COLLAPSE
+}
+
+// SubgraphNode is a wallet reached by a traversal
+type SubgraphNode struct {
+	Address  string   `json:"address"`
+	Network  string   `json:"network"`
+	NodeType NodeType `json:"node_type,omitempty"`
+	Depth    int      `json:"depth"`
+	Degree   int64    `json:"degree"`             // SENT_TO relationships
+	Hub      bool     `json:"hub,omitempty"`      // not expanded unless the policy is EXPAND
+	Capped   bool     `json:"capped,omitempty"`   // more edges than the fan-out were left out
+	Expanded bool     `json:"expanded,omitempty"` // its edges were followed
+}
+
+// SubgraphEdge is an aggregated relationship between two wallets of a subgraph
+type SubgraphEdge struct {
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	RelType         string    `json:"rel_type"`
+	ContractAddress string    `json:"contract_address,omitempty"`
+	TotalValue      string    `json:"total_value"`
+	TxCount         int64     `json:"tx_count"`
+	FirstTx         time.Time `json:"first_tx"`
+	LastTx          time.Time `json:"last_tx"`
+}
+
+// GraphNeighbour is an edge followed from a wallet of a traversal frontier, with the wallet at
+// its other end
+type GraphNeighbour struct {
+	Source string
+	Edge   *SubgraphEdge
+	Node   *SubgraphNode // Depth is set by the traversal
+}
+
+// Subgraph is the result of a traversal
+type Subgraph struct {
+	Root      string          `json:"root"`
+	Network   string          `json:"network"`
+	Nodes     []*SubgraphNode `json:"nodes"`
+	Edges     []*SubgraphEdge `json:"edges"`
+	Truncated bool            `json:"truncated"` // MaxNodes was reached
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// BlockRepository tracks canonical block hashes and rolls back orphaned blocks
+type BlockRepository interface {
+	// GetBlockHashes returns the recorded hash per block number
+	GetBlockHashes(ctx context.Context, network string, numbers []uint64) (map[uint64]string, error)
+
+	// FindOrphanedBlocks returns the block numbers whose hash was rolled back as orphaned
+	FindOrphanedBlocks(ctx context.Context, network string, hashes map[uint64]string) (map[uint64]bool, error)
+
+	// GetHighestBlock returns the highest recorded block number, false if none is recorded
+	GetHighestBlock(ctx context.Context, network string) (uint64, bool, error)
+
+	// SaveBlocks records the canonical hash of each block
+	SaveBlocks(ctx context.Context, blocks []*entity.Block) error
+
+	// RollbackFrom undoes the graph contributions of every transaction indexed at or above
+	// the block number, removes their processed markers, records those blocks as orphaned and
+	// moves the network checkpoint below them
+	RollbackFrom(ctx context.Context, network string, fromNumber uint64) ([]*entity.IndexedTransaction, error)
+
+	// PruneJournal drops the rollback journal of transactions and the orphaned hashes below the
+	// block number
+	PruneJournal(ctx context.Context, network string, belowNumber uint64) error
+}
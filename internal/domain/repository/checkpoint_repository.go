@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// CheckpointRepository persists per-network indexing checkpoints
+type CheckpointRepository interface {
+	// GetCheckpoint returns the checkpoint of a network, nil if none is recorded
+	GetCheckpoint(ctx context.Context, network string) (*entity.IndexerCheckpoint, error)
+
+	// GetCheckpoints returns the checkpoints of all networks
+	GetCheckpoints(ctx context.Context) ([]*entity.IndexerCheckpoint, error)
+
+	// GetGaps returns the open block gaps of a network, or of all networks if network is ""
+	GetGaps(ctx context.Context, network string) ([]*entity.BlockGap, error)
+
+	// SaveCheckpoint replaces the checkpoint and open gaps of a network if the checkpoint still
+	// has the version it was read with (0 when none was recorded). It returns false without
+	// writing anything when another writer updated the checkpoint first.
+	SaveCheckpoint(ctx context.Context, checkpoint *entity.IndexerCheckpoint, gaps []*entity.BlockGap) (bool, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// EdgeHistoryRepository defines the interface for reading the transactions behind aggregated relationships
+type EdgeHistoryRepository interface {
+	// GetEdgeTransactions returns a page of the transactions that contributed to a relationship, newest first
+	GetEdgeTransactions(ctx context.Context, query *entity.EdgeHistoryQuery) (*entity.EdgeHistoryPage, error)
+}
@@ -16,8 +16,8 @@ type ERC20Repository interface {
 	// BatchCreateERC20TransferRelationships creates multiple transfer relationships in batch
 	BatchCreateERC20TransferRelationships(ctx context.Context, transfers []*entity.ERC20TransferRelationship) error
 
-	// GetERC20Contract retrieves an ERC20 contract by address
-	GetERC20Contract(ctx context.Context, address string) (*entity.ERC20Contract, error)
+	// GetERC20Contract retrieves the ERC20 contract of an address on a network
+	GetERC20Contract(ctx context.Context, network, address string) (*entity.ERC20Contract, error)
 
 	// GetERC20TransfersBetweenWallets retrieves ERC20 transfers between two wallets
 	GetERC20TransfersBetweenWallets(ctx context.Context, fromAddress, toAddress string, limit int) ([]*entity.ERC20Transfer, error)
@@ -25,6 +25,9 @@ type ERC20Repository interface {
 	// GetERC20TransfersForWallet retrieves all ERC20 transfers for a wallet
 	GetERC20TransfersForWallet(ctx context.Context, address string, limit int) ([]*entity.ERC20Transfer, error)
 
+	// GetTokenBubbleMap retrieves the top holders of a token on a network and the transfers among them
+	GetTokenBubbleMap(ctx context.Context, network, token string, topN int) (*entity.TokenBubbleMap, error)
+
 	// Contract Classification Methods
 	// StoreContractClassification stores contract classification data
 	StoreContractClassification(ctx context.Context, classification *entity.ContractClassification) error
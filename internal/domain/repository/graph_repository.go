@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// GraphRepository defines the interface for expanding the wallet graph level by level
+type GraphRepository interface {
+	// GetNeighbours returns, for each wallet of the frontier, its strongest edges that pass the
+	// filters of the query, at most fanOut+1 per wallet so callers can tell when the cap was
+	// hit, together with the classification and degree of the wallet at the other end. Hubs
+	// are left out when the query skips them.
+	GetNeighbours(ctx context.Context, query *entity.TraversalQuery, frontier []string, fanOut int) ([]*entity.GraphNeighbour, error)
+}
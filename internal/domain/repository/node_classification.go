@@ -10,8 +10,8 @@ type NodeClassificationRepository interface {
 	// CreateOrUpdateClassification creates or updates a node classification
 	CreateOrUpdateClassification(ctx context.Context, classification *entity.NodeClassification) error
 
-	// GetClassification retrieves a node classification by address
-	GetClassification(ctx context.Context, address string) (*entity.NodeClassification, error)
+	// GetClassification retrieves the node classification of an address on a network
+	GetClassification(ctx context.Context, network, address string) (*entity.NodeClassification, error)
 
 	// GetClassificationsByType retrieves all nodes of a specific type
 	GetClassificationsByType(ctx context.Context, nodeType entity.NodeType) ([]*entity.NodeClassification, error)
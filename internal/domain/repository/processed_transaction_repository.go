@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"errors"
+)
+
+// ErrAlreadyProcessed is returned by MarkProcessed when another delivery of a transaction
+// committed its marker first; the unit of work is then retried and skips the transaction
+var ErrAlreadyProcessed = errors.New("transaction already processed")
+
+// ProcessedTransactionRepository tracks which transactions have already been indexed
+type ProcessedTransactionRepository interface {
+	// FilterUnprocessed returns the transactions that have not been indexed yet. Called
+	// within a unit of work, the lookup is part of its transaction.
+	FilterUnprocessed(ctx context.Context, transactions []*entity.Transaction) ([]*entity.Transaction, error)
+
+	// MarkProcessed records transactions as indexed so replays become no-ops, together with
+	// the journal of graph contributions needed to roll them back on a reorg. It fails with
+	// ErrAlreadyProcessed when a marker already exists.
+	MarkProcessed(ctx context.Context, transactions []*entity.IndexedTransaction) error
+}
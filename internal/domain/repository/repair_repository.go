@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// RepairRepository recomputes stored aggregates from the data they were built from
+type RepairRepository interface {
+	// RepairEdgeTotals recomputes the total_value of every relationship of the type from its
+	// TxEvent nodes; with dryRun the differences are only counted
+	RepairEdgeTotals(ctx context.Context, relType string, batchSize int, dryRun bool) (*entity.RepairReport, error)
+
+	// RepairEdgeRollups rebuilds the time-bucketed rollups of every relationship of the type
+	// from its TxEvent nodes; with dryRun the differences are only counted
+	RepairEdgeRollups(ctx context.Context, relType string, batchSize int, dryRun bool) (*entity.RepairReport, error)
+
+	// RepairWalletTotals recomputes wallet total_sent and total_received from their SENT_TO
+	// relationship totals; with dryRun the differences are only counted
+	RepairWalletTotals(ctx context.Context, batchSize int, dryRun bool) (*entity.RepairReport, error)
+
+	// RepairTokenHoldings recomputes the HOLDS balances of every wallet from its ERC20_TRANSFER
+	// relationship totals; with dryRun the differences are only counted
+	RepairTokenHoldings(ctx context.Context, batchSize int, dryRun bool) (*entity.RepairReport, error)
+}
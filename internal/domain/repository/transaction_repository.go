@@ -16,13 +16,14 @@ type TransactionRepository interface {
 	// GetTransaction retrieves a transaction by hash
 	GetTransaction(ctx context.Context, hash string) (*entity.TransactionNode, error)
 
-	// GetTransactionPath finds the path between two wallets through transactions
-	GetTransactionPath(ctx context.Context, fromAddress, toAddress string, maxHops int) ([]*entity.TransactionNode, error)
+	// GetTransactionPath finds the k shortest value-flow paths between two wallets, with the
+	// aggregates and transaction hashes of every hop
+	GetTransactionPath(ctx context.Context, query *entity.PathQuery) ([]*entity.TransactionPath, error)
 
-	// GetTransactionsByWallet retrieves transactions for a specific wallet
+	// GetTransactionsByWallet retrieves the latest transactions sent or received by a wallet
 	GetTransactionsByWallet(ctx context.Context, address string, limit int) ([]*entity.TransactionNode, error)
 
-	// GetTransactionsByTimeRange retrieves transactions within a time range
+	// GetTransactionsByTimeRange retrieves the transactions of [startTime, endTime) (ISO-8601), oldest first
 	GetTransactionsByTimeRange(ctx context.Context, startTime, endTime string, limit int) ([]*entity.TransactionNode, error)
 
 	// BatchCreateTransactions creates multiple transactions in a batch
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+)
+
+// UnitOfWork makes a set of repository writes atomic
+type UnitOfWork interface {
+	// Do runs fn in a single write transaction. Repository writes made with the context
+	// passed to fn join that transaction and commit together when fn returns nil; an error
+	// rolls all of them back. A nested Do joins the outer unit of work. fn may be run again
+	// when the transaction fails with a transient error, so it must only write.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -7,24 +7,35 @@ import (
 
 // WalletRepository defines the interface for wallet data operations
 type WalletRepository interface {
-	// CreateOrUpdateWallet creates a new wallet or updates existing one
+	// CreateOrUpdateWallet creates a new wallet or adds the wallet's counters to the existing one
 	CreateOrUpdateWallet(ctx context.Context, wallet *entity.Wallet) error
 
-	// GetWallet retrieves a wallet by address
-	GetWallet(ctx context.Context, address string) (*entity.Wallet, error)
+	// BatchCreateOrUpdateWallets upserts many wallets at once; counters are added to the
+	// stored ones and first_seen/last_seen only ever widen
+	BatchCreateOrUpdateWallets(ctx context.Context, wallets []*entity.Wallet) error
 
-	// GetWalletStats retrieves statistics for a wallet
-	GetWalletStats(ctx context.Context, address string) (*entity.WalletStats, error)
+	// LinkSameAddressWallets links each wallet to the wallets sharing its address on other
+	// networks (SAME_ADDRESS_AS); it does nothing unless linking is enabled
+	LinkSameAddressWallets(ctx context.Context, wallets []*entity.Wallet) error
 
-	// GetWalletConnections retrieves connections for a wallet
-	GetWalletConnections(ctx context.Context, address string, limit int) ([]*entity.WalletConnection, error)
+	// GetWallet retrieves the wallet of an address on a network
+	GetWallet(ctx context.Context, network, address string) (*entity.Wallet, error)
 
-	// FindConnectedWallets finds wallets connected to a given wallet within specified hops
-	FindConnectedWallets(ctx context.Context, address string, maxHops int) ([]*entity.Wallet, error)
+	// GetWalletStats retrieves statistics for the wallet of an address on a network
+	GetWalletStats(ctx context.Context, network, address string) (*entity.WalletStats, error)
+
+	// GetWalletConnections retrieves connections for a wallet; a non-empty window sums the
+	// time-bucketed rollups of that period instead of the lifetime totals
+	GetWalletConnections(ctx context.Context, network, address string, window *entity.TimeWindow, limit int) ([]*entity.WalletConnection, error)
+
+	// FindConnectedWallets finds wallets connected to the wallet of an address on a network
+	// within specified hops
+	FindConnectedWallets(ctx context.Context, network, address string, maxHops int) ([]*entity.Wallet, error)
 
 	// GetTopWallets retrieves top wallets by transaction count
 	GetTopWallets(ctx context.Context, limit int) ([]*entity.Wallet, error)
 
-	// GetBubbleWallets retrieves wallets that form bubbles (high connectivity)
-	GetBubbleWallets(ctx context.Context, minConnections int, limit int) ([]*entity.Wallet, error)
+	// GetBubbleWallets retrieves the wallets of a network that form bubbles (high
+	// connectivity); a non-empty window only counts the connections active in that period
+	GetBubbleWallets(ctx context.Context, network string, minConnections int, window *entity.TimeWindow, limit int) ([]*entity.Wallet, error)
 }
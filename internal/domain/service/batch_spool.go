@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"errors"
+)
+
+// ErrSpoolFull is returned when a batch does not fit in the spool
+var ErrSpoolFull = errors.New("spool full")
+
+// BatchSpool is a bounded, durable FIFO of batches that could not be written to the graph store
+type BatchSpool interface {
+	// Append adds a batch at the tail and returns once it is on stable storage;
+	// it fails with ErrSpoolFull instead of blocking
+	Append(batch *entity.SpooledBatch) error
+
+	// Peek returns the oldest batch without removing it, or nil if the spool is empty
+	Peek() (*entity.SpooledBatch, error)
+
+	// Commit removes the batch returned by the last Peek
+	Commit() error
+
+	// Len returns the number of spooled batches, including one returned by Peek until it is
+	// committed
+	Len() int
+
+	// Bytes returns the size of the spooled batches
+	Bytes() int64
+
+	// MaxBytes returns the bound of the spool
+	MaxBytes() int64
+
+	// Close releases the spool; spooled batches are kept for the next run
+	Close() error
+}
+
+// StoreHealthChecker reports whether the graph store accepts writes
+type StoreHealthChecker interface {
+	// IsConnected checks that the store can be reached
+	IsConnected(ctx context.Context) bool
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// CheckpointService tracks indexing progress per network and detects block gaps
+type CheckpointService interface {
+	// RecordBatch advances the checkpoints with a committed batch and reports block gaps
+	RecordBatch(ctx context.Context, messages []*entity.TransactionMessage) error
+
+	// Checkpoints returns the current checkpoint of every network
+	Checkpoints(ctx context.Context) ([]*entity.IndexerCheckpoint, error)
+
+	// SettleGaps closes the open gaps of a network once every transaction read from its source
+	// is settled: blocks are published in order, so the blocks still missing had none
+	SettleGaps(ctx context.Context, network string) error
+
+	// AcknowledgeGaps closes the part of the open gaps of a network between fromBlock and
+	// toBlock, for blocks that are known not to arrive
+	AcknowledgeGaps(ctx context.Context, network string, fromBlock, toBlock uint64) error
+
+	// Gaps returns the block gaps detected and not yet filled
+	Gaps(ctx context.Context) ([]*entity.BlockGap, error)
+}
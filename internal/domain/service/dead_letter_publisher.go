@@ -0,0 +1,12 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// DeadLetterPublisher defines the interface for parking permanently failing messages
+type DeadLetterPublisher interface {
+	// PublishDeadLetter publishes a failed message together with its failure details
+	PublishDeadLetter(ctx context.Context, letter *entity.DeadLetter) error
+}
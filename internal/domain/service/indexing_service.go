@@ -10,21 +10,28 @@ type IndexingService interface {
 	// ProcessTransaction processes a transaction event and indexes it
 	ProcessTransaction(ctx context.Context, tx *entity.Transaction) error
 
-	// ProcessTransactionBatch processes multiple transactions in batch
-	ProcessTransactionBatch(ctx context.Context, transactions []*entity.Transaction) error
+	// ProcessTransactionBatch processes multiple transactions in batch; the writes of the
+	// batch commit atomically and the result reports whether they did
+	ProcessTransactionBatch(ctx context.Context, transactions []*entity.Transaction) (*entity.BatchResult, error)
 
-	// GetWalletAnalytics retrieves analytics for a wallet
-	GetWalletAnalytics(ctx context.Context, address string) (*entity.WalletStats, error)
+	// GetWalletAnalytics retrieves analytics for the wallet of an address on a network
+	GetWalletAnalytics(ctx context.Context, network, address string) (*entity.WalletStats, error)
 
-	// GetBubbleAnalysis retrieves bubble analysis data
-	GetBubbleAnalysis(ctx context.Context, minConnections int, limit int) ([]*entity.Wallet, error)
+	// GetBubbleAnalysis retrieves bubble analysis data of a network, optionally for a time window
+	GetBubbleAnalysis(ctx context.Context, network string, minConnections int, window *entity.TimeWindow, limit int) ([]*entity.Wallet, error)
 
-	// GetTransactionPath finds transaction path between wallets
-	GetTransactionPath(ctx context.Context, fromAddress, toAddress string, maxHops int) ([]*entity.TransactionNode, error)
+	// GetTransactionPath finds the k shortest value-flow paths between wallets
+	GetTransactionPath(ctx context.Context, query *entity.PathQuery) ([]*entity.TransactionPath, error)
+
+	// GetEdgeTransactions returns a page of the transactions behind a relationship, newest first
+	GetEdgeTransactions(ctx context.Context, query *entity.EdgeHistoryQuery) (*entity.EdgeHistoryPage, error)
 
 	// GetERC20TransfersForWallet retrieves ERC20 transfers for a wallet
 	GetERC20TransfersForWallet(ctx context.Context, address string, limit int) ([]*entity.ERC20Transfer, error)
 
 	// GetERC20TransfersBetweenWallets retrieves ERC20 transfers between two wallets
 	GetERC20TransfersBetweenWallets(ctx context.Context, fromAddress, toAddress string, limit int) ([]*entity.ERC20Transfer, error)
+
+	// GetTokenBubbleMap retrieves the top holders of a token on a network and the transfers among them
+	GetTokenBubbleMap(ctx context.Context, network, token string, topN int) (*entity.TokenBubbleMap, error)
 }
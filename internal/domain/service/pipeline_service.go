@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// PipelineService batches transactions per network and indexes them with a worker pool per network
+type PipelineService interface {
+	// Run consumes the transaction source until it is exhausted or the context is cancelled,
+	// then waits for every in-flight batch to settle
+	Run(ctx context.Context)
+
+	// Stats returns the throughput and lag of every network
+	Stats(ctx context.Context) []*entity.NetworkStats
+
+	// Spool returns the state of the local spool of batches written while the store is down
+	Spool() *entity.SpoolStats
+}
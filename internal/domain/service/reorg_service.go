@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// ReorgService detects chain reorganizations from block hashes and rolls back orphaned blocks
+type ReorgService interface {
+	// Reconcile compares the block hashes of a batch with the recorded canonical hashes, rolls back
+	// orphaned blocks within the confirmation depth and returns the transactions to index; stale
+	// deliveries of orphaned blocks are dropped
+	Reconcile(ctx context.Context, transactions []*entity.Transaction) ([]*entity.Transaction, error)
+
+	// RecordBlocks records the block hashes of an indexed batch as canonical
+	RecordBlocks(ctx context.Context, transactions []*entity.Transaction) error
+}
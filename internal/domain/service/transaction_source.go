@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// TransactionSource defines the interface for streams of transactions to be indexed
+type TransactionSource interface {
+	// Start begins reading transactions from the source
+	Start(ctx context.Context) error
+
+	// Messages returns the stream of transactions with their delivery handles;
+	// the channel is closed once the source is stopped or exhausted
+	Messages() <-chan *entity.TransactionMessage
+
+	// StopReceiving stops reading new transactions and closes the message stream once the
+	// transactions already read are delivered; their handles can still be settled until Stop
+	StopReceiving() error
+
+	// Stop stops reading and releases the source's resources
+	Stop() error
+
+	// Lag returns the number of transactions waiting to be consumed or settled
+	Lag(ctx context.Context) (uint64, error)
+}
+
+// NetworkPartitionedSource is implemented by sources that deliver every network on its own stream
+type NetworkPartitionedSource interface {
+	// NetworkMessages returns the stream of every network; empty if the source is not partitioned
+	NetworkMessages() map[string]<-chan *entity.TransactionMessage
+
+	// NetworkLag returns the number of transactions waiting per network
+	NetworkLag(ctx context.Context) (map[string]uint64, error)
+}
+
+// SettlementReporter is implemented by sources that can tell whether every transaction they
+// read has been settled
+type SettlementReporter interface {
+	// Settled reports whether every transaction of a network read so far is acknowledged or
+	// dead-lettered and none is waiting to be delivered or redelivered
+	Settled(ctx context.Context, network string) (bool, error)
+}
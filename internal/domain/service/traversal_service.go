@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// TraversalService expands the graph around a wallet within explicit bounds
+type TraversalService interface {
+	// Traverse expands the graph hop by hop from the root of the query, capping the fan-out
+	// per wallet and level and treating hubs according to the hub policy, and returns the
+	// subgraph reached
+	Traverse(ctx context.Context, query *entity.TraversalQuery) (*entity.Subgraph, error)
+}
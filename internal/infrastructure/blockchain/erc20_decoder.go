@@ -57,6 +57,10 @@ var (
 	// Events
 	transferEventSignature = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
 	approvalEventSignature = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+
+	// Method signatures recorded for transfers decoded from event logs
+	transferEventMethodSignature = "EVENT_TRANSFER"
+	approvalEventMethodSignature = "EVENT_APPROVAL"
 )
 
 // DecodeERC20Transfer decodes ERC20 and contract interactions from transaction data
@@ -71,17 +75,20 @@ func (s *ERC20DecoderService) DecodeERC20Transfer(ctx context.Context, tx *entit
 		zap.String("data", tx.Data),
 		zap.Int("data_length", len(tx.Data)))
 
+	// Receipts are authoritative: decode token flows from event logs instead of guessing from calldata
+	if tx.HasReceipt() {
+		return s.decodeFromReceipt(tx), nil
+	}
+
+	// Value moved in the native currency, whether a plain transfer or attached to a call
+	if value, ok := nativeValue(tx); ok {
+		transfers = append(transfers, s.createNativeTransferRecord(tx, value))
+	}
+
 	// Check if transaction has data (contract interaction)
 	if tx.Data == "" || tx.Data == "0x" {
-		s.logger.Debug("No transaction data found, creating ETH transfer record",
+		s.logger.Debug("No transaction data found, native transfer only",
 			zap.String("tx_hash", tx.Hash))
-		// For ETH transfers, still create a record for tracking
-		if tx.Value != "0" {
-			transfer := s.createETHTransferRecord(tx)
-			if transfer != nil {
-				transfers = append(transfers, transfer)
-			}
-		}
 		return transfers, nil
 	}
 
@@ -120,6 +127,150 @@ func (s *ERC20DecoderService) DecodeERC20Transfer(ctx context.Context, tx *entit
 	return transfers, nil
 }
 
+// decodeFromReceipt decodes token flows from the receipt event logs and keeps the calldata
+// interaction record only for non-token interactions (swaps, multicalls, etc.)
+func (s *ERC20DecoderService) decodeFromReceipt(tx *entity.Transaction) []*entity.ERC20Transfer {
+	if tx.Failed() {
+		s.logger.Debug("Transaction reverted, no token flows to decode",
+			zap.String("tx_hash", tx.Hash),
+			zap.String("status", tx.Status))
+		return nil
+	}
+
+	transfers := s.decodeEventLogs(tx)
+	if value, ok := nativeValue(tx); ok {
+		transfers = append(transfers, s.createNativeTransferRecord(tx, value))
+	}
+
+	if tx.Data == "" || tx.Data == "0x" {
+		return transfers
+	}
+
+	if tx.To == "" || tx.To == "0x0000000000000000000000000000000000000000" {
+		return transfers
+	}
+
+	interactionType, decoded := s.decodeContractInteraction(tx)
+	if isTokenInteraction(interactionType) {
+		// Covered by the Transfer/Approval logs
+		return transfers
+	}
+
+	if decoded == nil {
+		decoded = s.createUnknownContractCallRecord(tx)
+	}
+	if decoded != nil {
+		transfers = append(transfers, decoded)
+	}
+
+	s.logger.Debug("Decoded transaction receipt",
+		zap.String("tx_hash", tx.Hash),
+		zap.Int("logs", len(tx.Logs)),
+		zap.Int("transfers", len(transfers)))
+
+	return transfers
+}
+
+// decodeEventLogs emits one transfer record per ERC20 Transfer or Approval log
+func (s *ERC20DecoderService) decodeEventLogs(tx *entity.Transaction) []*entity.ERC20Transfer {
+	var transfers []*entity.ERC20Transfer
+
+	for _, log := range tx.Logs {
+		if log.Removed || len(log.Topics) == 0 {
+			continue
+		}
+
+		var interactionType entity.ContractInteractionType
+		var methodSig string
+		switch strings.ToLower(log.Topics[0]) {
+		case transferEventSignature.Hex():
+			interactionType = entity.InteractionTransfer
+			methodSig = transferEventMethodSignature
+		case approvalEventSignature.Hex():
+			interactionType = entity.InteractionApprove
+			methodSig = approvalEventMethodSignature
+		default:
+			continue
+		}
+
+		transfer, err := decodeTokenEventLog(tx, log, interactionType, methodSig)
+		if err != nil {
+			// ERC721 shares the Transfer/Approval signatures but indexes the token id as a fourth topic
+			s.logger.Debug("Skipping non-ERC20 event log",
+				zap.String("tx_hash", tx.Hash),
+				zap.String("contract", log.Address),
+				zap.Uint64("log_index", log.LogIndex),
+				zap.Error(err))
+			continue
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers
+}
+
+// decodeTokenEventLog decodes an ERC20 Transfer(from, to, value) or Approval(owner, spender, value) log
+func decodeTokenEventLog(tx *entity.Transaction, log entity.Log, interactionType entity.ContractInteractionType, methodSig string) (*entity.ERC20Transfer, error) {
+	if len(log.Topics) != 3 {
+		return nil, fmt.Errorf("expected 3 topics, got %d", len(log.Topics))
+	}
+
+	data := strings.TrimPrefix(strings.TrimPrefix(log.Data, "0x"), "0X")
+	if len(data) != 64 {
+		return nil, fmt.Errorf("expected 32 bytes of data, got %d hex characters", len(data))
+	}
+
+	from, err := topicToAddress(log.Topics[1])
+	if err != nil {
+		return nil, err
+	}
+	to, err := topicToAddress(log.Topics[2])
+	if err != nil {
+		return nil, err
+	}
+
+	value := new(big.Int)
+	if _, ok := value.SetString(data, 16); !ok {
+		return nil, fmt.Errorf("failed to parse value hex: %s", data)
+	}
+
+	return &entity.ERC20Transfer{
+		ContractAddress: strings.ToLower(log.Address),
+		From:            from,
+		To:              to,
+		Value:           value.String(),
+		TxHash:          tx.Hash,
+		BlockNumber:     tx.BlockNumber,
+		Timestamp:       tx.Timestamp,
+		Network:         tx.Network,
+		InteractionType: interactionType,
+		MethodSignature: methodSig,
+		Success:         true,
+		LogIndex:        log.LogIndex,
+	}, nil
+}
+
+// topicToAddress extracts an address from a 32-byte indexed topic
+func topicToAddress(topic string) (string, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(topic, "0x"), "0X")
+	if len(hex) != 64 {
+		return "", fmt.Errorf("invalid address topic length: %d characters", len(hex))
+	}
+	return "0x" + strings.ToLower(hex[24:]), nil
+}
+
+// isTokenInteraction reports whether the calldata interaction is an ERC20 transfer or allowance call
+func isTokenInteraction(interactionType entity.ContractInteractionType) bool {
+	switch interactionType {
+	case entity.InteractionTransfer, entity.InteractionTransferFrom,
+		entity.InteractionApprove, entity.InteractionIncreaseAllowance, entity.InteractionDecreaseAllowance:
+		return true
+	default:
+		return false
+	}
+}
+
 // decodeContractInteraction decodes various types of contract interactions
 func (s *ERC20DecoderService) decodeContractInteraction(tx *entity.Transaction) (entity.ContractInteractionType, *entity.ERC20Transfer) {
 	data := tx.Data
@@ -606,23 +757,36 @@ func (s *ERC20DecoderService) createMulticallRecord(tx *entity.Transaction) *ent
 	}
 }
 
-// createETHTransferRecord creates a record for pure ETH transfers
-func (s *ERC20DecoderService) createETHTransferRecord(tx *entity.Transaction) *entity.ERC20Transfer {
+// createNativeTransferRecord creates a record for the native currency moved by a transaction
+func (s *ERC20DecoderService) createNativeTransferRecord(tx *entity.Transaction, value *big.Int) *entity.ERC20Transfer {
 	return &entity.ERC20Transfer{
-		ContractAddress: "ETH",
+		ContractAddress: entity.NativeAssetAddress,
 		From:            tx.From,
 		To:              tx.To,
-		Value:           tx.Value,
+		Value:           value.String(),
 		TxHash:          tx.Hash,
 		BlockNumber:     tx.BlockNumber,
 		Timestamp:       tx.Timestamp,
 		Network:         tx.Network,
-		InteractionType: entity.InteractionETHTransfer,
-		MethodSignature: "ETH_TRANSFER",
+		InteractionType: entity.InteractionNativeTransfer,
+		MethodSignature: string(entity.InteractionNativeTransfer),
 		Success:         true,
 	}
 }
 
+// nativeValue returns the native currency a transaction moved to an account; reverted
+// transactions, contract creations and malformed values move none
+func nativeValue(tx *entity.Transaction) (*big.Int, bool) {
+	if tx.Failed() || tx.To == "" || tx.To == "0x0000000000000000000000000000000000000000" {
+		return nil, false
+	}
+	value, err := entity.ParseAmount(tx.Value)
+	if err != nil || value.Sign() <= 0 {
+		return nil, false
+	}
+	return value, true
+}
+
 // createUnknownContractCallRecord creates a record for unknown contract calls
 func (s *ERC20DecoderService) createUnknownContractCallRecord(tx *entity.Transaction) *entity.ERC20Transfer {
 	// Extract method signature from transaction data
@@ -9,11 +9,16 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	App     AppConfig     `mapstructure:"app"`
-	NATS    NATSConfig    `mapstructure:"nats"`
-	Neo4J   Neo4JConfig   `mapstructure:"neo4j"`
-	Health  HealthConfig  `mapstructure:"health"`
-	Metrics MetricsConfig `mapstructure:"metrics"`
+	App    AppConfig    `mapstructure:"app"`
+	NATS   NATSConfig   `mapstructure:"nats"`
+	Source SourceConfig `mapstructure:"source"`
+
+	// Pipelines holds per-network overrides of the batch size and worker pool size
+	Pipelines map[string]PipelineConfig `mapstructure:"pipelines"`
+	Neo4J     Neo4JConfig               `mapstructure:"neo4j"`
+	Spool     SpoolConfig               `mapstructure:"spool"`
+	Health    HealthConfig              `mapstructure:"health"`
+	Metrics   MetricsConfig             `mapstructure:"metrics"`
 }
 
 // AppConfig represents application-specific configuration
@@ -23,6 +28,20 @@ type AppConfig struct {
 	HTTPPort       int    `mapstructure:"http_port"`
 	WorkerPoolSize int    `mapstructure:"worker_pool_size"`
 	BatchSize      int    `mapstructure:"batch_size"`
+
+	// ConfirmationDepth is the number of blocks after which a block is final; reorgs within
+	// the depth are rolled back, deeper ones are rejected. 0 disables the limit.
+	ConfirmationDepth uint64 `mapstructure:"confirmation_depth"`
+
+	// Batching policy: a partial batch is flushed every FlushInterval, a batch is flushed once
+	// its payloads reach MaxBatchBytes, and at most MaxInFlightBatches batches are written at
+	// once across all networks. 0 disables the byte and in-flight limits.
+	FlushInterval      time.Duration `mapstructure:"flush_interval"`
+	MaxBatchBytes      int           `mapstructure:"max_batch_bytes"`
+	MaxInFlightBatches int           `mapstructure:"max_in_flight_batches"`
+
+	// ShutdownTimeout bounds draining the pending batches and closing connections on shutdown
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 }
 
 // NATSConfig represents NATS configuration
@@ -36,6 +55,76 @@ type NATSConfig struct {
 	ReconnectDelay     time.Duration `mapstructure:"reconnect_delay"`
 	MaxPendingMessages int           `mapstructure:"max_pending_messages"`
 	Enabled            bool          `mapstructure:"enabled"`
+
+	// Networks consumed from per-network subjects (<subject_prefix>.<network>.events);
+	// empty reads the single filter subject
+	Networks []string `mapstructure:"networks"`
+
+	// JetStream durable consumer settings
+	UseJetStream  bool          `mapstructure:"use_jetstream"`
+	ConsumerName  string        `mapstructure:"consumer_name"`
+	FilterSubject string        `mapstructure:"filter_subject"`
+	AckWait       time.Duration `mapstructure:"ack_wait"`
+	MaxDeliver    int           `mapstructure:"max_deliver"`
+	MaxAckPending int           `mapstructure:"max_ack_pending"`
+	DeliverPolicy string        `mapstructure:"deliver_policy"` // all, last, new, by_start_sequence, by_start_time
+	StartSequence uint64        `mapstructure:"start_sequence"`
+	StartTime     string        `mapstructure:"start_time"` // RFC3339, used with by_start_time
+	NakDelay      time.Duration `mapstructure:"nak_delay"`  // redelivery delay after a failed batch
+
+	// Flow control: fetches are sized to the free room in the processing channel
+	FetchBatchSize int           `mapstructure:"fetch_batch_size"` // upper bound of messages per JetStream fetch
+	FetchMaxWait   time.Duration `mapstructure:"fetch_max_wait"`
+
+	// Core NATS spill buffer; core NATS refuses to start without a directory
+	SpillDir      string `mapstructure:"spill_dir"`
+	SpillMaxBytes int64  `mapstructure:"spill_max_bytes"`
+
+	// Dead-letter settings; an empty subject disables dead-lettering
+	DeadLetterSubject string `mapstructure:"dead_letter_subject"`
+	DeadLetterStream  string `mapstructure:"dead_letter_stream"` // JetStream stream capturing the dead-letter subject
+}
+
+// SourceConfig selects where transactions are read from
+type SourceConfig struct {
+	Type string `mapstructure:"type"` // nats, file or stdin
+	Path string `mapstructure:"path"` // file or directory of JSON-lines (optionally gzip) files
+}
+
+// PipelineConfig represents the processing settings of one network; zero values fall back
+// to the application defaults
+type PipelineConfig struct {
+	BatchSize      int `mapstructure:"batch_size"`
+	WorkerPoolSize int `mapstructure:"worker_pool_size"`
+}
+
+// NetworkList returns the configured networks, normalized and without duplicates
+func (c *NATSConfig) NetworkList() []string {
+	seen := make(map[string]bool)
+	var networks []string
+	for _, entry := range c.Networks {
+		for _, network := range strings.Split(entry, ",") {
+			network = strings.ToLower(strings.TrimSpace(network))
+			if network == "" || seen[network] {
+				continue
+			}
+			seen[network] = true
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// PipelineFor returns the processing settings of a network
+func (c *Config) PipelineFor(network string) PipelineConfig {
+	pipeline := c.Pipelines[strings.ToLower(network)]
+	if pipeline.BatchSize <= 0 {
+		pipeline.BatchSize = c.App.BatchSize
+	}
+	if pipeline.WorkerPoolSize <= 0 {
+		pipeline.WorkerPoolSize = c.App.WorkerPoolSize
+	}
+	return pipeline
 }
 
 // Neo4JConfig represents Neo4J configuration
@@ -47,6 +136,26 @@ type Neo4JConfig struct {
 	ConnectTimeout               time.Duration `mapstructure:"connect_timeout"`
 	MaxConnectionPoolSize        int           `mapstructure:"max_connection_pool_size"`
 	ConnectionAcquisitionTimeout time.Duration `mapstructure:"connection_acquisition_timeout"`
+
+	// LinkSameAddress links wallets sharing an address across networks with SAME_ADDRESS_AS
+	LinkSameAddress bool `mapstructure:"link_same_address"`
+
+	// RollupBucket is the size of the time buckets wallet-to-wallet flows are also aggregated
+	// in (ROLLUP relationships); zero disables rollups
+	RollupBucket time.Duration `mapstructure:"rollup_bucket"`
+
+	// Writes failing with transient errors (deadlocks, lock timeouts, lost connections) are
+	// retried with jittered exponential backoff; other errors fail immediately
+	RetryMaxAttempts    int           `mapstructure:"retry_max_attempts"`
+	RetryInitialBackoff time.Duration `mapstructure:"retry_initial_backoff"`
+	RetryMaxBackoff     time.Duration `mapstructure:"retry_max_backoff"`
+}
+
+// SpoolConfig represents the local spool of batches written while Neo4J is unavailable
+type SpoolConfig struct {
+	Dir           string        `mapstructure:"dir"`            // an empty directory disables spooling
+	MaxBytes      int64         `mapstructure:"max_bytes"`      // bound of the spooled batches
+	ProbeInterval time.Duration `mapstructure:"probe_interval"` // how often Neo4J is probed before replaying
 }
 
 // HealthConfig represents health check configuration
@@ -91,9 +200,30 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	loadPipelines(&config)
+
 	return &config, nil
 }
 
+// loadPipelines reads the per-network overrides of the configured networks, so that they can
+// also be set from the environment (PIPELINES_<NETWORK>_BATCH_SIZE, PIPELINES_<NETWORK>_WORKER_POOL_SIZE)
+func loadPipelines(config *Config) {
+	if config.Pipelines == nil {
+		config.Pipelines = make(map[string]PipelineConfig)
+	}
+
+	for _, network := range config.NATS.NetworkList() {
+		pipeline := config.Pipelines[network]
+		if size := viper.GetInt("pipelines." + network + ".batch_size"); size > 0 {
+			pipeline.BatchSize = size
+		}
+		if size := viper.GetInt("pipelines." + network + ".worker_pool_size"); size > 0 {
+			pipeline.WorkerPoolSize = size
+		}
+		config.Pipelines[network] = pipeline
+	}
+}
+
 // setDefaults sets default configuration values
 func setDefaults() {
 	// App defaults
@@ -102,6 +232,11 @@ func setDefaults() {
 	viper.SetDefault("app.http_port", 8080)
 	viper.SetDefault("app.worker_pool_size", 10)
 	viper.SetDefault("app.batch_size", 100)
+	viper.SetDefault("app.confirmation_depth", 12)
+	viper.SetDefault("app.flush_interval", "5s")
+	viper.SetDefault("app.max_batch_bytes", 0)
+	viper.SetDefault("app.max_in_flight_batches", 0)
+	viper.SetDefault("app.shutdown_timeout", "30s")
 
 	// NATS defaults
 	viper.SetDefault("nats.url", "nats://ethereum-nats:4222")
@@ -113,6 +248,27 @@ func setDefaults() {
 	viper.SetDefault("nats.reconnect_delay", "2s")
 	viper.SetDefault("nats.max_pending_messages", 10000)
 	viper.SetDefault("nats.enabled", true)
+	viper.SetDefault("nats.networks", []string{})
+	viper.SetDefault("nats.use_jetstream", true)
+	viper.SetDefault("nats.consumer_name", "bubble-map-indexer")
+	viper.SetDefault("nats.filter_subject", "")
+	viper.SetDefault("nats.ack_wait", "30s")
+	viper.SetDefault("nats.max_deliver", 5)
+	viper.SetDefault("nats.max_ack_pending", 1000)
+	viper.SetDefault("nats.deliver_policy", "all")
+	viper.SetDefault("nats.start_sequence", 0)
+	viper.SetDefault("nats.start_time", "")
+	viper.SetDefault("nats.nak_delay", "5s")
+	viper.SetDefault("nats.fetch_batch_size", 100)
+	viper.SetDefault("nats.fetch_max_wait", "5s")
+	viper.SetDefault("nats.spill_dir", "data/nats-spill")
+	viper.SetDefault("nats.spill_max_bytes", 1<<30)
+	viper.SetDefault("nats.dead_letter_subject", "dlq.transactions")
+	viper.SetDefault("nats.dead_letter_stream", "TRANSACTIONS_DLQ")
+
+	// Source defaults
+	viper.SetDefault("source.type", "nats")
+	viper.SetDefault("source.path", "")
 
 	// Neo4J defaults
 	viper.SetDefault("neo4j.uri", "neo4j://localhost:7687")
@@ -122,6 +278,16 @@ func setDefaults() {
 	viper.SetDefault("neo4j.connect_timeout", "10s")
 	viper.SetDefault("neo4j.max_connection_pool_size", 50)
 	viper.SetDefault("neo4j.connection_acquisition_timeout", "60s")
+	viper.SetDefault("neo4j.link_same_address", false)
+	viper.SetDefault("neo4j.rollup_bucket", "24h")
+	viper.SetDefault("neo4j.retry_max_attempts", 5)
+	viper.SetDefault("neo4j.retry_initial_backoff", "100ms")
+	viper.SetDefault("neo4j.retry_max_backoff", "5s")
+
+	// Spool defaults
+	viper.SetDefault("spool.dir", "")
+	viper.SetDefault("spool.max_bytes", 1<<30)
+	viper.SetDefault("spool.probe_interval", "5s")
 
 	// Health defaults
 	viper.SetDefault("health.interval", "30s")
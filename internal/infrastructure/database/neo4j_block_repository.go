@@ -0,0 +1,652 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// Neo4JBlockRepository implements BlockRepository using Block nodes keyed by network and number
+type Neo4JBlockRepository struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JBlockRepository creates a new Neo4J block repository
+func NewNeo4JBlockRepository(client *Neo4JClient, logger *logger.Logger) repository.BlockRepository {
+	return &Neo4JBlockRepository{
+		client: client,
+		logger: logger.WithComponent("neo4j-block-repo"),
+	}
+}
+
+// GetBlockHashes returns the recorded hash per block number
+func (r *Neo4JBlockRepository) GetBlockHashes(ctx context.Context, network string, numbers []uint64) (map[uint64]string, error) {
+	hashes := make(map[uint64]string)
+	if len(numbers) == 0 {
+		return hashes, nil
+	}
+
+	query := `
+		UNWIND $numbers as number
+		MATCH (b:Block {network: $network, number: number})
+		RETURN b.number, b.hash
+	`
+
+	params := map[string]interface{}{
+		"network": network,
+		"numbers": toInt64s(numbers),
+	}
+
+	_, err := r.client.executeRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			values := records.Record().Values
+			hashes[uint64(values[0].(int64))] = values[1].(string)
+		}
+		return nil, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block hashes: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// FindOrphanedBlocks returns the block numbers whose hash was rolled back as orphaned
+func (r *Neo4JBlockRepository) FindOrphanedBlocks(ctx context.Context, network string, hashes map[uint64]string) (map[uint64]bool, error) {
+	orphaned := make(map[uint64]bool)
+	if len(hashes) == 0 {
+		return orphaned, nil
+	}
+
+	query := `
+		UNWIND $blocks as block
+		MATCH (o:OrphanedBlock {network: $network, number: block.number, hash: block.hash})
+		RETURN o.number
+	`
+
+	blocks := make([]map[string]interface{}, 0, len(hashes))
+	for number, hash := range hashes {
+		blocks = append(blocks, map[string]interface{}{
+			"number": int64(number),
+			"hash":   hash,
+		})
+	}
+
+	_, err := r.client.executeRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]interface{}{"network": network, "blocks": blocks})
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			orphaned[uint64(records.Record().Values[0].(int64))] = true
+		}
+		return nil, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphaned blocks: %w", err)
+	}
+
+	return orphaned, nil
+}
+
+// GetHighestBlock returns the highest recorded block number, false if none is recorded
+func (r *Neo4JBlockRepository) GetHighestBlock(ctx context.Context, network string) (uint64, bool, error) {
+	query := `
+		MATCH (b:Block {network: $network})
+		RETURN max(b.number)
+	`
+
+	result, err := r.client.executeRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		record, err := tx.Run(ctx, query, map[string]interface{}{"network": network})
+		if err != nil {
+			return nil, err
+		}
+		single, err := record.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return single.Values[0], nil
+	})
+
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get highest block: %w", err)
+	}
+
+	highest, ok := result.(int64)
+	if !ok {
+		return 0, false, nil
+	}
+
+	return uint64(highest), true, nil
+}
+
+// SaveBlocks records the canonical hash of each block
+func (r *Neo4JBlockRepository) SaveBlocks(ctx context.Context, blocks []*entity.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	query := `
+		UNWIND $blocks as block
+		MERGE (b:Block {network: block.network, number: block.number})
+		SET b.hash = block.hash,
+			b.indexed_at = datetime(block.indexed_at)
+	`
+
+	var blockData []map[string]interface{}
+	for _, block := range blocks {
+		indexedAt := block.IndexedAt
+		if indexedAt.IsZero() {
+			indexedAt = time.Now().UTC()
+		}
+		blockData = append(blockData, map[string]interface{}{
+			"network":    block.Network,
+			"number":     int64(block.Number),
+			"hash":       block.Hash,
+			"indexed_at": indexedAt.Format("2006-01-02T15:04:05.000Z"),
+		})
+	}
+
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return tx.Run(ctx, query, map[string]interface{}{"blocks": blockData})
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save blocks: %w", err)
+	}
+
+	return nil
+}
+
+// RollbackFrom undoes the graph contributions of every transaction indexed at or above the block
+// number, removes their processed markers, records those blocks as orphaned and moves the network
+// checkpoint below them, all in a single transaction
+func (r *Neo4JBlockRepository) RollbackFrom(ctx context.Context, network string, fromNumber uint64) ([]*entity.IndexedTransaction, error) {
+	params := map[string]interface{}{
+		"network": network,
+		"from":    int64(fromNumber),
+	}
+
+	result, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		orphaned, err := r.loadJournal(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := r.undoEffects(ctx, tx, orphaned); err != nil {
+			return nil, err
+		}
+
+		if err := r.undoWalletCounters(ctx, tx, entity.NormalizeNetwork(network), orphaned); err != nil {
+			return nil, err
+		}
+
+		cleanup := `
+			MATCH (p:ProcessedTransaction {network: $network})
+			WHERE p.block_number >= $from
+			DELETE p
+			WITH count(*) as ignored
+			MATCH (b:Block {network: $network})
+			WHERE b.number >= $from
+			MERGE (o:OrphanedBlock {network: b.network, number: b.number, hash: b.hash})
+			SET o.orphaned_at = datetime()
+			DELETE b
+			WITH count(*) as ignored
+			MATCH (g:BlockGap {network: $network})
+			WHERE g.from_block >= $from
+			DELETE g
+			WITH count(*) as ignored
+			MATCH (g:BlockGap {network: $network})
+			WHERE g.to_block >= $from
+			SET g.to_block = $from - 1
+			WITH count(*) as ignored
+			MATCH (c:IndexerCheckpoint {network: $network})
+			WHERE coalesce(c.highest_block, c.last_block) >= $from
+			SET c.last_block = CASE WHEN c.last_block >= $from THEN $from - 1 ELSE c.last_block END,
+				c.highest_block = $from - 1,
+				c.block_hash = null,
+				c.version = coalesce(c.version, 0) + 1
+		`
+		if _, err := tx.Run(ctx, cleanup, params); err != nil {
+			return nil, fmt.Errorf("failed to delete orphaned markers: %w", err)
+		}
+
+		return orphaned, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back blocks from %d: %w", fromNumber, err)
+	}
+
+	orphaned := result.([]*entity.IndexedTransaction)
+	r.logger.Info("Rolled back orphaned blocks",
+		zap.String("network", network),
+		zap.Uint64("from_block", fromNumber),
+		zap.Int("transactions", len(orphaned)))
+
+	return orphaned, nil
+}
+
+// loadJournal reads the processed markers and rollback journal of the orphaned blocks
+func (r *Neo4JBlockRepository) loadJournal(ctx context.Context, tx neo4j.ManagedTransaction, params map[string]interface{}) ([]*entity.IndexedTransaction, error) {
+	query := `
+		MATCH (p:ProcessedTransaction {network: $network})
+		WHERE p.block_number >= $from
+		RETURN p.hash, p.block_number, p.block_hash, p.from_address, p.to_address, p.value, p.effects_json
+	`
+
+	records, err := tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rollback journal: %w", err)
+	}
+
+	var orphaned []*entity.IndexedTransaction
+	for records.Next(ctx) {
+		values := records.Record().Values
+		indexed := &entity.IndexedTransaction{
+			Network:     params["network"].(string),
+			Hash:        stringValue(values[0]),
+			BlockNumber: uint64(values[1].(int64)),
+			BlockHash:   stringValue(values[2]),
+			FromAddress: stringValue(values[3]),
+			ToAddress:   stringValue(values[4]),
+			Value:       stringValue(values[5]),
+		}
+
+		effectsJSON := stringValue(values[6])
+		if effectsJSON == "" {
+			return nil, fmt.Errorf("transaction %s in block %d has no rollback journal", indexed.Hash, indexed.BlockNumber)
+		}
+		if err := json.Unmarshal([]byte(effectsJSON), &indexed.Effects); err != nil {
+			return nil, fmt.Errorf("failed to decode rollback journal of %s: %w", indexed.Hash, err)
+		}
+
+		orphaned = append(orphaned, indexed)
+	}
+
+	return orphaned, records.Err()
+}
+
+// undoEffects subtracts each journaled contribution from its relationship and its rollup,
+// reverts the token balances it moved, deletes emptied relationships and the TxEvent nodes
+// of the orphaned transactions
+func (r *Neo4JBlockRepository) undoEffects(ctx context.Context, tx neo4j.ManagedTransaction, orphaned []*entity.IndexedTransaction) error {
+	var effects []map[string]interface{}
+	for _, indexed := range orphaned {
+		for _, effect := range indexed.Effects {
+			edge := effect.Edge(indexed.Network)
+			effects = append(effects, map[string]interface{}{
+				"tx_hash":          indexed.Hash,
+				"network":          entity.NormalizeNetwork(indexed.Network),
+				"rel_type":         edge.RelType,
+				"edge_key":         edge.Key(),
+				"from_address":     effect.FromAddress,
+				"target_address":   effect.TargetAddress,
+				"contract_address": effect.ContractAddress,
+				"spender":          effect.Spender,
+				"value":            effect.Value,
+			})
+		}
+	}
+	if len(effects) == 0 {
+		return nil
+	}
+
+	// Lock the affected relationships and read their totals so the subtraction is exact
+	read := `
+		UNWIND range(0, size($effects) - 1) as i
+		WITH i, $effects[i] as e
+		MATCH (from:Wallet {network: e.network, address: e.from_address})-[r]->(target {network: e.network, address: e.target_address})
+		WHERE type(r) = e.rel_type
+			AND (e.contract_address = "" OR r.contract_address = e.contract_address)
+			AND (e.spender = "" OR r.spender = e.spender)
+		SET r._lock = true
+		RETURN i, elementId(r), r.total_value
+	`
+
+	records, err := tx.Run(ctx, read, map[string]interface{}{"effects": effects})
+	if err != nil {
+		return fmt.Errorf("failed to read relationship contributions: %w", err)
+	}
+	rows, err := records.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read relationship contributions: %w", err)
+	}
+
+	type edgeUndo struct {
+		total   *big.Int
+		edgeKey string
+		count   int64
+		hashes  []string
+		latest  bool
+		rollup  bool
+	}
+	var ids []string
+	undos := make(map[string]*edgeUndo)
+	holdings := newHoldingDeltas()
+	for _, row := range rows {
+		effect := effects[row.Values[0].(int64)]
+		id := stringValue(row.Values[1])
+
+		u, ok := undos[id]
+		if !ok {
+			total, err := entity.ParseTotal(stringValue(row.Values[2]))
+			if err != nil {
+				return fmt.Errorf("failed to parse relationship total: %w", err)
+			}
+			u = &edgeUndo{
+				total:   total,
+				edgeKey: effect["edge_key"].(string),
+				latest:  effect["rel_type"] == "ERC20_APPROVAL",
+				rollup:  entity.HasRollups(effect["rel_type"].(string)),
+			}
+			undos[id] = u
+			ids = append(ids, id)
+		}
+
+		value, err := entity.ParseAmount(effect["value"].(string))
+		if err != nil {
+			return fmt.Errorf("failed to parse journaled value: %w", err)
+		}
+		u.total.Sub(u.total, value)
+		u.count++
+		u.hashes = append(u.hashes, effect["tx_hash"].(string))
+
+		if effect["rel_type"] == "ERC20_TRANSFER" {
+			// Move the tokens back to the sender
+			holdings.addTransfer(effect["network"].(string), effect["target_address"].(string),
+				effect["from_address"].(string), effect["contract_address"].(string), value)
+		}
+	}
+
+	var approvals []map[string]interface{}
+	for _, id := range ids {
+		if u := undos[id]; u.latest {
+			approvals = append(approvals, map[string]interface{}{
+				"id":        id,
+				"edge_key":  u.edgeKey,
+				"tx_hashes": u.hashes,
+			})
+		}
+	}
+	allowances, err := remainingAllowances(ctx, tx, approvals)
+	if err != nil {
+		return err
+	}
+
+	updates := make([]map[string]interface{}, 0, len(ids))
+	var events, rolledUp []map[string]interface{}
+	for _, id := range ids {
+		u := undos[id]
+
+		total := u.total
+		if u.latest {
+			// An approval's total is the allowance of its latest remaining approval
+			if total, err = entity.ParseAmount(allowances[id]); err != nil {
+				return fmt.Errorf("failed to parse allowance: %w", err)
+			}
+		}
+		if total.Sign() < 0 {
+			total.SetInt64(0)
+		}
+
+		updates = append(updates, map[string]interface{}{
+			"id":          id,
+			"total_value": total.String(),
+			"tx_count":    u.count,
+		})
+		edgeEvents := map[string]interface{}{
+			"edge_key":  u.edgeKey,
+			"tx_hashes": u.hashes,
+		}
+		events = append(events, edgeEvents)
+		if u.rollup {
+			rolledUp = append(rolledUp, edgeEvents)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	write := `
+		UNWIND $updates as u
+		MATCH ()-[r]->()
+		WHERE elementId(r) = u.id
+		SET r.total_value = u.total_value,
+			r.tx_count = r.tx_count - u.tx_count
+		REMOVE r._lock
+		WITH r
+		WHERE r.tx_count <= 0
+		DELETE r
+	`
+
+	if _, err := tx.Run(ctx, write, map[string]interface{}{"updates": updates}); err != nil {
+		return fmt.Errorf("failed to undo relationship contributions: %w", err)
+	}
+
+	if err := undoEdgeRollups(ctx, tx, rolledUp, r.client.config.RollupBucket); err != nil {
+		return err
+	}
+
+	if err := applyHoldingDeltas(ctx, tx, holdings); err != nil {
+		return err
+	}
+
+	deleteEvents := `
+		UNWIND $events as e
+		MATCH (t:TxEvent {edge_key: e.edge_key})
+		WHERE t.hash IN e.tx_hashes
+		DELETE t
+	`
+
+	if _, err := tx.Run(ctx, deleteEvents, map[string]interface{}{"events": events}); err != nil {
+		return fmt.Errorf("failed to delete orphaned relationship transactions: %w", err)
+	}
+
+	return nil
+}
+
+// remainingAllowances returns, by relationship id, the value of the latest TxEvent of each
+// approval that does not belong to one of its orphaned transactions
+func remainingAllowances(ctx context.Context, tx neo4j.ManagedTransaction, approvals []map[string]interface{}) (map[string]string, error) {
+	if len(approvals) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UNWIND $approvals as a
+		CALL {
+			WITH a
+			MATCH (t:TxEvent {edge_key: a.edge_key})
+			WHERE NOT t.hash IN a.tx_hashes
+			RETURN t.value as value
+			ORDER BY t.timestamp DESC
+			LIMIT 1
+		}
+		RETURN a.id, value
+	`
+
+	records, err := tx.Run(ctx, query, map[string]interface{}{"approvals": approvals})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remaining approvals: %w", err)
+	}
+	rows, err := records.Collect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remaining approvals: %w", err)
+	}
+
+	allowances := make(map[string]string, len(rows))
+	for _, row := range rows {
+		allowances[stringValue(row.Values[0])] = stringValue(row.Values[1])
+	}
+	return allowances, nil
+}
+
+// undoWalletCounters subtracts the orphaned transactions from the sender and receiver wallet counters
+func (r *Neo4JBlockRepository) undoWalletCounters(ctx context.Context, tx neo4j.ManagedTransaction, network string, orphaned []*entity.IndexedTransaction) error {
+	type walletDelta struct {
+		transactions int64
+		sent         *big.Int
+		received     *big.Int
+	}
+
+	deltas := make(map[string]*walletDelta)
+	delta := func(address string) *walletDelta {
+		d, ok := deltas[address]
+		if !ok {
+			d = &walletDelta{sent: new(big.Int), received: new(big.Int)}
+			deltas[address] = d
+		}
+		return d
+	}
+
+	for _, indexed := range orphaned {
+		value, err := entity.ParseAmount(indexed.Value)
+		if err != nil {
+			return fmt.Errorf("failed to parse journaled value: %w", err)
+		}
+
+		sender := delta(indexed.FromAddress)
+		sender.transactions++
+		sender.sent.Add(sender.sent, value)
+
+		receiver := delta(indexed.ToAddress)
+		receiver.transactions++
+		receiver.received.Add(receiver.received, value)
+	}
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	addresses := make([]string, 0, len(deltas))
+	for address := range deltas {
+		addresses = append(addresses, address)
+	}
+
+	read := `
+		UNWIND $addresses as address
+		MATCH (w:Wallet {network: $network, address: address})
+		RETURN w.address, w.total_transactions, w.total_sent, w.total_received
+	`
+	records, err := tx.Run(ctx, read, map[string]interface{}{"network": network, "addresses": addresses})
+	if err != nil {
+		return fmt.Errorf("failed to read wallet counters: %w", err)
+	}
+
+	var updates []map[string]interface{}
+	for records.Next(ctx) {
+		values := records.Record().Values
+		address := stringValue(values[0])
+		d := deltas[address]
+
+		total, _ := values[1].(int64)
+		total -= d.transactions
+		if total < 0 {
+			total = 0
+		}
+
+		sent, err := entity.SubtractAmount(stringValue(values[2]), d.sent)
+		if err != nil {
+			return fmt.Errorf("failed to parse wallet total sent: %w", err)
+		}
+		received, err := entity.SubtractAmount(stringValue(values[3]), d.received)
+		if err != nil {
+			return fmt.Errorf("failed to parse wallet total received: %w", err)
+		}
+
+		updates = append(updates, map[string]interface{}{
+			"address":            address,
+			"total_transactions": total,
+			"total_sent":         sent,
+			"total_received":     received,
+		})
+	}
+	if err := records.Err(); err != nil {
+		return fmt.Errorf("failed to read wallet counters: %w", err)
+	}
+
+	write := `
+		UNWIND $updates as u
+		MATCH (w:Wallet {network: $network, address: u.address})
+		SET w.total_transactions = u.total_transactions,
+			w.total_sent = u.total_sent,
+			w.total_received = u.total_received
+	`
+	if _, err := tx.Run(ctx, write, map[string]interface{}{"network": network, "updates": updates}); err != nil {
+		return fmt.Errorf("failed to update wallet counters: %w", err)
+	}
+
+	return nil
+}
+
+// PruneJournal drops the rollback journal of transactions and the orphaned hashes below the block
+// number. Pruning only
+// touches committed, final blocks, so it runs in its own transaction even inside a unit of work
+// and a failure cannot fail the batch that triggered it.
+func (r *Neo4JBlockRepository) PruneJournal(ctx context.Context, network string, belowNumber uint64) error {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (p:ProcessedTransaction {network: $network})
+		WHERE p.block_number < $below AND p.effects_json IS NOT NULL
+		WITH p LIMIT 10000
+		REMOVE p.effects_json
+	`
+
+	params := map[string]interface{}{
+		"network": network,
+		"below":   int64(belowNumber),
+	}
+
+	orphans := `
+		MATCH (o:OrphanedBlock {network: $network})
+		WHERE o.number < $below
+		WITH o LIMIT 10000
+		DELETE o
+	`
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return nil, err
+		}
+		return tx.Run(ctx, orphans, params)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to prune rollback journal: %w", err)
+	}
+
+	return nil
+}
+
+// toInt64s converts block numbers to the integer type stored by Neo4J
+func toInt64s(numbers []uint64) []int64 {
+	converted := make([]int64, len(numbers))
+	for i, number := range numbers {
+		converted[i] = int64(number)
+	}
+	return converted
+}
+
+// stringValue returns a string record value, or "" for null
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
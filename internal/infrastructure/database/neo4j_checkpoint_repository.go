@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4JCheckpointRepository implements CheckpointRepository using one IndexerCheckpoint node per network
+type Neo4JCheckpointRepository struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JCheckpointRepository creates a new Neo4J checkpoint repository
+func NewNeo4JCheckpointRepository(client *Neo4JClient, logger *logger.Logger) repository.CheckpointRepository {
+	return &Neo4JCheckpointRepository{
+		client: client,
+		logger: logger.WithComponent("neo4j-checkpoint-repo"),
+	}
+}
+
+// GetCheckpoint returns the checkpoint of a network, nil if none is recorded
+func (r *Neo4JCheckpointRepository) GetCheckpoint(ctx context.Context, network string) (*entity.IndexerCheckpoint, error) {
+	checkpoints, err := r.queryCheckpoints(ctx, `
+		MATCH (c:IndexerCheckpoint {network: $network})
+		RETURN c.network, c.last_block, coalesce(c.highest_block, c.last_block), c.block_hash,
+			c.stream_sequence, c.updated_at, coalesce(c.version, 0)
+	`, map[string]interface{}{"network": network})
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, nil
+	}
+	return checkpoints[0], nil
+}
+
+// GetCheckpoints returns the checkpoints of all networks
+func (r *Neo4JCheckpointRepository) GetCheckpoints(ctx context.Context) ([]*entity.IndexerCheckpoint, error) {
+	return r.queryCheckpoints(ctx, `
+		MATCH (c:IndexerCheckpoint)
+		RETURN c.network, c.last_block, coalesce(c.highest_block, c.last_block), c.block_hash,
+			c.stream_sequence, c.updated_at, coalesce(c.version, 0)
+		ORDER BY c.network
+	`, nil)
+}
+
+// queryCheckpoints runs a checkpoint read query
+func (r *Neo4JCheckpointRepository) queryCheckpoints(ctx context.Context, query string, params map[string]interface{}) ([]*entity.IndexerCheckpoint, error) {
+	result, err := r.client.executeRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var checkpoints []*entity.IndexerCheckpoint
+		for records.Next(ctx) {
+			values := records.Record().Values
+			checkpoint := &entity.IndexerCheckpoint{
+				Network:   stringValue(values[0]),
+				BlockHash: stringValue(values[3]),
+			}
+			if lastBlock, ok := values[1].(int64); ok {
+				checkpoint.LastBlock = uint64(lastBlock)
+			}
+			if highestBlock, ok := values[2].(int64); ok {
+				checkpoint.HighestBlock = uint64(highestBlock)
+			}
+			if sequence, ok := values[4].(int64); ok {
+				checkpoint.StreamSequence = uint64(sequence)
+			}
+			if updatedAt, ok := values[5].(time.Time); ok {
+				checkpoint.UpdatedAt = updatedAt
+			}
+			checkpoint.Version, _ = values[6].(int64)
+			checkpoints = append(checkpoints, checkpoint)
+		}
+		return checkpoints, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoints: %w", err)
+	}
+
+	return result.([]*entity.IndexerCheckpoint), nil
+}
+
+// GetGaps returns the open block gaps of a network, or of all networks if network is ""
+func (r *Neo4JCheckpointRepository) GetGaps(ctx context.Context, network string) ([]*entity.BlockGap, error) {
+	query := `
+		MATCH (g:BlockGap)
+		WHERE $network = "" OR g.network = $network
+		RETURN g.network, g.from_block, g.to_block, g.detected_at
+		ORDER BY g.network, g.from_block
+	`
+
+	result, err := r.client.executeRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]interface{}{"network": network})
+		if err != nil {
+			return nil, err
+		}
+
+		var gaps []*entity.BlockGap
+		for records.Next(ctx) {
+			values := records.Record().Values
+			gap := &entity.BlockGap{Network: stringValue(values[0])}
+			if from, ok := values[1].(int64); ok {
+				gap.FromBlock = uint64(from)
+			}
+			if to, ok := values[2].(int64); ok {
+				gap.ToBlock = uint64(to)
+			}
+			if detectedAt, ok := values[3].(time.Time); ok {
+				gap.DetectedAt = detectedAt
+			}
+			gaps = append(gaps, gap)
+		}
+		return gaps, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block gaps: %w", err)
+	}
+
+	return result.([]*entity.BlockGap), nil
+}
+
+// SaveCheckpoint replaces the checkpoint and open gaps of a network if the checkpoint still has
+// the version it was read with. The checkpoint node is write-locked before its version is
+// compared, so concurrent writers are serialized and all but the first see a newer version.
+func (r *Neo4JCheckpointRepository) SaveCheckpoint(ctx context.Context, checkpoint *entity.IndexerCheckpoint, gaps []*entity.BlockGap) (bool, error) {
+	update := `
+		MERGE (c:IndexerCheckpoint {network: $network})
+		ON CREATE SET c.version = 0
+		SET c._lock = true
+		REMOVE c._lock
+		WITH c
+		WHERE coalesce(c.version, 0) = $version
+		SET c.last_block = $last_block,
+			c.highest_block = $highest_block,
+			c.block_hash = $block_hash,
+			c.stream_sequence = $stream_sequence,
+			c.updated_at = datetime($updated_at),
+			c.version = $version + 1
+		RETURN c.version
+	`
+
+	replaceGaps := `
+		OPTIONAL MATCH (old:BlockGap {network: $network})
+		DELETE old
+		WITH count(*) as ignored
+		UNWIND $gaps as gap
+		CREATE (g:BlockGap {network: $network})
+		SET g.from_block = gap.from_block,
+			g.to_block = gap.to_block,
+			g.detected_at = datetime(gap.detected_at)
+	`
+
+	gapData := make([]map[string]interface{}, 0, len(gaps))
+	for _, gap := range gaps {
+		gapData = append(gapData, map[string]interface{}{
+			"from_block":  int64(gap.FromBlock),
+			"to_block":    int64(gap.ToBlock),
+			"detected_at": gap.DetectedAt.Format("2006-01-02T15:04:05.000Z"),
+		})
+	}
+
+	params := map[string]interface{}{
+		"network":         checkpoint.Network,
+		"last_block":      int64(checkpoint.LastBlock),
+		"highest_block":   int64(checkpoint.HighestBlock),
+		"block_hash":      checkpoint.BlockHash,
+		"stream_sequence": int64(checkpoint.StreamSequence),
+		"updated_at":      checkpoint.UpdatedAt.Format("2006-01-02T15:04:05.000Z"),
+		"version":         checkpoint.Version,
+		"gaps":            gapData,
+	}
+
+	result, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, update, params)
+		if err != nil {
+			return false, err
+		}
+		rows, err := records.Collect(ctx)
+		if err != nil {
+			return false, err
+		}
+		if len(rows) == 0 {
+			return false, nil
+		}
+
+		if _, err := tx.Run(ctx, replaceGaps, params); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+
+	if err != nil {
+		return false, fmt.Errorf("failed to save checkpoint for %s: %w", checkpoint.Network, err)
+	}
+
+	return result.(bool), nil
+}
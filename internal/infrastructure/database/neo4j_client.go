@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"crypto-bubble-map-indexer/internal/domain/entity"
 	"crypto-bubble-map-indexer/internal/infrastructure/config"
 	"crypto-bubble-map-indexer/internal/infrastructure/logger"
 
@@ -15,14 +17,17 @@ import (
 type Neo4JClient struct {
 	driver neo4j.DriverWithContext
 	config *config.Neo4JConfig
+	retry  *retryPolicy
 	logger *logger.Logger
 }
 
 // NewNeo4JClient creates a new Neo4J client
 func NewNeo4JClient(cfg *config.Neo4JConfig, logger *logger.Logger) *Neo4JClient {
+	log := logger.WithComponent("neo4j-client")
 	return &Neo4JClient{
 		config: cfg,
-		logger: logger.WithComponent("neo4j-client"),
+		retry:  newRetryPolicy(cfg, log),
+		logger: log,
 	}
 }
 
@@ -36,6 +41,8 @@ func (n *Neo4JClient) Connect(ctx context.Context) error {
 		func(config *neo4j.Config) {
 			config.MaxConnectionPoolSize = n.config.MaxConnectionPoolSize
 			config.ConnectionAcquisitionTimeout = n.config.ConnectionAcquisitionTimeout
+			// Transient failures are retried by the retry policy only, so attempts do not multiply
+			config.MaxTransactionRetryTime = 0
 		},
 	)
 	if err != nil {
@@ -81,9 +88,20 @@ func (n *Neo4JClient) setupSchema(ctx context.Context) error {
 	})
 	defer session.Close(ctx)
 
-	// Create constraints
+	// Migrate existing data before the constraints it has to satisfy are created
+	if err := n.runMigrations(ctx, session); err != nil {
+		return err
+	}
+
+	// Create constraints; wallets and contracts are identified by network and address
 	constraints := []string{
-		"CREATE CONSTRAINT wallet_address IF NOT EXISTS FOR (w:Wallet) REQUIRE w.address IS UNIQUE",
+		"CREATE CONSTRAINT wallet_network_address IF NOT EXISTS FOR (w:Wallet) REQUIRE (w.network, w.address) IS UNIQUE",
+		"CREATE CONSTRAINT erc20_contract_network_address IF NOT EXISTS FOR (c:ERC20Contract) REQUIRE (c.network, c.address) IS UNIQUE",
+		"CREATE CONSTRAINT schema_migration_name IF NOT EXISTS FOR (m:SchemaMigration) REQUIRE m.name IS UNIQUE",
+		"CREATE CONSTRAINT processed_transaction_key IF NOT EXISTS FOR (p:ProcessedTransaction) REQUIRE p.key IS UNIQUE",
+		"CREATE CONSTRAINT block_network_number IF NOT EXISTS FOR (b:Block) REQUIRE (b.network, b.number) IS UNIQUE",
+		"CREATE CONSTRAINT orphaned_block_network_number_hash IF NOT EXISTS FOR (o:OrphanedBlock) REQUIRE (o.network, o.number, o.hash) IS UNIQUE",
+		"CREATE CONSTRAINT indexer_checkpoint_network IF NOT EXISTS FOR (c:IndexerCheckpoint) REQUIRE c.network IS UNIQUE",
 	}
 
 	for _, constraint := range constraints {
@@ -100,6 +118,21 @@ func (n *Neo4JClient) setupSchema(ctx context.Context) error {
 		"CREATE INDEX wallet_first_seen IF NOT EXISTS FOR (w:Wallet) ON (w.first_seen)",
 		"CREATE INDEX wallet_last_seen IF NOT EXISTS FOR (w:Wallet) ON (w.last_seen)",
 		"CREATE INDEX wallet_network IF NOT EXISTS FOR (w:Wallet) ON (w.network)",
+		"CREATE INDEX wallet_address_lookup IF NOT EXISTS FOR (w:Wallet) ON (w.address)",
+		"CREATE INDEX erc20_contract_address_lookup IF NOT EXISTS FOR (c:ERC20Contract) ON (c.address)",
+		"CREATE INDEX block_gap_network IF NOT EXISTS FOR (g:BlockGap) ON (g.network, g.from_block)",
+		"CREATE INDEX processed_transaction_block IF NOT EXISTS FOR (p:ProcessedTransaction) ON (p.network, p.block_number)",
+		"CREATE INDEX tx_event_edge_time IF NOT EXISTS FOR (t:TxEvent) ON (t.edge_key, t.timestamp)",
+		"CREATE INDEX tx_event_edge_hash IF NOT EXISTS FOR (t:TxEvent) ON (t.edge_key, t.hash)",
+		"CREATE INDEX tx_event_type_time IF NOT EXISTS FOR (t:TxEvent) ON (t.rel_type, t.timestamp)",
+		"CREATE INDEX rollup_edge_bucket IF NOT EXISTS FOR ()-[r:ROLLUP]-() ON (r.edge_key, r.bucket_start)",
+		"CREATE INDEX rollup_bucket IF NOT EXISTS FOR ()-[r:ROLLUP]-() ON (r.bucket_start)",
+	}
+
+	// The repair command pages aggregated relationships by edge key
+	for _, relType := range entity.AggregatedRelationshipTypes {
+		indexes = append(indexes, fmt.Sprintf("CREATE INDEX %s_edge_key IF NOT EXISTS FOR ()-[r:%s]-() ON (r.edge_key)",
+			strings.ToLower(relType), relType))
 	}
 
 	for _, index := range indexes {
@@ -132,6 +165,11 @@ func (n *Neo4JClient) setupSchema(ctx context.Context) error {
 	return nil
 }
 
+// RetryStats returns the retry counters of the writes made through the client
+func (n *Neo4JClient) RetryStats() *entity.RetryStats {
+	return n.retry.stats()
+}
+
 // IsConnected checks if connected to Neo4J
 func (n *Neo4JClient) IsConnected(ctx context.Context) bool {
 	if n.driver == nil {
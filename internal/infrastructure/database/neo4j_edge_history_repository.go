@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// defaultEdgeHistoryLimit is the page size when the query does not set one
+const defaultEdgeHistoryLimit = 100
+
+// Neo4JEdgeHistoryRepository implements EdgeHistoryRepository interface
+type Neo4JEdgeHistoryRepository struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JEdgeHistoryRepository creates a new Neo4J edge history repository
+func NewNeo4JEdgeHistoryRepository(client *Neo4JClient, logger *logger.Logger) repository.EdgeHistoryRepository {
+	return &Neo4JEdgeHistoryRepository{
+		client: client,
+		logger: logger.WithComponent("neo4j-edge-history-repo"),
+	}
+}
+
+// GetEdgeTransactions returns a page of the TxEvent nodes of a relationship, newest first.
+// Pages are ordered by timestamp and element id, so the cursor stays stable while new
+// transactions are appended.
+func (r *Neo4JEdgeHistoryRepository) GetEdgeTransactions(ctx context.Context, query *entity.EdgeHistoryQuery) (*entity.EdgeHistoryPage, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultEdgeHistoryLimit
+	}
+
+	parameters := map[string]interface{}{
+		"edge_key":    query.Edge.Key(),
+		"since":       nil,
+		"until":       nil,
+		"cursor_time": nil,
+		"cursor_id":   nil,
+		"limit":       limit + 1,
+	}
+	if !query.Since.IsZero() {
+		parameters["since"] = query.Since.UTC().Format(edgeTimestampLayout)
+	}
+	if !query.Until.IsZero() {
+		parameters["until"] = query.Until.UTC().Format(edgeTimestampLayout)
+	}
+	if query.Cursor != "" {
+		timestamp, id, err := decodeEdgeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		parameters["cursor_time"] = timestamp
+		parameters["cursor_id"] = id
+	}
+
+	cypher := `
+		MATCH (t:TxEvent {edge_key: $edge_key})
+		WHERE ($since IS NULL OR t.timestamp >= datetime($since))
+			AND ($until IS NULL OR t.timestamp < datetime($until))
+			AND ($cursor_time IS NULL
+				OR t.timestamp < datetime($cursor_time)
+				OR (t.timestamp = datetime($cursor_time) AND elementId(t) < $cursor_id))
+		RETURN elementId(t), t.hash, t.value, t.timestamp, t.interaction_type, t.method_signature
+		ORDER BY t.timestamp DESC, elementId(t) DESC
+		LIMIT $limit
+	`
+
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, cypher, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return records.Collect(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edge transactions: %w", err)
+	}
+
+	rows := result.([]*neo4j.Record)
+	page := &entity.EdgeHistoryPage{Events: make([]*entity.EdgeEvent, 0, limit)}
+	for i, row := range rows {
+		if i == limit {
+			last := rows[limit-1]
+			timestamp, _ := last.Values[3].(time.Time)
+			page.NextCursor = encodeEdgeCursor(timestamp, stringValue(last.Values[0]))
+			break
+		}
+
+		event := &entity.EdgeEvent{
+			TxHash:          stringValue(row.Values[1]),
+			Value:           stringValue(row.Values[2]),
+			InteractionType: stringValue(row.Values[4]),
+			MethodSignature: stringValue(row.Values[5]),
+		}
+		if timestamp, ok := row.Values[3].(time.Time); ok {
+			event.Timestamp = timestamp
+		}
+		page.Events = append(page.Events, event)
+	}
+
+	return page, nil
+}
+
+// encodeEdgeCursor returns the opaque cursor of the page after the given event
+func encodeEdgeCursor(timestamp time.Time, id string) string {
+	raw := timestamp.UTC().Format(edgeTimestampLayout) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeEdgeCursor returns the timestamp and element id of the event a cursor points after
+func decodeEdgeCursor(cursor string) (string, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid edge history cursor: %w", err)
+	}
+
+	timestamp, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return "", "", fmt.Errorf("invalid edge history cursor: %s", cursor)
+	}
+	if _, err := time.Parse(edgeTimestampLayout, timestamp); err != nil {
+		return "", "", fmt.Errorf("invalid edge history cursor: %w", err)
+	}
+	return timestamp, id, nil
+}
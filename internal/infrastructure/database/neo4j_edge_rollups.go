@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// edgeRollupMerge merges the ROLLUP relationship of each wallet pair, asset and time bucket;
+// rollups carry the edge_key of their lifetime relationship. The bucket size is part of the
+// key, so buckets of a new size never merge into those of the previous one.
+const edgeRollupMerge = `
+	UNWIND $edges as e
+	MATCH (from:Wallet {network: e.network, address: e.from_address})
+	MATCH (to:Wallet {network: e.network, address: e.to_address})
+	MERGE (from)-[r:ROLLUP {edge_key: e.edge_key, bucket_seconds: e.bucket_seconds, bucket_start: datetime(e.bucket_start)}]->(to)
+	ON CREATE SET
+		r.rel_type = e.rel_type,
+		r.contract_address = e.contract_address
+` + edgeMergeSuffix
+
+// upsertEdgeRollups adds the contributions of a batch to the time buckets of the relationships
+// that are rolled up
+func upsertEdgeRollups(ctx context.Context, tx neo4j.ManagedTransaction, agg *edgeAggregator, bucket time.Duration) error {
+	if bucket <= 0 {
+		return nil
+	}
+
+	rollups := newEdgeAggregator()
+	for _, key := range agg.order {
+		c := agg.byKey[key]
+		if !entity.HasRollups(c.edge.RelType) {
+			continue
+		}
+		for _, event := range c.events {
+			start := entity.RollupBucketStart(event.Timestamp, bucket).Format(edgeTimestampLayout)
+			if err := rollups.addTo(key+"|"+start, c.edge, rollupParams(c.edge, start, bucket), event); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := mergeEdgeTotals(ctx, tx, edgeRollupMerge, rollups, false); err != nil {
+		return fmt.Errorf("failed to update rollups: %w", err)
+	}
+	return nil
+}
+
+// rollupParams returns the merge parameters of the rollup of a relationship for one bucket
+func rollupParams(edge entity.EdgeRef, bucketStart string, bucket time.Duration) map[string]interface{} {
+	params := map[string]interface{}{
+		"from_address":   edge.From,
+		"to_address":     edge.To,
+		"network":        entity.NormalizeNetwork(edge.Network),
+		"rel_type":       edge.RelType,
+		"bucket_start":   bucketStart,
+		"bucket_seconds": int64(bucket / time.Second),
+	}
+	if edge.ContractAddress != "" {
+		params["contract_address"] = edge.ContractAddress
+	}
+	return params
+}
+
+// undoEdgeRollups subtracts the TxEvent nodes of orphaned transactions from the rollups of
+// their buckets and deletes emptied rollups; edges hold the edge_key and tx_hashes of each
+// affected relationship. It must run before those TxEvent nodes are deleted.
+func undoEdgeRollups(ctx context.Context, tx neo4j.ManagedTransaction, edges []map[string]interface{}, bucket time.Duration) error {
+	if bucket <= 0 || len(edges) == 0 {
+		return nil
+	}
+
+	read := `
+		UNWIND $edges as e
+		MATCH (t:TxEvent {edge_key: e.edge_key})
+		WHERE t.hash IN e.tx_hashes
+		RETURN t.edge_key, t.timestamp, t.value
+	`
+
+	records, err := tx.Run(ctx, read, map[string]interface{}{"edges": edges})
+	if err != nil {
+		return fmt.Errorf("failed to read orphaned rollup contributions: %w", err)
+	}
+	rows, err := records.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read orphaned rollup contributions: %w", err)
+	}
+
+	type rollupUndo struct {
+		edgeKey     string
+		bucketStart string
+		value       *big.Int
+		count       int64
+	}
+	var keys []string
+	undos := make(map[string]*rollupUndo)
+	for _, row := range rows {
+		timestamp, ok := row.Values[1].(time.Time)
+		if !ok {
+			continue
+		}
+		edgeKey := stringValue(row.Values[0])
+		start := entity.RollupBucketStart(timestamp, bucket).Format(edgeTimestampLayout)
+		key := edgeKey + "|" + start
+
+		u, ok := undos[key]
+		if !ok {
+			u = &rollupUndo{edgeKey: edgeKey, bucketStart: start, value: new(big.Int)}
+			undos[key] = u
+			keys = append(keys, key)
+		}
+		value, err := entity.ParseAmount(stringValue(row.Values[2]))
+		if err != nil {
+			return fmt.Errorf("failed to parse event value: %w", err)
+		}
+		u.value.Add(u.value, value)
+		u.count++
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	rollups := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		u := undos[key]
+		rollups = append(rollups, map[string]interface{}{
+			"key":          key,
+			"edge_key":     u.edgeKey,
+			"bucket_start": u.bucketStart,
+		})
+	}
+
+	// Lock the rollups and read their totals so the subtraction is exact
+	lock := `
+		UNWIND $rollups as u
+		MATCH ()-[r:ROLLUP {edge_key: u.edge_key, bucket_seconds: $bucket_seconds, bucket_start: datetime(u.bucket_start)}]->()
+		SET r._lock = true
+		RETURN u.key, elementId(r), r.total_value
+	`
+
+	records, err = tx.Run(ctx, lock, map[string]interface{}{
+		"rollups":        rollups,
+		"bucket_seconds": int64(bucket / time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read rollups: %w", err)
+	}
+	rows, err = records.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read rollups: %w", err)
+	}
+
+	updates := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		u := undos[stringValue(row.Values[0])]
+		if u == nil {
+			continue
+		}
+
+		total, err := entity.ParseTotal(stringValue(row.Values[2]))
+		if err != nil {
+			return fmt.Errorf("failed to parse rollup total: %w", err)
+		}
+		total.Sub(total, u.value)
+		if total.Sign() < 0 {
+			total.SetInt64(0)
+		}
+		updates = append(updates, map[string]interface{}{
+			"id":          stringValue(row.Values[1]),
+			"total_value": total.String(),
+			"tx_count":    u.count,
+		})
+	}
+
+	write := `
+		UNWIND $updates as u
+		MATCH ()-[r]->()
+		WHERE elementId(r) = u.id
+		SET r.total_value = u.total_value,
+			r.tx_count = r.tx_count - u.tx_count
+		REMOVE r._lock
+		WITH r
+		WHERE r.tx_count <= 0
+		DELETE r
+	`
+
+	if _, err := tx.Run(ctx, write, map[string]interface{}{"updates": updates}); err != nil {
+		return fmt.Errorf("failed to undo rollup contributions: %w", err)
+	}
+
+	return nil
+}
+
+// rollupWindowFilter restricts the rollups matched as b to the buckets of the configured size
+// starting within the window given by windowParams, of the relationship types and token it
+// covers. Buckets of an earlier size cover the same transfers, so they are never summed along.
+const rollupWindowFilter = `b.rel_type IN $rollup_types
+			AND b.bucket_seconds = $bucket_seconds
+			AND ($token IS NULL OR toLower(b.contract_address) = $token)
+			AND ($since IS NULL OR b.bucket_start >= datetime($since))
+			AND ($until IS NULL OR b.bucket_start < datetime($until))`
+
+// windowParams returns the $rollup_types, $bucket_seconds, $token, $since and $until
+// parameters of a time window over buckets of a size; open bounds and an unset token are null
+func windowParams(window *entity.TimeWindow, bucket time.Duration) map[string]interface{} {
+	params := map[string]interface{}{
+		"rollup_types":   window.RelationshipTypes(),
+		"bucket_seconds": int64(bucket / time.Second),
+		"token":          nil,
+		"since":          nil,
+		"until":          nil,
+	}
+	if window == nil {
+		return params
+	}
+	if token := window.TokenAddress(); token != "" {
+		params["token"] = token
+	}
+	if !window.Since.IsZero() {
+		params["since"] = window.Since.UTC().Format(edgeTimestampLayout)
+	}
+	if !window.Until.IsZero() {
+		params["until"] = window.Until.UTC().Format(edgeTimestampLayout)
+	}
+	return params
+}
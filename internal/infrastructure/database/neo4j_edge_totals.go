@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// edgeTimestampLayout is the ISO-8601 format timestamps are passed to Neo4J in
+const edgeTimestampLayout = "2006-01-02T15:04:05.000Z"
+
+// edgeMergeSuffix completes a query that UNWINDs $edges as e and MERGEs a relationship as r.
+// It write-locks the relationship for the rest of the transaction and returns its current
+// total, so the new total can be computed exactly in Go.
+const edgeMergeSuffix = `
+	ON CREATE SET
+		r.total_value = "0",
+		r.tx_count = 0,
+		r.first_tx = datetime(e.first_tx),
+		r.last_tx = datetime(e.last_tx),
+		r.interaction_type = e.interaction_type,
+		r.network = e.network
+	SET r._lock = true, r.edge_key = e.edge_key
+	RETURN e.key, elementId(r), r.total_value
+`
+
+// edgeTotalsUpdate writes the totals computed for each locked relationship
+const edgeTotalsUpdate = `
+	UNWIND $edges as e
+	MATCH ()-[r]->()
+	WHERE elementId(r) = e.id
+	SET r.total_value = e.total_value,
+		r.tx_count = r.tx_count + e.tx_count,
+		r.first_tx = CASE WHEN datetime(e.first_tx) < r.first_tx THEN datetime(e.first_tx) ELSE r.first_tx END,
+		r.last_tx = CASE WHEN datetime(e.last_tx) > r.last_tx THEN datetime(e.last_tx) ELSE r.last_tx END
+	REMOVE r._lock
+`
+
+// edgeEventsCreate records each transaction's contribution as a TxEvent node keyed by the
+// edge_key of its relationship. Contributions are only written once per transaction (see
+// the processed markers), so plain CREATE is enough.
+const edgeEventsCreate = `
+	UNWIND $events as ev
+	CREATE (t:TxEvent {edge_key: ev.edge_key, hash: ev.hash})
+	SET t += ev.props,
+		t.timestamp = datetime(ev.timestamp)
+`
+
+// edgeContribution is what a batch adds to one relationship
+type edgeContribution struct {
+	edge    entity.EdgeRef
+	params  map[string]interface{}
+	value   *big.Int
+	latest  *big.Int
+	count   int64
+	firstTx string
+	lastTx  string
+	events  []*entity.EdgeEvent
+}
+
+// edgeAggregator groups the contributions of a batch by relationship identity
+type edgeAggregator struct {
+	order []string
+	byKey map[string]*edgeContribution
+}
+
+// newEdgeAggregator creates an empty aggregator
+func newEdgeAggregator() *edgeAggregator {
+	return &edgeAggregator{byKey: make(map[string]*edgeContribution)}
+}
+
+// add records one transaction's contribution to a relationship; params carry the endpoints
+// and properties the merge query needs
+func (a *edgeAggregator) add(edge entity.EdgeRef, params map[string]interface{}, event *entity.EdgeEvent) error {
+	return a.addTo(edge.Key(), edge, params, event)
+}
+
+// addTo records a contribution under an explicit key, for aggregates finer than the
+// relationship; the event value is normalized to a decimal amount
+func (a *edgeAggregator) addTo(key string, edge entity.EdgeRef, params map[string]interface{}, event *entity.EdgeEvent) error {
+	amount, err := entity.ParseAmount(event.Value)
+	if err != nil {
+		return fmt.Errorf("failed to parse value of transaction %s: %w", event.TxHash, err)
+	}
+	event.Value = amount.String()
+	timestamp := event.Timestamp.UTC().Format(edgeTimestampLayout)
+
+	c, ok := a.byKey[key]
+	if !ok {
+		c = &edgeContribution{
+			edge:    edge,
+			params:  params,
+			value:   new(big.Int),
+			firstTx: timestamp,
+			lastTx:  timestamp,
+		}
+		a.byKey[key] = c
+		a.order = append(a.order, key)
+	}
+
+	c.value.Add(c.value, amount)
+	c.latest = amount
+	c.count++
+	if timestamp < c.firstTx {
+		c.firstTx = timestamp
+	}
+	if timestamp > c.lastTx {
+		c.lastTx = timestamp
+	}
+	c.events = append(c.events, event)
+	return nil
+}
+
+// len returns the number of distinct relationships
+func (a *edgeAggregator) len() int {
+	return len(a.order)
+}
+
+// upsertEdgeTotals merges the relationships of a batch and adds the contributions to their
+// totals with exact integer arithmetic. mergeQuery must UNWIND $edges as e and MERGE the
+// relationship as r, followed by edgeMergeSuffix. With keepLatest the total is replaced by
+// the latest amount instead (e.g. approvals, whose total is the current allowance). Each
+// contribution is also recorded as a TxEvent node, so relationships stay pure aggregates,
+// and added to the rollups of its time bucket when rollupBucket is set.
+func upsertEdgeTotals(ctx context.Context, tx neo4j.ManagedTransaction, mergeQuery string, agg *edgeAggregator, keepLatest bool, rollupBucket time.Duration) error {
+	if agg.len() == 0 {
+		return nil
+	}
+
+	if err := mergeEdgeTotals(ctx, tx, mergeQuery, agg, keepLatest); err != nil {
+		return err
+	}
+
+	var events []map[string]interface{}
+	for _, key := range agg.order {
+		c := agg.byKey[key]
+		for _, event := range c.events {
+			events = append(events, edgeEventParams(key, c.edge, event))
+		}
+	}
+	if _, err := tx.Run(ctx, edgeEventsCreate, map[string]interface{}{"events": events}); err != nil {
+		return fmt.Errorf("failed to record relationship transactions: %w", err)
+	}
+
+	return upsertEdgeRollups(ctx, tx, agg, rollupBucket)
+}
+
+// mergeEdgeTotals merges the relationships of an aggregator and adds its contributions to
+// their totals
+func mergeEdgeTotals(ctx context.Context, tx neo4j.ManagedTransaction, mergeQuery string, agg *edgeAggregator, keepLatest bool) error {
+	if agg.len() == 0 {
+		return nil
+	}
+
+	edges := make([]map[string]interface{}, 0, agg.len())
+	for _, key := range agg.order {
+		c := agg.byKey[key]
+		edge := make(map[string]interface{}, len(c.params)+4)
+		for k, v := range c.params {
+			edge[k] = v
+		}
+		edge["key"] = key
+		edge["edge_key"] = c.edge.Key()
+		edge["first_tx"] = c.firstTx
+		edge["last_tx"] = c.lastTx
+		edges = append(edges, edge)
+	}
+
+	records, err := tx.Run(ctx, mergeQuery, map[string]interface{}{"edges": edges})
+	if err != nil {
+		return fmt.Errorf("failed to merge relationships: %w", err)
+	}
+	rows, err := records.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to merge relationships: %w", err)
+	}
+
+	// Several keys can resolve to the same relationship; their contributions are combined
+	type edgeUpdate struct {
+		total   *big.Int
+		count   int64
+		firstTx string
+		lastTx  string
+	}
+	var ids []string
+	updates := make(map[string]*edgeUpdate)
+	for _, row := range rows {
+		key := stringValue(row.Values[0])
+		id := stringValue(row.Values[1])
+		c := agg.byKey[key]
+		if c == nil {
+			continue
+		}
+
+		u, ok := updates[id]
+		if !ok {
+			total, err := entity.ParseTotal(stringValue(row.Values[2]))
+			if err != nil {
+				return fmt.Errorf("failed to parse relationship total: %w", err)
+			}
+			u = &edgeUpdate{
+				total:   total,
+				firstTx: c.firstTx,
+				lastTx:  c.lastTx,
+			}
+			updates[id] = u
+			ids = append(ids, id)
+		}
+
+		if keepLatest {
+			u.total = new(big.Int).Set(c.latest)
+		} else {
+			u.total.Add(u.total, c.value)
+		}
+		u.count += c.count
+		if c.firstTx < u.firstTx {
+			u.firstTx = c.firstTx
+		}
+		if c.lastTx > u.lastTx {
+			u.lastTx = c.lastTx
+		}
+	}
+
+	params := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		u := updates[id]
+		params = append(params, map[string]interface{}{
+			"id":          id,
+			"total_value": u.total.String(),
+			"tx_count":    u.count,
+			"first_tx":    u.firstTx,
+			"last_tx":     u.lastTx,
+		})
+	}
+
+	if _, err := tx.Run(ctx, edgeTotalsUpdate, map[string]interface{}{"edges": params}); err != nil {
+		return fmt.Errorf("failed to update relationship totals: %w", err)
+	}
+
+	return nil
+}
+
+// edgeEventParams returns the parameters of the TxEvent node of a contribution
+func edgeEventParams(key string, edge entity.EdgeRef, event *entity.EdgeEvent) map[string]interface{} {
+	props := map[string]interface{}{
+		"rel_type": edge.RelType,
+		"network":  entity.NormalizeNetwork(edge.Network),
+		"value":    event.Value,
+	}
+	if event.InteractionType != "" {
+		props["interaction_type"] = event.InteractionType
+	}
+	if event.MethodSignature != "" {
+		props["method_signature"] = event.MethodSignature
+	}
+
+	return map[string]interface{}{
+		"edge_key":  key,
+		"hash":      event.TxHash,
+		"timestamp": event.Timestamp.UTC().Format(edgeTimestampLayout),
+		"props":     props,
+	}
+}
@@ -30,11 +30,8 @@ func NewNeo4JERC20Repository(client *Neo4JClient, logger *logger.Logger) reposit
 
 // CreateOrUpdateERC20Contract creates or updates an ERC20 contract
 func (r *Neo4JERC20Repository) CreateOrUpdateERC20Contract(ctx context.Context, contract *entity.ERC20Contract) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
 	query := `
-		MERGE (c:ERC20Contract {address: $address})
+		MERGE (c:ERC20Contract {network: $network, address: $address})
 		ON CREATE SET
 			c.name = $name,
 			c.symbol = $symbol,
@@ -47,19 +44,28 @@ func (r *Neo4JERC20Repository) CreateOrUpdateERC20Contract(ctx context.Context,
 			c.last_seen = $last_seen,
 			c.total_txs = c.total_txs + 1
 	`
-
-	parameters := map[string]interface{}{
-		"address":    contract.Address,
-		"name":       contract.Name,
-		"symbol":     contract.Symbol,
-		"decimals":   contract.Decimals,
-		"first_seen": contract.FirstSeen.Format("2006-01-02T15:04:05.000Z"),
-		"last_seen":  contract.LastSeen.Format("2006-01-02T15:04:05.000Z"),
-		"total_txs":  contract.TotalTxs,
-		"network":    contract.Network,
+	if entity.IsNativeAsset(contract.Address) {
+		// The native currency shares the token model so flows of both are queried alike
+		query += `
+		SET c:NativeAsset,
+			c.contract_type = $contract_type,
+			c.is_verified = true
+	`
 	}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+	parameters := map[string]interface{}{
+		"address":       contract.Address,
+		"name":          contract.Name,
+		"symbol":        contract.Symbol,
+		"decimals":      contract.Decimals,
+		"first_seen":    contract.FirstSeen.Format("2006-01-02T15:04:05.000Z"),
+		"last_seen":     contract.LastSeen.Format("2006-01-02T15:04:05.000Z"),
+		"total_txs":     contract.TotalTxs,
+		"network":       entity.NormalizeNetwork(contract.Network),
+		"contract_type": contract.ContractType,
+	}
+
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		return tx.Run(ctx, query, parameters)
 	})
 
@@ -75,13 +81,10 @@ func (r *Neo4JERC20Repository) CreateOrUpdateERC20Contract(ctx context.Context,
 
 // CreateERC20TransferRelationship creates a transfer relationship between wallets
 func (r *Neo4JERC20Repository) CreateERC20TransferRelationship(ctx context.Context, transfer *entity.ERC20TransferRelationship) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
 	query := `
-		MERGE (from:Wallet {address: $from_address})
-		MERGE (to:Wallet {address: $to_address})
-		MERGE (contract:ERC20Contract {address: $contract_address})
+		MERGE (from:Wallet {network: $network, address: $from_address})
+		MERGE (to:Wallet {network: $network, address: $to_address})
+		MERGE (contract:ERC20Contract {network: $network, address: $contract_address})
 		CREATE (from)-[:ERC20_TRANSFER {
 			value: $value,
 			tx_hash: $tx_hash,
@@ -100,10 +103,10 @@ func (r *Neo4JERC20Repository) CreateERC20TransferRelationship(ctx context.Conte
 		"value":            transfer.Value,
 		"tx_hash":          transfer.TxHash,
 		"timestamp":        transfer.Timestamp.Format("2006-01-02T15:04:05.000Z"),
-		"network":          transfer.Network,
+		"network":          entity.NormalizeNetwork(transfer.Network),
 	}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		return tx.Run(ctx, query, parameters)
 	})
 
@@ -121,9 +124,6 @@ func (r *Neo4JERC20Repository) CreateERC20TransferRelationship(ctx context.Conte
 
 // BatchCreateERC20TransferRelationships creates multiple ERC20 transfer relationships in a batch
 func (r *Neo4JERC20Repository) BatchCreateERC20TransferRelationships(ctx context.Context, relationships []*entity.ERC20TransferRelationship) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
 	// Group relationships by type for optimal processing
 	relationshipGroups := make(map[string][]*entity.ERC20TransferRelationship)
 	for _, rel := range relationships {
@@ -137,7 +137,7 @@ func (r *Neo4JERC20Repository) BatchCreateERC20TransferRelationships(ctx context
 
 	// Process each relationship type separately
 	for relType, rels := range relationshipGroups {
-		if err := r.batchCreateRelationshipsByType(ctx, session, relType, rels); err != nil {
+		if err := r.batchCreateRelationshipsByType(ctx, relType, rels); err != nil {
 			return fmt.Errorf("failed to create %s relationships: %w", relType, err)
 		}
 		r.logger.Debug("Created relationships by type",
@@ -149,244 +149,174 @@ func (r *Neo4JERC20Repository) BatchCreateERC20TransferRelationships(ctx context
 }
 
 // batchCreateRelationshipsByType creates relationships of a specific type
-func (r *Neo4JERC20Repository) batchCreateRelationshipsByType(ctx context.Context, session neo4j.SessionWithContext, relType string, relationships []*entity.ERC20TransferRelationship) error {
+func (r *Neo4JERC20Repository) batchCreateRelationshipsByType(ctx context.Context, relType string, relationships []*entity.ERC20TransferRelationship) error {
 	// Different queries for different relationship types
 	var query string
 
 	switch relType {
 	case "ERC20_TRANSFER":
-		// For transfers, create relationship between wallets with tx_details
+		// For transfers, create relationship between wallets.
+		// Log-decoded transfers can involve wallets that never sent or received a transaction, so they are merged
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (to:Wallet {address: rel.to_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:ERC20_TRANSFER {contract_address: rel.contract_address}]->(to)
+			UNWIND $edges as e
+			MERGE (from:Wallet {network: e.network, address: e.from_address})
 			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.contract_address = rel.contract_address,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
+				from.first_seen = datetime(e.first_tx),
+				from.last_seen = datetime(e.first_tx),
+				from.total_transactions = 0,
+				from.total_sent = "0",
+				from.total_received = "0",
+				from.network = e.network
+			MERGE (to:Wallet {network: e.network, address: e.to_address})
+			ON CREATE SET
+				to.first_seen = datetime(e.first_tx),
+				to.last_seen = datetime(e.first_tx),
+				to.total_transactions = 0,
+				to.total_sent = "0",
+				to.total_received = "0",
+				to.network = e.network
+			WITH e, from, to
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:ERC20_TRANSFER {contract_address: e.contract_address}]->(to)
+		` + edgeMergeSuffix
 
 	case "ERC20_APPROVAL":
-		// For approvals, create relationship from wallet to contract/spender with tx_details
+		// For approvals, create relationship from wallet to contract/spender
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (to:Wallet {address: rel.to_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:ERC20_APPROVAL {contract_address: rel.contract_address, spender: rel.to_address}]->(contract)
+			UNWIND $edges as e
+			MERGE (from:Wallet {network: e.network, address: e.from_address})
+			ON CREATE SET
+				from.first_seen = datetime(e.first_tx),
+				from.last_seen = datetime(e.first_tx),
+				from.total_transactions = 0,
+				from.total_sent = "0",
+				from.total_received = "0",
+				from.network = e.network
+			MERGE (to:Wallet {network: e.network, address: e.to_address})
 			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.spender = rel.to_address,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = rel.value,
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
+				to.first_seen = datetime(e.first_tx),
+				to.last_seen = datetime(e.first_tx),
+				to.total_transactions = 0,
+				to.total_sent = "0",
+				to.total_received = "0",
+				to.network = e.network
+			WITH e, from, to
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:ERC20_APPROVAL {contract_address: e.contract_address, spender: e.to_address}]->(contract)
+		` + edgeMergeSuffix
 
 	case "DEX_SWAP":
-		// For swaps, create relationship from wallet to DEX contract with tx_details
+		// For swaps, create relationship from wallet to DEX contract
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:DEX_SWAP {contract_address: rel.contract_address}]->(contract)
-			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
+			UNWIND $edges as e
+			MATCH (from:Wallet {network: e.network, address: e.from_address})
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:DEX_SWAP {contract_address: e.contract_address}]->(contract)
+		` + edgeMergeSuffix
 
 	case "LIQUIDITY_OPERATION":
-		// For liquidity operations with tx_details
+		// For liquidity operations
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:LIQUIDITY_OPERATION {contract_address: rel.contract_address}]->(contract)
-			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
+			UNWIND $edges as e
+			MATCH (from:Wallet {network: e.network, address: e.from_address})
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:LIQUIDITY_OPERATION {contract_address: e.contract_address}]->(contract)
+		` + edgeMergeSuffix
 
 	case "DEFI_OPERATION":
-		// For DeFi operations (deposit/withdraw) with tx_details
+		// For DeFi operations (deposit/withdraw)
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:DEFI_OPERATION {contract_address: rel.contract_address}]->(contract)
-			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
+			UNWIND $edges as e
+			MATCH (from:Wallet {network: e.network, address: e.from_address})
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:DEFI_OPERATION {contract_address: e.contract_address}]->(contract)
+		` + edgeMergeSuffix
 
 	case "MULTICALL_OPERATION":
-		// For multicall operations with tx_details
+		// For multicall operations
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:MULTICALL_OPERATION {contract_address: rel.contract_address}]->(contract)
-			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
-
-	case "ETH_TRANSFER":
-		// For ETH transfers, create simple relationship with tx_details
+			UNWIND $edges as e
+			MATCH (from:Wallet {network: e.network, address: e.from_address})
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:MULTICALL_OPERATION {contract_address: e.contract_address}]->(contract)
+		` + edgeMergeSuffix
+
+	case "NATIVE_TRANSFER":
+		// Native currency moves between wallets like a token, keyed by the native asset address.
+		// SENT_TO keeps one contribution per transaction; this edge only counts value that moved.
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (to:Wallet {address: rel.to_address})
-			MERGE (from)-[r:ETH_TRANSFER]->(to)
+			UNWIND $edges as e
+			MERGE (from:Wallet {network: e.network, address: e.from_address})
 			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
+				from.first_seen = datetime(e.first_tx),
+				from.last_seen = datetime(e.first_tx),
+				from.total_transactions = 0,
+				from.total_sent = "0",
+				from.total_received = "0",
+				from.network = e.network
+			MERGE (to:Wallet {network: e.network, address: e.to_address})
+			ON CREATE SET
+				to.first_seen = datetime(e.first_tx),
+				to.last_seen = datetime(e.first_tx),
+				to.total_transactions = 0,
+				to.total_sent = "0",
+				to.total_received = "0",
+				to.network = e.network
+			WITH e, from, to
+			MERGE (from)-[r:NATIVE_TRANSFER {contract_address: e.contract_address}]->(to)
+		` + edgeMergeSuffix
 
 	default:
-		// For unknown contract interactions with tx_details
+		// For unknown contract interactions
 		query = `
-			UNWIND $relationships as rel
-			MATCH (from:Wallet {address: rel.from_address})
-			MATCH (contract:ERC20Contract {address: rel.contract_address})
-			MERGE (from)-[r:CONTRACT_INTERACTION {contract_address: rel.contract_address}]->(contract)
-			ON CREATE SET
-				r.total_value = rel.value,
-				r.tx_count = 1,
-				r.first_tx = datetime(rel.timestamp),
-				r.last_tx = datetime(rel.timestamp),
-				r.interaction_type = rel.interaction_type,
-				r.network = rel.network,
-				r.tx_details = [rel.tx_detail]
-			ON MATCH SET
-				r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-				r.tx_count = r.tx_count + 1,
-				r.last_tx = datetime(rel.timestamp),
-				r.tx_details = CASE
-					WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-					ELSE r.tx_details + rel.tx_detail
-				END
-		`
-	}
-
-	// Prepare relationship data with tx_details
-	var relData []map[string]interface{}
+			UNWIND $edges as e
+			MATCH (from:Wallet {network: e.network, address: e.from_address})
+			MATCH (contract:ERC20Contract {network: e.network, address: e.contract_address})
+			MERGE (from)-[r:CONTRACT_INTERACTION {contract_address: e.contract_address}]->(contract)
+		` + edgeMergeSuffix
+	}
+
+	// Aggregate the batch per relationship
+	agg := newEdgeAggregator()
 	for _, rel := range relationships {
-		timestampStr := rel.Timestamp.Format("2006-01-02T15:04:05.000Z")
-
-		// Create tx_detail string in format "hash:value:timestamp:interaction_type:method_signature"
-		// Enhanced format similar to SENT_TO but with more details for better tracking
-		txDetail := fmt.Sprintf("%s:%s:%s:%s:%s",
-			rel.TxHash,
-			rel.Value,
-			timestampStr,
-			string(rel.InteractionType),
-			rel.MethodSignature)
-
-		relData = append(relData, map[string]interface{}{
+		err := agg.add(rel.Edge(), map[string]interface{}{
 			"from_address":     rel.FromAddress,
 			"to_address":       rel.ToAddress,
 			"contract_address": rel.ContractAddress,
-			"value":            rel.Value,
-			"timestamp":        timestampStr,
 			"interaction_type": string(rel.InteractionType),
-			"network":          rel.Network,
-			"tx_detail":        txDetail,
+			"network":          entity.NormalizeNetwork(rel.Network),
+		}, &entity.EdgeEvent{
+			TxHash:          rel.TxHash,
+			Value:           rel.Value,
+			Timestamp:       rel.Timestamp,
+			InteractionType: string(rel.InteractionType),
+			MethodSignature: rel.MethodSignature,
 		})
+		if err != nil {
+			return err
+		}
 	}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, map[string]interface{}{"relationships": relData})
+	// An approval's total is the latest allowance rather than a sum
+	keepLatest := relType == "ERC20_APPROVAL"
+
+	// Token transfers also move balances between holders
+	holdings := newHoldingDeltas()
+	if relType == "ERC20_TRANSFER" {
+		for _, rel := range relationships {
+			value, err := entity.ParseAmount(rel.Value)
+			if err != nil {
+				return fmt.Errorf("failed to parse value of transaction %s: %w", rel.TxHash, err)
+			}
+			holdings.addTransfer(rel.Network, rel.FromAddress, rel.ToAddress, rel.ContractAddress, value)
+		}
+	}
+
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		if err := upsertEdgeTotals(ctx, tx, query, agg, keepLatest, r.client.config.RollupBucket); err != nil {
+			return nil, err
+		}
+		return nil, applyHoldingDeltas(ctx, tx, holdings)
 	})
 
 	if err != nil {
@@ -396,17 +326,18 @@ func (r *Neo4JERC20Repository) batchCreateRelationshipsByType(ctx context.Contex
 	return nil
 }
 
-// GetERC20Contract retrieves an ERC20 contract by address
-func (r *Neo4JERC20Repository) GetERC20Contract(ctx context.Context, address string) (*entity.ERC20Contract, error) {
+// GetERC20Contract retrieves the ERC20 contract of an address on a network
+func (r *Neo4JERC20Repository) GetERC20Contract(ctx context.Context, network, address string) (*entity.ERC20Contract, error) {
 	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH (c:ERC20Contract {address: $address})
+		MATCH (c:ERC20Contract {network: $network, address: $address})
 		RETURN c.address, c.name, c.symbol, c.decimals, c.first_seen, c.last_seen, c.total_txs, c.network
 	`
 
 	parameters := map[string]interface{}{
+		"network": entity.NormalizeNetwork(network),
 		"address": address,
 	}
 
@@ -440,17 +371,14 @@ func (r *Neo4JERC20Repository) GetERC20Contract(ctx context.Context, address str
 	return contract, nil
 }
 
-// GetERC20TransfersBetweenWallets retrieves ERC20 transfers between two wallets
+// GetERC20TransfersBetweenWallets retrieves the token and native transfers between two wallets
 func (r *Neo4JERC20Repository) GetERC20TransfersBetweenWallets(ctx context.Context, fromAddress, toAddress string, limit int) ([]*entity.ERC20Transfer, error) {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
 	query := `
-		MATCH (from:Wallet {address: $from_address})-[r:ERC20_TRANSFER]->(to:Wallet {address: $to_address})
-		RETURN r.contract_address, r.value, r.tx_hash, r.timestamp, r.network, from.address, to.address, ''
-		ORDER BY r.timestamp DESC
+		MATCH (from:Wallet {address: $from_address})-[r:ERC20_TRANSFER|NATIVE_TRANSFER]->(to:Wallet {address: $to_address})
+		WITH r, from.address as sender, to.address as receiver
+		ORDER BY r.last_tx DESC
 		LIMIT $limit
-	`
+	` + assetTransferEvents
 
 	parameters := map[string]interface{}{
 		"from_address": fromAddress,
@@ -458,94 +386,94 @@ func (r *Neo4JERC20Repository) GetERC20TransfersBetweenWallets(ctx context.Conte
 		"limit":        limit,
 	}
 
-	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, parameters)
-	})
-
+	transfers, err := r.assetTransfers(ctx, query, parameters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ERC20 transfers between wallets: %w", err)
 	}
-
-	var transfers []*entity.ERC20Transfer
-	records := result.(neo4j.ResultWithContext)
-
-	for records.Next(ctx) {
-		record := records.Record()
-		values := record.Values
-
-		transfer := &entity.ERC20Transfer{
-			ContractAddress: values[0].(string),
-			Value:           values[1].(string),
-			TxHash:          values[2].(string),
-			Timestamp:       values[3].(time.Time),
-			Network:         values[4].(string),
-			From:            values[5].(string),
-			To:              values[6].(string),
-			BlockNumber:     values[7].(string),
-		}
-		transfers = append(transfers, transfer)
-	}
-
 	return transfers, nil
 }
 
-// GetERC20TransfersForWallet retrieves all ERC20 transfers for a wallet
+// GetERC20TransfersForWallet retrieves the token and native transfers sent or received by a wallet
 func (r *Neo4JERC20Repository) GetERC20TransfersForWallet(ctx context.Context, address string, limit int) ([]*entity.ERC20Transfer, error) {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
 	query := `
 		MATCH (w:Wallet {address: $address})
-		MATCH (w)-[r:ERC20_TRANSFER]-(other:Wallet)
-		RETURN r.contract_address, r.value, r.tx_hash, r.timestamp, r.network,
-			   startNode(r).address, endNode(r).address, ''
-		ORDER BY r.timestamp DESC
+		MATCH (w)-[r:ERC20_TRANSFER|NATIVE_TRANSFER]-(other:Wallet)
+		WITH r, startNode(r).address as sender, endNode(r).address as receiver
+		ORDER BY r.last_tx DESC
 		LIMIT $limit
-	`
+	` + assetTransferEvents
 
 	parameters := map[string]interface{}{
 		"address": address,
 		"limit":   limit,
 	}
 
+	transfers, err := r.assetTransfers(ctx, query, parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ERC20 transfers for wallet: %w", err)
+	}
+	return transfers, nil
+}
+
+// assetTransferEvents completes a query that selects up to $limit transfer relationships as r
+// with their sender and receiver, ordered by their last transfer, which always include the
+// relationships of the $limit most recent transfers. It returns those transfers.
+const assetTransferEvents = `
+	CALL {
+		WITH r
+		MATCH (t:TxEvent {edge_key: r.edge_key})
+		RETURN t
+		ORDER BY t.timestamp DESC
+		LIMIT $limit
+	}
+	RETURN r.contract_address, r.network, sender, receiver,
+		t.hash, t.value, t.timestamp, t.interaction_type, t.method_signature
+	ORDER BY t.timestamp DESC
+	LIMIT $limit
+`
+
+// assetTransfers runs a query completed by assetTransferEvents and returns the transfers
+func (r *Neo4JERC20Repository) assetTransfers(ctx context.Context, query string, parameters map[string]interface{}) ([]*entity.ERC20Transfer, error) {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, parameters)
+		records, err := tx.Run(ctx, query, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return records.Collect(ctx)
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ERC20 transfers for wallet: %w", err)
+		return nil, err
 	}
 
 	var transfers []*entity.ERC20Transfer
-	records := result.(neo4j.ResultWithContext)
-
-	for records.Next(ctx) {
-		record := records.Record()
+	for _, record := range result.([]*neo4j.Record) {
 		values := record.Values
-
 		transfer := &entity.ERC20Transfer{
-			ContractAddress: values[0].(string),
-			Value:           values[1].(string),
-			TxHash:          values[2].(string),
-			Timestamp:       values[3].(time.Time),
-			Network:         values[4].(string),
-			From:            values[5].(string),
-			To:              values[6].(string),
-			BlockNumber:     values[7].(string),
+			ContractAddress: stringValue(values[0]),
+			Network:         stringValue(values[1]),
+			From:            stringValue(values[2]),
+			To:              stringValue(values[3]),
+			TxHash:          stringValue(values[4]),
+			Value:           stringValue(values[5]),
+			InteractionType: entity.ContractInteractionType(stringValue(values[7])),
+			MethodSignature: stringValue(values[8]),
+			Success:         true,
+		}
+		if timestamp, ok := values[6].(time.Time); ok {
+			transfer.Timestamp = timestamp
 		}
 		transfers = append(transfers, transfer)
 	}
-
 	return transfers, nil
 }
 
 // StoreContractClassification stores contract classification data
 func (r *Neo4JERC20Repository) StoreContractClassification(ctx context.Context, classification *entity.ContractClassification) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
 	query := `
-		MERGE (contract:ERC20Contract {address: $address})
+		MERGE (contract:ERC20Contract {network: $network, address: $address})
 		SET
 			contract.primary_type = $primary_type,
 			contract.secondary_types = $secondary_types,
@@ -586,12 +514,12 @@ func (r *Neo4JERC20Repository) StoreContractClassification(ctx context.Context,
 		"is_verified":               classification.IsVerified,
 		"verification_source":       classification.VerificationSource,
 		"tags":                      classification.Tags,
-		"network":                   classification.Network,
+		"network":                   entity.NormalizeNetwork(classification.Network),
 		"method_signatures_json":    string(methodSigJSON),
 		"interaction_patterns_json": string(interactionPatternsJSON),
 	}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		return tx.Run(ctx, query, parameters)
 	})
 
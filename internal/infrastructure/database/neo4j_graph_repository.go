@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// maxNeighbourScan bounds the edges read per frontier wallet, so expanding a hub costs the
+// same as any wallet; beyond it the strongest edges are those of the edges read
+const maxNeighbourScan = 5000
+
+// neighbourExpansion returns the strongest edges of each frontier wallet among at most
+// $scan_limit of its edges, leaving out wallets of $skip_types or with more than $skip_degree
+// SENT_TO relationships when set. Totals are decimal strings without leading zeros, so comparing
+// their length first orders and filters them exactly. The relationship pattern is validated
+// and formatted in.
+const neighbourExpansion = `
+	UNWIND $frontier as address
+	MATCH (w:Wallet {network: $network, address: address})
+	CALL {
+		WITH w
+		MATCH (w)%s(n:Wallet)
+		WHERE n <> w
+			AND ($skip_types IS NULL OR NOT coalesce(n.node_type, '') IN $skip_types)
+			AND ($skip_degree IS NULL OR COUNT { (n)-[:SENT_TO]-() } <= $skip_degree)
+			AND ($token IS NULL OR r.contract_address = $token)
+			AND ($min_value IS NULL OR size(r.total_value) > size($min_value)
+				OR (size(r.total_value) = size($min_value) AND r.total_value >= $min_value))
+		WITH r, n
+		LIMIT $scan_limit
+		WITH r, n
+		ORDER BY size(r.total_value) DESC, r.total_value DESC
+		LIMIT $limit
+		RETURN r, n
+	}
+	RETURN w.address, startNode(r).address, endNode(r).address, type(r), r.contract_address,
+		r.total_value, r.tx_count, r.first_tx, r.last_tx,
+		n.address, n.node_type, COUNT { (n)-[:SENT_TO]-() }
+`
+
+// Neo4JGraphRepository implements GraphRepository interface
+type Neo4JGraphRepository struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JGraphRepository creates a new Neo4J graph repository
+func NewNeo4JGraphRepository(client *Neo4JClient, logger *logger.Logger) repository.GraphRepository {
+	return &Neo4JGraphRepository{
+		client: client,
+		logger: logger.WithComponent("neo4j-graph-repo"),
+	}
+}
+
+// GetNeighbours returns the strongest edges of each frontier wallet that pass the filters of
+// the query, at most fanOut+1 per wallet. When the hub policy skips hubs they are left out
+// before the edges are ranked, so they do not take the place of other wallets.
+func (r *Neo4JGraphRepository) GetNeighbours(ctx context.Context, query *entity.TraversalQuery, frontier []string, fanOut int) ([]*entity.GraphNeighbour, error) {
+	if len(frontier) == 0 {
+		return nil, nil
+	}
+
+	pattern, err := neighbourPattern(query)
+	if err != nil {
+		return nil, err
+	}
+
+	network := entity.NormalizeNetwork(query.Network)
+	parameters := map[string]interface{}{
+		"frontier":    frontier,
+		"network":     network,
+		"token":       nil,
+		"min_value":   nil,
+		"limit":       fanOut + 1,
+		"scan_limit":  max(maxNeighbourScan, fanOut+1),
+		"skip_types":  nil,
+		"skip_degree": nil,
+	}
+	if query.HubPolicy == entity.HubPolicySkip {
+		hubTypes := query.HubTypes
+		if len(hubTypes) == 0 {
+			hubTypes = entity.DefaultHubNodeTypes
+		}
+		skipTypes := make([]string, len(hubTypes))
+		for i, nodeType := range hubTypes {
+			skipTypes[i] = string(nodeType)
+		}
+		parameters["skip_types"] = skipTypes
+		if query.HubDegree > 0 {
+			parameters["skip_degree"] = query.HubDegree
+		}
+	}
+	if token := strings.TrimSpace(query.Token); token != "" {
+		if entity.IsNativeAsset(token) {
+			token = entity.NativeAssetAddress
+		}
+		parameters["token"] = token
+	}
+	if query.MinValue != "" {
+		minValue, err := entity.ParseAmount(query.MinValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum value: %w", err)
+		}
+		parameters["min_value"] = minValue.String()
+	}
+
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, fmt.Sprintf(neighbourExpansion, pattern), parameters)
+		if err != nil {
+			return nil, err
+		}
+		return records.Collect(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get neighbours: %w", err)
+	}
+
+	var neighbours []*entity.GraphNeighbour
+	for _, record := range result.([]*neo4j.Record) {
+		values := record.Values
+		edge := &entity.SubgraphEdge{
+			From:            stringValue(values[1]),
+			To:              stringValue(values[2]),
+			RelType:         stringValue(values[3]),
+			ContractAddress: stringValue(values[4]),
+			TotalValue:      stringValue(values[5]),
+		}
+		edge.TxCount, _ = values[6].(int64)
+		edge.FirstTx, _ = values[7].(time.Time)
+		edge.LastTx, _ = values[8].(time.Time)
+
+		node := &entity.SubgraphNode{
+			Address:  stringValue(values[9]),
+			Network:  network,
+			NodeType: entity.NodeType(stringValue(values[10])),
+		}
+		node.Degree, _ = values[11].(int64)
+
+		neighbours = append(neighbours, &entity.GraphNeighbour{
+			Source: stringValue(values[0]),
+			Edge:   edge,
+			Node:   node,
+		})
+	}
+
+	return neighbours, nil
+}
+
+// neighbourPattern returns the relationship pattern of a traversal; only wallet-to-wallet
+// flows can be followed
+func neighbourPattern(query *entity.TraversalQuery) (string, error) {
+	relTypes := query.RelTypes
+	if len(relTypes) == 0 {
+		relTypes = entity.RollupRelationshipTypes
+	}
+	for _, relType := range relTypes {
+		if !entity.HasRollups(relType) {
+			return "", fmt.Errorf("relationship type %s cannot be traversed", relType)
+		}
+	}
+
+	rel := "[r:" + strings.Join(relTypes, "|") + "]"
+	switch query.Direction {
+	case entity.TraversalOutgoing:
+		return "-" + rel + "->", nil
+	case entity.TraversalIncoming:
+		return "<-" + rel + "-", nil
+	case entity.TraversalBoth, "":
+		return "-" + rel + "-", nil
+	default:
+		return "", fmt.Errorf("unknown traversal direction %s", query.Direction)
+	}
+}
@@ -41,7 +41,7 @@ func (r *Neo4jNodeClassificationRepository) CreateOrUpdateClassification(ctx con
 	reportedByJSON, _ := json.Marshal(classification.ReportedBy)
 
 	query := `
-		MERGE (w:Wallet {address: $address})
+		MERGE (w:Wallet {network: $network, address: $address})
 		SET w.node_type = $nodeType,
 			w.risk_level = $riskLevel,
 			w.confidence_score = $confidenceScore,
@@ -85,7 +85,7 @@ func (r *Neo4jNodeClassificationRepository) CreateOrUpdateClassification(ctx con
 			"exchanges":            string(exchangesJSON),
 			"protocols":            string(protocolsJSON),
 			"lastClassified":       classification.LastClassified,
-			"network":              classification.Network,
+			"network":              entity.NormalizeNetwork(classification.Network),
 			"totalTransactions":    classification.TotalTransactions,
 			"totalVolume":          classification.TotalVolume,
 			"firstActivity":        classification.FirstActivity,
@@ -104,13 +104,13 @@ func (r *Neo4jNodeClassificationRepository) CreateOrUpdateClassification(ctx con
 	return err
 }
 
-// GetClassification retrieves a node classification by address
-func (r *Neo4jNodeClassificationRepository) GetClassification(ctx context.Context, address string) (*entity.NodeClassification, error) {
+// GetClassification retrieves the node classification of an address on a network
+func (r *Neo4jNodeClassificationRepository) GetClassification(ctx context.Context, network, address string) (*entity.NodeClassification, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH (w:Wallet {address: $address})
+		MATCH (w:Wallet {network: $network, address: $address})
 		RETURN w.address as address,
 			   w.node_type as nodeType,
 			   w.risk_level as riskLevel,
@@ -139,6 +139,7 @@ func (r *Neo4jNodeClassificationRepository) GetClassification(ctx context.Contex
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		return tx.Run(ctx, query, map[string]interface{}{
+			"network": entity.NormalizeNetwork(network),
 			"address": strings.ToLower(address),
 		})
 	})
@@ -338,13 +339,22 @@ func (r *Neo4jNodeClassificationRepository) UpdateRiskLevel(ctx context.Context,
 	return err
 }
 
-// AddToBlacklist adds an address to the blacklist
+// AddToBlacklist adds an address to the blacklist; the wallets of the address on every
+// network are flagged
 func (r *Neo4jNodeClassificationRepository) AddToBlacklist(ctx context.Context, address, reason string) error {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
+	// An address not seen yet is blacklisted on the default network
+	ensureWallet := `
+		OPTIONAL MATCH (existing:Wallet {address: $address})
+		WITH count(existing) as found
+		WHERE found = 0
+		MERGE (:Wallet {network: $network, address: $address})
+	`
+
 	query := `
-		MERGE (w:Wallet {address: $address})
+		MATCH (w:Wallet {address: $address})
 		SET w.is_blacklisted = true,
 			w.blacklist_reason = $reason,
 			w.blacklisted_at = datetime(),
@@ -357,11 +367,17 @@ func (r *Neo4jNodeClassificationRepository) AddToBlacklist(ctx context.Context,
 		RETURN w.address as address
 	`
 
+	params := map[string]interface{}{
+		"address": strings.ToLower(address),
+		"reason":  reason,
+		"network": entity.DefaultNetwork,
+	}
+
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		return tx.Run(ctx, query, map[string]interface{}{
-			"address": strings.ToLower(address),
-			"reason":  reason,
-		})
+		if _, err := tx.Run(ctx, ensureWallet, params); err != nil {
+			return nil, err
+		}
+		return tx.Run(ctx, query, params)
 	})
 
 	return err
@@ -429,8 +445,8 @@ func (r *Neo4jNodeClassificationRepository) CreateNodeRelationship(ctx context.C
 	propertiesJSON, _ := json.Marshal(relationship.Properties)
 
 	query := fmt.Sprintf(`
-		MATCH (from:Wallet {address: $fromAddress})
-		MATCH (to:Wallet {address: $toAddress})
+		MATCH (from:Wallet {network: $network, address: $fromAddress})
+		MATCH (to:Wallet {network: $network, address: $toAddress})
 		MERGE (from)-[r:%s]->(to)
 		SET r.strength = $strength,
 			r.total_value = $totalValue,
@@ -454,7 +470,7 @@ func (r *Neo4jNodeClassificationRepository) CreateNodeRelationship(ctx context.C
 			"transactionCount": relationship.TransactionCount,
 			"firstSeen":        relationship.FirstSeen,
 			"lastSeen":         relationship.LastSeen,
-			"network":          relationship.Network,
+			"network":          entity.NormalizeNetwork(relationship.Network),
 			"confidence":       relationship.Confidence,
 			"detectionMethod":  relationship.DetectionMethod,
 			"properties":       string(propertiesJSON),
@@ -471,6 +487,7 @@ func (r *Neo4jNodeClassificationRepository) GetNodeRelationships(ctx context.Con
 
 	query := `
 		MATCH (w:Wallet {address: $address})-[r]-(other:Wallet)
+		WHERE type(r) <> "ROLLUP"
 		RETURN w.address as fromAddress,
 			   other.address as toAddress,
 			   type(r) as relationshipType,
@@ -532,9 +549,10 @@ func (r *Neo4jNodeClassificationRepository) GetSuspiciousCluster(ctx context.Con
 
 	query := fmt.Sprintf(`
 		MATCH path = (start:Wallet {address: $address})-[*1..%d]-(connected:Wallet)
-		WHERE connected.risk_level IN ['HIGH', 'CRITICAL']
+		WHERE none(rel IN relationships(path) WHERE type(rel) = "ROLLUP")
+		  AND (connected.risk_level IN ['HIGH', 'CRITICAL']
 		   OR connected.is_blacklisted = true
-		   OR connected.suspicious_activities IS NOT NULL
+		   OR connected.suspicious_activities IS NOT NULL)
 		RETURN DISTINCT connected.address as address,
 			   connected.node_type as nodeType,
 			   connected.risk_level as riskLevel,
@@ -731,7 +749,7 @@ func (r *Neo4jNodeClassificationRepository) BulkUpdateClassifications(ctx contex
 			tagsJSON, _ := json.Marshal(classification.Tags)
 
 			query := `
-				MERGE (w:Wallet {address: $address})
+				MERGE (w:Wallet {network: $network, address: $address})
 				SET w.node_type = $nodeType,
 					w.risk_level = $riskLevel,
 					w.confidence_score = $confidenceScore,
@@ -753,7 +771,7 @@ func (r *Neo4jNodeClassificationRepository) BulkUpdateClassifications(ctx contex
 				"detectionMethods": string(detectionMethodsJSON),
 				"tags":             string(tagsJSON),
 				"lastClassified":   classification.LastClassified,
-				"network":          classification.Network,
+				"network":          entity.NormalizeNetwork(classification.Network),
 			})
 
 			if err != nil {
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4JProcessedTransactionRepository implements ProcessedTransactionRepository using
+// ProcessedTransaction marker nodes keyed by network and transaction hash
+type Neo4JProcessedTransactionRepository struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JProcessedTransactionRepository creates a new Neo4J processed transaction repository
+func NewNeo4JProcessedTransactionRepository(client *Neo4JClient, logger *logger.Logger) repository.ProcessedTransactionRepository {
+	return &Neo4JProcessedTransactionRepository{
+		client: client,
+		logger: logger.WithComponent("neo4j-processed-tx-repo"),
+	}
+}
+
+// FilterUnprocessed returns the transactions that have no processed marker yet; within a unit
+// of work the markers are read in its transaction
+func (r *Neo4JProcessedTransactionRepository) FilterUnprocessed(ctx context.Context, transactions []*entity.Transaction) ([]*entity.Transaction, error) {
+	if len(transactions) == 0 {
+		return transactions, nil
+	}
+
+	keys := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		keys = append(keys, tx.Key())
+	}
+
+	query := `
+		UNWIND $keys as key
+		MATCH (p:ProcessedTransaction {key: key})
+		RETURN p.key
+	`
+
+	result, err := r.client.executeRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]interface{}{"keys": keys})
+		if err != nil {
+			return nil, err
+		}
+		processed := make(map[string]bool)
+		for records.Next(ctx) {
+			processed[records.Record().Values[0].(string)] = true
+		}
+		return processed, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up processed transactions: %w", err)
+	}
+
+	processed := result.(map[string]bool)
+	unprocessed := make([]*entity.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		if !processed[tx.Key()] {
+			unprocessed = append(unprocessed, tx)
+		}
+	}
+
+	return unprocessed, nil
+}
+
+// MarkProcessed records processed markers for the given transactions together with their rollback
+// journal. Markers are created rather than merged: when a concurrent delivery of a transaction
+// committed its marker first, the unique constraint fails this write with ErrAlreadyProcessed, and
+// with it the unit of work that indexed the transaction a second time.
+func (r *Neo4JProcessedTransactionRepository) MarkProcessed(ctx context.Context, transactions []*entity.IndexedTransaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	query := `
+		UNWIND $transactions as t
+		CREATE (p:ProcessedTransaction {key: t.key})
+		SET p.network = t.network,
+			p.hash = t.hash,
+			p.block_number = t.block_number,
+			p.block_hash = t.block_hash,
+			p.from_address = t.from_address,
+			p.to_address = t.to_address,
+			p.value = t.value,
+			p.effects_json = t.effects_json,
+			p.processed_at = datetime()
+	`
+
+	var txData []map[string]interface{}
+	for _, tx := range transactions {
+		effectsJSON, err := json.Marshal(tx.Effects)
+		if err != nil {
+			return fmt.Errorf("failed to marshal effects of transaction %s: %w", tx.Hash, err)
+		}
+
+		txData = append(txData, map[string]interface{}{
+			"key":          tx.Key(),
+			"network":      tx.Network,
+			"hash":         tx.Hash,
+			"block_number": int64(tx.BlockNumber),
+			"block_hash":   tx.BlockHash,
+			"from_address": tx.FromAddress,
+			"to_address":   tx.ToAddress,
+			"value":        tx.Value,
+			"effects_json": string(effectsJSON),
+		})
+	}
+
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, query, map[string]interface{}{"transactions": txData})
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume(ctx)
+	})
+
+	if errorCode(err) == constraintViolationCode {
+		return fmt.Errorf("failed to mark transactions as processed: %w: %v", repository.ErrAlreadyProcessed, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark transactions as processed: %w", err)
+	}
+
+	return nil
+}
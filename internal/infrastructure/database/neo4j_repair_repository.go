@@ -0,0 +1,647 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// Neo4JRepairRepository implements RepairRepository interface
+type Neo4JRepairRepository struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JRepairRepository creates a new Neo4J repair repository
+func NewNeo4JRepairRepository(client *Neo4JClient, logger *logger.Logger) repository.RepairRepository {
+	return &Neo4JRepairRepository{
+		client: client,
+		logger: logger.WithComponent("neo4j-repair-repo"),
+	}
+}
+
+// RepairEdgeTotals recomputes relationship totals from their TxEvent nodes page by page. A total is
+// only replaced while it still holds the value that was read, so relationships the indexer
+// updates concurrently are skipped rather than overwritten.
+func (r *Neo4JRepairRepository) RepairEdgeTotals(ctx context.Context, relType string, batchSize int, dryRun bool) (*entity.RepairReport, error) {
+	if !isAggregatedRelationshipType(relType) {
+		return nil, fmt.Errorf("relationship type %s has no aggregated totals", relType)
+	}
+
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	// The relationship type is validated above, so it can be part of the pattern. Pages follow
+	// the edge_key index of the type.
+	read := fmt.Sprintf(`
+		MATCH ()-[p:%[1]s]->()
+		WHERE p.edge_key > $after
+		WITH DISTINCT p.edge_key as key
+		ORDER BY key
+		LIMIT $limit
+		MATCH ()-[r:%[1]s {edge_key: key}]->()
+		OPTIONAL MATCH (t:TxEvent {edge_key: key})
+		WITH key, r, t ORDER BY t.timestamp
+		RETURN key, elementId(r), r.total_value, collect(t.value)
+	`, relType)
+
+	write := `
+		UNWIND $updates as u
+		MATCH ()-[r]->()
+		WHERE elementId(r) = u.id AND r.total_value = u.previous
+		SET r.total_value = u.total_value
+		RETURN count(r)
+	`
+
+	// An approval's total is the latest allowance rather than a sum
+	keepLatest := relType == "ERC20_APPROVAL"
+
+	report := &entity.RepairReport{Scope: relType}
+	after := ""
+	for {
+		result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			records, err := tx.Run(ctx, read, map[string]interface{}{"after": after, "limit": batchSize})
+			if err != nil {
+				return nil, err
+			}
+			return records.Collect(ctx)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to read %s relationships: %w", relType, err)
+		}
+
+		rows := result.([]*neo4j.Record)
+		if len(rows) == 0 {
+			return report, nil
+		}
+
+		var updates []map[string]interface{}
+		for _, row := range rows {
+			after = max(after, stringValue(row.Values[0]))
+			id := stringValue(row.Values[1])
+			report.Scanned++
+
+			total, err := edgeTotal(row.Values[3], keepLatest)
+			if err != nil {
+				r.skipInvalid(report, "relationship", id, err)
+				continue
+			}
+
+			previous := stringValue(row.Values[2])
+			if previous == total.String() {
+				continue
+			}
+			updates = append(updates, map[string]interface{}{
+				"id":          id,
+				"previous":    previous,
+				"total_value": total.String(),
+			})
+		}
+
+		repaired, err := r.applyUpdates(ctx, session, write, updates, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("failed to repair %s totals: %w", relType, err)
+		}
+		report.Repaired += repaired
+		report.Skipped += int64(len(updates)) - repaired
+
+		r.logger.Debug("Repaired relationship page",
+			zap.String("relationship_type", relType),
+			zap.Int64("scanned", report.Scanned),
+			zap.Int64("repaired", report.Repaired))
+	}
+}
+
+// RepairEdgeRollups rebuilds the time-bucketed rollups of relationships of a rolled up type
+// from their TxEvent nodes page by page, in the configured bucket size. Buckets without
+// transactions, e.g. of an earlier bucket size, are deleted. Like totals, a rollup is only
+// replaced while it still holds the value that was read.
+func (r *Neo4JRepairRepository) RepairEdgeRollups(ctx context.Context, relType string, batchSize int, dryRun bool) (*entity.RepairReport, error) {
+	if !entity.HasRollups(relType) {
+		return nil, fmt.Errorf("relationship type %s has no rollups", relType)
+	}
+	bucket := r.client.config.RollupBucket
+	if bucket <= 0 {
+		return nil, fmt.Errorf("rollups are disabled")
+	}
+
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	// The relationship type is validated above, so it can be part of the pattern. Pages follow
+	// the edge_key index of the type.
+	read := fmt.Sprintf(`
+		MATCH ()-[p:%[1]s]->()
+		WHERE p.edge_key > $after
+		WITH DISTINCT p.edge_key as key
+		ORDER BY key
+		LIMIT $limit
+		MATCH (a:Wallet)-[r:%[1]s {edge_key: key}]->(b:Wallet)
+		OPTIONAL MATCH (t:TxEvent {edge_key: key})
+		WITH a, r, b, key, collect([t.timestamp, t.value]) as events
+		OPTIONAL MATCH (a)-[x:ROLLUP {edge_key: key}]->(b)
+		RETURN key, elementId(r), a.network, a.address, b.address, r.contract_address, events,
+			collect([x.bucket_start, x.total_value, x.tx_count, x.bucket_seconds]) as rollups
+	`, relType)
+
+	write := `
+		UNWIND $updates as u
+		MATCH (a:Wallet {network: u.network, address: u.from_address})
+		MATCH (b:Wallet {network: u.network, address: u.to_address})
+		MERGE (a)-[r:ROLLUP {edge_key: u.edge_key, bucket_seconds: u.bucket_seconds, bucket_start: datetime(u.bucket_start)}]->(b)
+		ON CREATE SET
+			r.total_value = "0",
+			r.tx_count = 0,
+			r.rel_type = u.rel_type,
+			r.contract_address = u.contract_address,
+			r.network = u.network
+		WITH r, u
+		WHERE r.total_value = u.previous
+		SET r.total_value = u.total_value,
+			r.tx_count = u.tx_count,
+			r.first_tx = datetime(u.first_tx),
+			r.last_tx = datetime(u.last_tx)
+		FOREACH (_ IN CASE WHEN u.tx_count <= 0 THEN [1] ELSE [] END | DELETE r)
+		RETURN count(*)
+	`
+
+	report := &entity.RepairReport{Scope: relType + " rollups"}
+	after := ""
+	for {
+		result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			records, err := tx.Run(ctx, read, map[string]interface{}{"after": after, "limit": batchSize})
+			if err != nil {
+				return nil, err
+			}
+			return records.Collect(ctx)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to read %s relationships: %w", relType, err)
+		}
+
+		rows := result.([]*neo4j.Record)
+		if len(rows) == 0 {
+			return report, nil
+		}
+
+		var updates []map[string]interface{}
+		for _, row := range rows {
+			edgeKey := stringValue(row.Values[0])
+			after = max(after, edgeKey)
+
+			edge := entity.EdgeRef{
+				RelType:         relType,
+				Network:         stringValue(row.Values[2]),
+				From:            stringValue(row.Values[3]),
+				To:              stringValue(row.Values[4]),
+				ContractAddress: stringValue(row.Values[5]),
+			}
+			scanned, repairs, err := rollupRepairs(edge, edgeKey, row.Values[6], row.Values[7], bucket)
+			if err != nil {
+				report.Scanned++
+				r.skipInvalid(report, "relationship", stringValue(row.Values[1]), err)
+				continue
+			}
+			report.Scanned += int64(scanned)
+			updates = append(updates, repairs...)
+		}
+
+		repaired, err := r.applyUpdates(ctx, session, write, updates, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("failed to repair %s rollups: %w", relType, err)
+		}
+		report.Repaired += repaired
+		report.Skipped += int64(len(updates)) - repaired
+
+		r.logger.Debug("Repaired rollup page",
+			zap.String("relationship_type", relType),
+			zap.Int64("scanned", report.Scanned),
+			zap.Int64("repaired", report.Repaired))
+	}
+}
+
+// rollupRepairs recomputes the buckets of one relationship from its [timestamp, value] events
+// and returns the number of buckets, stored or recomputed, and the update parameters of those
+// that differ from the stored [bucket_start, total_value, tx_count, bucket_seconds] rollups.
+// Stored rollups of another bucket size are emptied and deleted.
+func rollupRepairs(edge entity.EdgeRef, edgeKey string, events, stored interface{}, bucket time.Duration) (int, []map[string]interface{}, error) {
+	type rollupTotals struct {
+		total   *big.Int
+		count   int64
+		firstTx time.Time
+		lastTx  time.Time
+	}
+	type rollupBucket struct {
+		start   string
+		seconds int64
+	}
+	seconds := int64(bucket / time.Second)
+	var starts []rollupBucket
+	buckets := make(map[rollupBucket]*rollupTotals)
+	eventList, _ := events.([]interface{})
+	for _, raw := range eventList {
+		pair, _ := raw.([]interface{})
+		if len(pair) != 2 {
+			continue
+		}
+		timestamp, ok := pair[0].(time.Time)
+		if !ok {
+			continue
+		}
+
+		start := rollupBucket{entity.RollupBucketStart(timestamp, bucket).Format(edgeTimestampLayout), seconds}
+		b, ok := buckets[start]
+		if !ok {
+			b = &rollupTotals{total: new(big.Int), firstTx: timestamp, lastTx: timestamp}
+			buckets[start] = b
+			starts = append(starts, start)
+		}
+		value, err := entity.ParseAmount(stringValue(pair[1]))
+		if err != nil {
+			return 0, nil, err
+		}
+		b.total.Add(b.total, value)
+		b.count++
+		if timestamp.Before(b.firstTx) {
+			b.firstTx = timestamp
+		}
+		if timestamp.After(b.lastTx) {
+			b.lastTx = timestamp
+		}
+	}
+
+	type storedRollup struct {
+		total string
+		count int64
+	}
+	existing := make(map[rollupBucket]storedRollup)
+	storedList, _ := stored.([]interface{})
+	for _, raw := range storedList {
+		values, _ := raw.([]interface{})
+		if len(values) != 4 {
+			continue
+		}
+		start, ok := values[0].(time.Time)
+		if !ok {
+			continue
+		}
+		storedSeconds, ok := values[3].(int64)
+		if !ok {
+			continue
+		}
+		key := rollupBucket{start.UTC().Format(edgeTimestampLayout), storedSeconds}
+		count, _ := values[2].(int64)
+		existing[key] = storedRollup{total: stringValue(values[1]), count: count}
+		if _, ok := buckets[key]; !ok {
+			// A bucket without transactions is emptied and deleted
+			buckets[key] = &rollupTotals{total: new(big.Int)}
+			starts = append(starts, key)
+		}
+	}
+
+	var updates []map[string]interface{}
+	for _, start := range starts {
+		b := buckets[start]
+		previous, ok := existing[start]
+		if ok && previous.total == b.total.String() && previous.count == b.count {
+			continue
+		}
+		if !ok {
+			previous.total = "0"
+		}
+
+		update := rollupParams(edge, start.start, bucket)
+		update["bucket_seconds"] = start.seconds
+		update["edge_key"] = edgeKey
+		update["previous"] = previous.total
+		update["total_value"] = b.total.String()
+		update["tx_count"] = b.count
+		update["first_tx"] = nil
+		update["last_tx"] = nil
+		if b.count > 0 {
+			update["first_tx"] = b.firstTx.UTC().Format(edgeTimestampLayout)
+			update["last_tx"] = b.lastTx.UTC().Format(edgeTimestampLayout)
+		}
+		updates = append(updates, update)
+	}
+	return len(starts), updates, nil
+}
+
+// RepairWalletTotals recomputes wallet totals from the SENT_TO relationships page by page
+func (r *Neo4JRepairRepository) RepairWalletTotals(ctx context.Context, batchSize int, dryRun bool) (*entity.RepairReport, error) {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	read := `
+		MATCH (p:Wallet)
+		WHERE p.address > $after
+		WITH DISTINCT p.address as address
+		ORDER BY address
+		LIMIT $limit
+		MATCH (w:Wallet {address: address})
+		OPTIONAL MATCH (w)-[s:SENT_TO]->()
+		WITH w, collect(s.total_value) as sent
+		OPTIONAL MATCH ()-[rc:SENT_TO]->(w)
+		RETURN w.address, elementId(w), w.total_sent, w.total_received, sent, collect(rc.total_value) as received
+	`
+
+	write := `
+		UNWIND $updates as u
+		MATCH (w:Wallet)
+		WHERE elementId(w) = u.id AND w.total_sent = u.previous_sent AND w.total_received = u.previous_received
+		SET w.total_sent = u.total_sent,
+			w.total_received = u.total_received
+		RETURN count(w)
+	`
+
+	report := &entity.RepairReport{Scope: "Wallet"}
+	after := ""
+	for {
+		result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			records, err := tx.Run(ctx, read, map[string]interface{}{"after": after, "limit": batchSize})
+			if err != nil {
+				return nil, err
+			}
+			return records.Collect(ctx)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to read wallets: %w", err)
+		}
+
+		rows := result.([]*neo4j.Record)
+		if len(rows) == 0 {
+			return report, nil
+		}
+
+		var updates []map[string]interface{}
+		for _, row := range rows {
+			after = max(after, stringValue(row.Values[0]))
+			id := stringValue(row.Values[1])
+			report.Scanned++
+
+			totalSent, err := sumAmounts(row.Values[4])
+			if err != nil {
+				r.skipInvalid(report, "wallet", id, err)
+				continue
+			}
+			totalReceived, err := sumAmounts(row.Values[5])
+			if err != nil {
+				r.skipInvalid(report, "wallet", id, err)
+				continue
+			}
+			previousSent := stringValue(row.Values[2])
+			previousReceived := stringValue(row.Values[3])
+			if previousSent == totalSent && previousReceived == totalReceived {
+				continue
+			}
+			updates = append(updates, map[string]interface{}{
+				"id":                id,
+				"previous_sent":     previousSent,
+				"previous_received": previousReceived,
+				"total_sent":        totalSent,
+				"total_received":    totalReceived,
+			})
+		}
+
+		repaired, err := r.applyUpdates(ctx, session, write, updates, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("failed to repair wallet totals: %w", err)
+		}
+		report.Repaired += repaired
+		report.Skipped += int64(len(updates)) - repaired
+
+		r.logger.Debug("Repaired wallet page",
+			zap.Int64("scanned", report.Scanned),
+			zap.Int64("repaired", report.Repaired))
+	}
+}
+
+// RepairTokenHoldings recomputes HOLDS balances page by page of wallets: a balance is what the
+// wallet received minus what it sent over its ERC20_TRANSFER relationships of the token. Missing
+// holdings are created, emptied ones deleted, and a balance is only replaced while it still
+// holds the value that was read.
+func (r *Neo4JRepairRepository) RepairTokenHoldings(ctx context.Context, batchSize int, dryRun bool) (*entity.RepairReport, error) {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	read := `
+		MATCH (p:Wallet)
+		WHERE p.address > $after
+		WITH DISTINCT p.address as address
+		ORDER BY address
+		LIMIT $limit
+		MATCH (w:Wallet {address: address})
+		OPTIONAL MATCH (w)-[s:ERC20_TRANSFER]->()
+		WITH w, collect([s.contract_address, s.total_value]) as sent
+		OPTIONAL MATCH ()-[rc:ERC20_TRANSFER]->(w)
+		WITH w, sent, collect([rc.contract_address, rc.total_value]) as received
+		OPTIONAL MATCH (w)-[h:HOLDS]->()
+		RETURN elementId(w), w.address, sent, received, collect([h.contract_address, h.balance])
+	`
+
+	// A holding created here for a balance that changed concurrently keeps "0" and is deleted again
+	write := `
+		UNWIND $updates as u
+		MATCH (w:Wallet)
+		WHERE elementId(w) = u.id
+		MATCH (c:ERC20Contract {network: w.network, address: u.contract_address})
+		MERGE (w)-[h:HOLDS {contract_address: u.contract_address}]->(c)
+		ON CREATE SET
+			h.balance = "0",
+			h.network = w.network
+		WITH h, u, h.balance = u.previous as current
+		SET h.balance = CASE WHEN current THEN u.balance ELSE h.balance END
+		WITH h, current
+		FOREACH (_ IN CASE WHEN h.balance = "0" THEN [1] ELSE [] END | DELETE h)
+		RETURN sum(CASE WHEN current THEN 1 ELSE 0 END)
+	`
+
+	report := &entity.RepairReport{Scope: "HOLDS"}
+	after := ""
+	for {
+		result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			records, err := tx.Run(ctx, read, map[string]interface{}{"after": after, "limit": batchSize})
+			if err != nil {
+				return nil, err
+			}
+			return records.Collect(ctx)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to read wallet holdings: %w", err)
+		}
+
+		rows := result.([]*neo4j.Record)
+		if len(rows) == 0 {
+			return report, nil
+		}
+
+		var updates []map[string]interface{}
+		for _, row := range rows {
+			id := stringValue(row.Values[0])
+			address := stringValue(row.Values[1])
+			after = max(after, address)
+			if !entity.IsHolderAddress(address) {
+				continue
+			}
+
+			var contracts []string
+			balances := make(map[string]*big.Int)
+			addTotals := func(value interface{}, sign int64) error {
+				pairs, _ := value.([]interface{})
+				for _, raw := range pairs {
+					pair, _ := raw.([]interface{})
+					if len(pair) != 2 || pair[0] == nil {
+						continue
+					}
+					amount, err := entity.ParseTotal(stringValue(pair[1]))
+					if err != nil {
+						return err
+					}
+					contract := stringValue(pair[0])
+					if _, ok := balances[contract]; !ok {
+						balances[contract] = new(big.Int)
+						contracts = append(contracts, contract)
+					}
+					balances[contract].Add(balances[contract], amount.Mul(amount, big.NewInt(sign)))
+				}
+				return nil
+			}
+			if err := addTotals(row.Values[3], 1); err != nil {
+				r.skipInvalid(report, "wallet", id, err)
+				continue
+			}
+			if err := addTotals(row.Values[2], -1); err != nil {
+				r.skipInvalid(report, "wallet", id, err)
+				continue
+			}
+
+			stored := make(map[string]string)
+			held, _ := row.Values[4].([]interface{})
+			for _, raw := range held {
+				pair, _ := raw.([]interface{})
+				if len(pair) != 2 || pair[0] == nil {
+					continue
+				}
+				contract := stringValue(pair[0])
+				stored[contract] = stringValue(pair[1])
+				if _, ok := balances[contract]; !ok {
+					balances[contract] = new(big.Int)
+					contracts = append(contracts, contract)
+				}
+			}
+
+			for _, contract := range contracts {
+				report.Scanned++
+				previous, ok := stored[contract]
+				if !ok {
+					previous = "0"
+				}
+				balance := balances[contract].String()
+				if previous == balance {
+					continue
+				}
+				updates = append(updates, map[string]interface{}{
+					"id":               id,
+					"contract_address": contract,
+					"previous":         previous,
+					"balance":          balance,
+				})
+			}
+		}
+
+		repaired, err := r.applyUpdates(ctx, session, write, updates, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("failed to repair token holdings: %w", err)
+		}
+		report.Repaired += repaired
+		report.Skipped += int64(len(updates)) - repaired
+
+		r.logger.Debug("Repaired holdings page",
+			zap.Int64("scanned", report.Scanned),
+			zap.Int64("repaired", report.Repaired))
+	}
+}
+
+// applyUpdates writes one page of recomputed totals and returns how many were replaced
+func (r *Neo4JRepairRepository) applyUpdates(ctx context.Context, session neo4j.SessionWithContext, query string, updates []map[string]interface{}, dryRun bool) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	if dryRun {
+		return int64(len(updates)), nil
+	}
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]interface{}{"updates": updates})
+		if err != nil {
+			return nil, err
+		}
+		record, err := records.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[0], nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	repaired, _ := result.(int64)
+	return repaired, nil
+}
+
+// isAggregatedRelationshipType reports whether relationships of the type carry a total_value
+func isAggregatedRelationshipType(relType string) bool {
+	for _, aggregated := range entity.AggregatedRelationshipTypes {
+		if relType == aggregated {
+			return true
+		}
+	}
+	return false
+}
+
+// edgeTotal recomputes a relationship total from its event values in time order; with
+// keepLatest the total is the latest value
+func edgeTotal(value interface{}, keepLatest bool) (*big.Int, error) {
+	total := new(big.Int)
+	values, _ := value.([]interface{})
+	for _, raw := range values {
+		amount, err := entity.ParseAmount(stringValue(raw))
+		if err != nil {
+			return nil, err
+		}
+		if keepLatest {
+			total = amount
+		} else {
+			total.Add(total, amount)
+		}
+	}
+	return total, nil
+}
+
+// skipInvalid records an aggregate left unchanged because a value it is built from is malformed
+func (r *Neo4JRepairRepository) skipInvalid(report *entity.RepairReport, kind, id string, err error) {
+	report.Invalid++
+	r.logger.Warn("Skipping aggregate built from a malformed amount",
+		zap.String("scope", report.Scope),
+		zap.String(kind, id),
+		zap.Error(err))
+}
+
+// sumAmounts returns the exact sum of a list of decimal amounts read from Neo4J
+func sumAmounts(value interface{}) (string, error) {
+	list, _ := value.([]interface{})
+	amounts := make([]string, 0, len(list))
+	for _, amount := range list {
+		amounts = append(amounts, stringValue(amount))
+	}
+	return entity.AddAmounts(amounts...)
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// nonRetriableTransientCodes are transient codes raised when a transaction was terminated
+// on purpose, which the driver does not retry either
+var nonRetriableTransientCodes = map[string]bool{
+	"Neo.TransientError.Transaction.Terminated":        true,
+	"Neo.TransientError.Transaction.LockClientStopped": true,
+}
+
+// constraintViolationCode is raised when a write would break a uniqueness constraint
+const constraintViolationCode = "Neo.ClientError.Schema.ConstraintValidationFailed"
+
+// retryPolicy retries Neo4J writes that fail with transient errors
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	logger         *logger.Logger
+
+	attempts  atomic.Uint64
+	retries   atomic.Uint64
+	exhausted atomic.Uint64
+	permanent atomic.Uint64
+}
+
+// newRetryPolicy creates a retry policy from the Neo4J configuration
+func newRetryPolicy(cfg *config.Neo4JConfig, logger *logger.Logger) *retryPolicy {
+	policy := &retryPolicy{
+		maxAttempts:    cfg.RetryMaxAttempts,
+		initialBackoff: cfg.RetryInitialBackoff,
+		maxBackoff:     cfg.RetryMaxBackoff,
+		logger:         logger,
+	}
+	if policy.maxAttempts <= 0 {
+		policy.maxAttempts = 1
+	}
+	if policy.initialBackoff <= 0 {
+		policy.initialBackoff = 100 * time.Millisecond
+	}
+	if policy.maxBackoff < policy.initialBackoff {
+		policy.maxBackoff = policy.initialBackoff
+	}
+	return policy
+}
+
+// run calls fn until it succeeds, fails with a non-transient error or runs out of attempts. A
+// write that lost the race for a processed marker is retried too: the next attempt sees the
+// committed marker and skips the transaction.
+func (p *retryPolicy) run(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		p.attempts.Add(1)
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if !IsTransientError(err) && !errors.Is(err, repository.ErrAlreadyProcessed) {
+			p.permanent.Add(1)
+			return err
+		}
+		if attempt >= p.maxAttempts {
+			p.exhausted.Add(1)
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		backoff := p.backoff(attempt)
+		p.retries.Add(1)
+		p.logger.Warn("Retrying Neo4J write after transient error",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.String("code", errorCode(err)),
+			zap.Error(err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry of Neo4J write cancelled: %w", err)
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt: exponential in the attempt, capped,
+// with half of it randomized so workers contending for the same nodes spread out
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.initialBackoff
+	for i := 1; i < attempt && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// stats returns the retry counters
+func (p *retryPolicy) stats() *entity.RetryStats {
+	return &entity.RetryStats{
+		Attempts:  p.attempts.Load(),
+		Retries:   p.retries.Load(),
+		Exhausted: p.exhausted.Load(),
+		Permanent: p.permanent.Load(),
+	}
+}
+
+// IsTransientError reports whether a failed write may succeed when retried: deadlocks, lock
+// timeouts and other Neo.TransientError codes, and lost connections. Client errors such as
+// constraint violations or syntax errors are permanent.
+func IsTransientError(err error) bool {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) {
+		if len(limit.Errors) == 0 {
+			return false
+		}
+		return IsTransientError(limit.Errors[len(limit.Errors)-1])
+	}
+
+	var dbErr *neo4j.Neo4jError
+	if errors.As(err, &dbErr) {
+		return strings.HasPrefix(dbErr.Code, "Neo.TransientError.") && !nonRetriableTransientCodes[dbErr.Code]
+	}
+
+	var connErr *neo4j.ConnectivityError
+	return errors.As(err, &connErr)
+}
+
+// errorCode returns the Neo4J status code of an error, or "" if it has none
+func errorCode(err error) string {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) && len(limit.Errors) > 0 {
+		return errorCode(limit.Errors[len(limit.Errors)-1])
+	}
+
+	var dbErr *neo4j.Neo4jError
+	if errors.As(err, &dbErr) {
+		return dbErr.Code
+	}
+	return ""
+}
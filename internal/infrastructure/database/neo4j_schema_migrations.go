@@ -0,0 +1,313 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+// schemaMigration is a one-off data migration applied before the constraints are created.
+// Each statement returns the number of rows it changed and is repeated until it changes
+// none, so large graphs are migrated in bounded transactions. A statement returning a
+// cursor column instead pages through the graph: it is repeated with the cursor as $after
+// until the cursor is null.
+type schemaMigration struct {
+	name       string
+	statements []string
+}
+
+// normalizeNetworkStatement assigns the default network to nodes of a label without one and
+// lower-cases the others, in batches
+const normalizeNetworkStatement = `
+	MATCH (n:%s)
+	WHERE n.network IS NULL OR n.network <> toLower(trim(n.network)) OR trim(n.network) = ""
+	WITH n LIMIT 10000
+	SET n.network = CASE
+		WHEN n.network IS NULL OR trim(n.network) = "" THEN $default_network
+		ELSE toLower(trim(n.network))
+	END
+	RETURN count(n)
+`
+
+// edgeHistoryEventsStatement moves a chunk of the tx_details of the relationships of a page of
+// wallets to TxEvent nodes and keys the relationships by the same edge_key the indexer writes.
+// Pages are distinct sender addresses in the order of the address index; a page is repeated
+// until none of its relationships has details left.
+const edgeHistoryEventsStatement = `
+	MATCH (page:Wallet)
+	WHERE page.address > $after
+	WITH DISTINCT page.address as address
+	ORDER BY address
+	LIMIT 100
+	WITH collect(address) as addresses
+	CALL {
+		WITH addresses
+		UNWIND addresses as address
+		MATCH (a:Wallet {address: address})-[r]->(b)
+		WHERE type(r) IN $aggregated_types AND (r.tx_details IS NOT NULL OR r.edge_key IS NULL)
+		WITH a, r, coalesce(r.tx_details, []) as details, coalesce(r.edge_key,
+			type(r) + "|" + a.network + "|" + a.address + "|" +
+			CASE WHEN type(r) = "ERC20_APPROVAL" THEN r.spender ELSE b.address END + "|" +
+			coalesce(r.contract_address, "")) as key
+		CALL {
+			WITH a, r, details, key
+			UNWIND details[0..1000] as detail
+			WITH a, r, key, split(detail, ":") as parts
+			WHERE size(parts) >= 5
+			CREATE (t:TxEvent {edge_key: key, hash: parts[0]})
+			SET t.rel_type = type(r),
+				t.network = a.network,
+				t.value = parts[1],
+				t.timestamp = datetime(parts[2] + ":" + parts[3] + ":" + parts[4]),
+				t.interaction_type = CASE WHEN size(parts) >= 7 AND parts[5] <> "" THEN parts[5] END,
+				t.method_signature = CASE WHEN size(parts) >= 7 AND parts[6] <> "" THEN parts[6] END
+			RETURN count(t) as created
+		}
+		SET r.edge_key = key,
+			r.tx_details = CASE WHEN size(details) > 1000 THEN details[1000..] END
+		RETURN count(CASE WHEN size(details) > 1000 THEN r END) as remaining
+	}
+	RETURN CASE WHEN remaining > 0 THEN $after ELSE addresses[-1] END as cursor
+`
+
+// splitNetworkStatement moves the relationships of a page of nodes of a label whose network
+// differs from the node's to the node of the same address on that network. Pages are distinct
+// addresses in the order of the address index, so split nodes are never revisited.
+const splitNetworkStatement = `
+	MATCH (page:%[1]s)
+	WHERE page.address > $after
+	WITH DISTINCT page.address as address
+	ORDER BY address
+	LIMIT 1000
+	WITH collect(address) as addresses
+	CALL {
+		WITH addresses
+		UNWIND addresses as address
+		MATCH (n:%[1]s {address: address})
+		%[2]s
+		RETURN count(n) as nodes
+	}
+	RETURN addresses[-1] as cursor
+`
+
+// splitRelationshipStatement moves the relationships of one type and direction of a node n to
+// its node on the relationship's network
+const splitRelationshipStatement = `
+		CALL {
+			WITH n
+			MATCH (n)%[2]s(other)
+			WHERE trim(coalesce(r.network, "")) <> "" AND toLower(trim(r.network)) <> n.network
+			WITH n, r, other, toLower(trim(r.network)) as network
+			MERGE (split:%[1]s {network: network, address: n.address})
+			ON CREATE SET %[3]s
+			CREATE (split)%[4]s(other)
+			SET moved = properties(r), moved.network = network
+			DELETE r
+			RETURN count(moved) as relationships
+		}
+		WITH n`
+
+// splitNetworkMigration builds the statements splitting the nodes of a label that were merged
+// across networks while they were unique by address. Only relationships that record their
+// network can be attributed; the others stay with the network the node kept. Split nodes start
+// with the given properties, counters are recomputed by the repair command. The pages are read
+// through the address index of the label, created here since constraints and indexes are only
+// set up after the migrations.
+func splitNetworkMigration(label, index, onCreate string) []string {
+	var calls strings.Builder
+	for _, relType := range entity.AggregatedRelationshipTypes {
+		calls.WriteString(fmt.Sprintf(splitRelationshipStatement, label,
+			"-[r:"+relType+"]->", onCreate, "-[moved:"+relType+"]->"))
+		calls.WriteString(fmt.Sprintf(splitRelationshipStatement, label,
+			"<-[r:"+relType+"]-", onCreate, "<-[moved:"+relType+"]-"))
+	}
+
+	return []string{
+		fmt.Sprintf("CREATE INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.address)", index, label),
+		"CALL db.awaitIndexes(600)",
+		fmt.Sprintf(splitNetworkStatement, label, calls.String()),
+	}
+}
+
+// migrations returns the migrations that apply to the configuration, in order
+func (n *Neo4JClient) migrations() []schemaMigration {
+	migrations := []schemaMigration{
+		{
+			// Wallets and contracts used to be unique by address alone
+			name: "network_scoped_identity",
+			statements: []string{
+				"DROP CONSTRAINT wallet_address IF EXISTS",
+				fmt.Sprintf(normalizeNetworkStatement, "Wallet"),
+				fmt.Sprintf(normalizeNetworkStatement, "ERC20Contract"),
+			},
+		},
+		{
+			// Native transfers used to be ETH_TRANSFER edges without an asset address
+			name: "native_asset_transfers",
+			statements: []string{`
+				MATCH (from)-[old:` + entity.LegacyNativeTransferType + `]->(to)
+				WITH from, old, to LIMIT 10000
+				CREATE (from)-[r:NATIVE_TRANSFER]->(to)
+				SET r = properties(old),
+					r.contract_address = $native_asset,
+					r.interaction_type = "NATIVE_TRANSFER"
+				DELETE old
+				RETURN count(r)
+			`},
+		},
+		{
+			// Wallets and contracts active on several networks used to share one node, whose
+			// network was overwritten; it is split along the networks of its relationships
+			name: "split_cross_network_nodes",
+			statements: append(
+				splitNetworkMigration("Wallet", "wallet_address_lookup", `
+					split.first_seen = coalesce(r.first_tx, n.first_seen),
+					split.last_seen = coalesce(r.last_tx, n.last_seen),
+					split.total_transactions = 0,
+					split.total_sent = "0",
+					split.total_received = "0"`),
+				splitNetworkMigration("ERC20Contract", "erc20_contract_address_lookup", `
+					split.name = n.name,
+					split.symbol = n.symbol,
+					split.decimals = n.decimals,
+					split.first_seen = coalesce(r.first_tx, n.first_seen),
+					split.last_seen = coalesce(r.last_tx, n.last_seen),
+					split.total_txs = 0`)...,
+			),
+		},
+		{
+			// Relationships used to keep every transaction in an unbounded tx_details list
+			// ("hash:value:timestamp[:interaction_type:method_signature]"); each entry becomes a
+			// TxEvent node on the network of the wallet, a bounded chunk per relationship and
+			// round. Every relationship starts at a wallet, so they are paged by sender address.
+			name: "edge_history_events",
+			statements: []string{
+				"CREATE INDEX wallet_address_lookup IF NOT EXISTS FOR (w:Wallet) ON (w.address)",
+				"CALL db.awaitIndexes(600)",
+				edgeHistoryEventsStatement,
+			},
+		},
+	}
+
+	if n.config.LinkSameAddress {
+		migrations = append(migrations, schemaMigration{
+			// Links wallets created on several networks before linking was enabled
+			name: "same_address_links",
+			statements: []string{`
+				MATCH (w:Wallet)
+				MATCH (other:Wallet {address: w.address})
+				WHERE other.network > w.network AND NOT (w)-[:SAME_ADDRESS_AS]-(other)
+				WITH w, other LIMIT 10000
+				MERGE (w)-[:SAME_ADDRESS_AS]-(other)
+				RETURN count(*)
+			`},
+		})
+	}
+
+	return migrations
+}
+
+// runMigrations applies the migrations that have not been recorded as applied yet
+func (n *Neo4JClient) runMigrations(ctx context.Context, session neo4j.SessionWithContext) error {
+	for _, migration := range n.migrations() {
+		applied, err := n.migrationApplied(ctx, session, migration.name)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		n.logger.Info("Applying schema migration", zap.String("migration", migration.name))
+
+		for _, statement := range migration.statements {
+			if err := n.runBatched(ctx, session, statement); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", migration.name, err)
+			}
+		}
+
+		_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			return tx.Run(ctx, `
+				MERGE (m:SchemaMigration {name: $name})
+				SET m.applied_at = datetime()
+			`, map[string]interface{}{"name": migration.name})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.name, err)
+		}
+
+		n.logger.Info("Applied schema migration", zap.String("migration", migration.name))
+	}
+
+	return nil
+}
+
+// migrationApplied reports whether a migration has been recorded as applied
+func (n *Neo4JClient) migrationApplied(ctx context.Context, session neo4j.SessionWithContext, name string) (bool, error) {
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, "MATCH (m:SchemaMigration {name: $name}) RETURN count(m)", map[string]interface{}{"name": name})
+		if err != nil {
+			return nil, err
+		}
+		record, err := records.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[0].(int64) > 0, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration %s: %w", name, err)
+	}
+
+	return result.(bool), nil
+}
+
+// runBatched repeats a statement until it reports that it changed no rows
+func (n *Neo4JClient) runBatched(ctx context.Context, session neo4j.SessionWithContext, statement string) error {
+	params := map[string]interface{}{
+		"default_network":  entity.DefaultNetwork,
+		"native_asset":     entity.NativeAssetAddress,
+		"aggregated_types": entity.AggregatedRelationshipTypes,
+		"after":            "",
+	}
+
+	for {
+		result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			records, err := tx.Run(ctx, statement, params)
+			if err != nil {
+				return nil, err
+			}
+			if !records.Next(ctx) {
+				return int64(0), records.Err()
+			}
+			record := records.Record()
+			if cursor, ok := record.Get("cursor"); ok {
+				return cursor, nil
+			}
+			changed, _ := record.Values[0].(int64)
+			return changed, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		switch value := result.(type) {
+		case int64:
+			if value > 0 {
+				n.logger.Debug("Migrated batch", zap.Int64("rows", value))
+				continue
+			}
+		case nil:
+		default:
+			n.logger.Debug("Migrated page", zap.Any("cursor", value))
+			params["after"] = value
+			continue
+		}
+		return nil
+	}
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// holdingsLock merges the HOLDS relationship of each changed balance, write-locks it for the
+// rest of the transaction and returns its current balance, so balances can be updated exactly
+// in Go. The token node is not written, so batches touching the same token only wait for each
+// other on shared holders.
+const holdingsLock = `
+	UNWIND $holdings as h
+	MATCH (w:Wallet {network: h.network, address: h.holder})
+	MATCH (c:ERC20Contract {network: h.network, address: h.contract_address})
+	MERGE (w)-[r:HOLDS {contract_address: h.contract_address}]->(c)
+	ON CREATE SET
+		r.balance = "0",
+		r.network = h.network
+	SET r._lock = true
+	RETURN h.key, elementId(r), r.balance
+`
+
+// holdingsUpdate writes the new balances; emptied holdings are deleted
+const holdingsUpdate = `
+	UNWIND $holdings as h
+	MATCH ()-[r]->()
+	WHERE elementId(r) = h.id
+	SET r.balance = h.balance
+	REMOVE r._lock
+	WITH r
+	WHERE r.balance = "0"
+	DELETE r
+`
+
+// holdingDelta is the change of one wallet's balance of a token
+type holdingDelta struct {
+	network  string
+	holder   string
+	contract string
+	amount   *big.Int
+}
+
+// holdingDeltas sums the balance changes of a batch of token transfers per holding
+type holdingDeltas struct {
+	order []string
+	byKey map[string]*holdingDelta
+}
+
+// newHoldingDeltas creates an empty set of balance changes
+func newHoldingDeltas() *holdingDeltas {
+	return &holdingDeltas{byKey: make(map[string]*holdingDelta)}
+}
+
+// addTransfer moves value from one holder to another; a negative value reverts a transfer
+func (d *holdingDeltas) addTransfer(network, from, to, contract string, value *big.Int) {
+	d.add(network, from, contract, new(big.Int).Neg(value))
+	d.add(network, to, contract, value)
+}
+
+// add changes the balance of a holder; mint and burn addresses are not holders
+func (d *holdingDeltas) add(network, holder, contract string, amount *big.Int) {
+	if !entity.IsHolderAddress(holder) || contract == "" {
+		return
+	}
+
+	network = entity.NormalizeNetwork(network)
+	key := network + "|" + holder + "|" + contract
+	delta, ok := d.byKey[key]
+	if !ok {
+		delta = &holdingDelta{network: network, holder: holder, contract: contract, amount: new(big.Int)}
+		d.byKey[key] = delta
+		d.order = append(d.order, key)
+	}
+	delta.amount.Add(delta.amount, amount)
+}
+
+// applyHoldingDeltas adds balance changes to the HOLDS relationships of their holders. The
+// holdings are locked in key order, so concurrent batches sharing holders cannot deadlock.
+// Balances are exact and may go negative while the history of a token is only partly indexed.
+func applyHoldingDeltas(ctx context.Context, tx neo4j.ManagedTransaction, deltas *holdingDeltas) error {
+	keys := make([]string, 0, len(deltas.order))
+	for _, key := range deltas.order {
+		if deltas.byKey[key].amount.Sign() != 0 {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	holdings := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		delta := deltas.byKey[key]
+		holdings = append(holdings, map[string]interface{}{
+			"key":              key,
+			"network":          delta.network,
+			"holder":           delta.holder,
+			"contract_address": delta.contract,
+		})
+	}
+
+	records, err := tx.Run(ctx, holdingsLock, map[string]interface{}{"holdings": holdings})
+	if err != nil {
+		return fmt.Errorf("failed to lock token holdings: %w", err)
+	}
+	rows, err := records.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to lock token holdings: %w", err)
+	}
+
+	balances := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		delta := deltas.byKey[stringValue(row.Values[0])]
+		if delta == nil {
+			continue
+		}
+
+		previous, err := entity.ParseBalance(stringValue(row.Values[2]))
+		if err != nil {
+			return fmt.Errorf("failed to parse holding balance: %w", err)
+		}
+		balances = append(balances, map[string]interface{}{
+			"id":      stringValue(row.Values[1]),
+			"balance": previous.Add(previous, delta.amount).String(),
+		})
+	}
+	if len(balances) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Run(ctx, holdingsUpdate, map[string]interface{}{"holdings": balances}); err != nil {
+		return fmt.Errorf("failed to update token holdings: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokenBubbleMap returns the topN holders of a token on a network by balance, their share of
+// its indexed supply and the aggregated transfers among them. The indexed supply and holder count
+// are derived from the positive balances when the map is read.
+func (r *Neo4JERC20Repository) GetTokenBubbleMap(ctx context.Context, network, token string, topN int) (*entity.TokenBubbleMap, error) {
+	if topN <= 0 {
+		topN = entity.DefaultBubbleMapHolders
+	}
+	token = strings.ToLower(strings.TrimSpace(token))
+
+	// Balances are exact decimal strings: the longer one is larger, equal lengths compare lexically
+	holdersQuery := `
+		MATCH (c:ERC20Contract {network: $network, address: $token})
+		CALL {
+			WITH c
+			MATCH ()-[h:HOLDS]->(c)
+			WHERE h.balance <> "0" AND NOT h.balance STARTS WITH "-"
+			RETURN collect(h.balance) as balances
+		}
+		CALL {
+			WITH c
+			MATCH (w:Wallet)-[h:HOLDS]->(c)
+			WHERE h.balance <> "0" AND NOT h.balance STARTS WITH "-"
+			WITH w, h
+			ORDER BY size(h.balance) DESC, h.balance DESC
+			LIMIT $top
+			RETURN collect([w.address, w.node_type, h.balance]) as holders
+		}
+		RETURN c.network, c.symbol, c.decimals, balances, holders
+	`
+
+	linksQuery := `
+		UNWIND $holders as holder
+		MATCH (a:Wallet {network: $network, address: holder})-[r:ERC20_TRANSFER {contract_address: $token}]->(b:Wallet)
+		WHERE b.address IN $holders AND b <> a
+		RETURN a.address, b.address, r.total_value, r.tx_count
+		ORDER BY size(r.total_value) DESC, r.total_value DESC
+	`
+
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, holdersQuery, map[string]interface{}{
+			"network": entity.NormalizeNetwork(network),
+			"token":   token,
+			"top":     topN,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows, err := records.Collect(ctx)
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+		row := rows[0]
+
+		supply, err := sumAmounts(row.Values[3])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum token supply: %w", err)
+		}
+		balances, _ := row.Values[3].([]interface{})
+		bubbleMap := &entity.TokenBubbleMap{
+			Network:     stringValue(row.Values[0]),
+			Token:       token,
+			Symbol:      stringValue(row.Values[1]),
+			Supply:      supply,
+			HolderCount: int64(len(balances)),
+			Holders:     []*entity.TokenHolder{},
+			Links:       []*entity.TokenHolderLink{},
+		}
+		if decimals, ok := row.Values[2].(int64); ok {
+			bubbleMap.Decimals = int(decimals)
+		}
+
+		list, _ := row.Values[4].([]interface{})
+		addresses := make([]string, 0, len(list))
+		for _, raw := range list {
+			values, _ := raw.([]interface{})
+			if len(values) != 3 {
+				continue
+			}
+			holder := &entity.TokenHolder{
+				Address:  stringValue(values[0]),
+				NodeType: stringValue(values[1]),
+				Balance:  stringValue(values[2]),
+			}
+			holder.Percentage = entity.SupplyPercentage(holder.Balance, bubbleMap.Supply)
+			bubbleMap.Holders = append(bubbleMap.Holders, holder)
+			addresses = append(addresses, holder.Address)
+		}
+		if len(addresses) < 2 {
+			return bubbleMap, nil
+		}
+
+		records, err = tx.Run(ctx, linksQuery, map[string]interface{}{
+			"network": bubbleMap.Network,
+			"token":   token,
+			"holders": addresses,
+		})
+		if err != nil {
+			return nil, err
+		}
+		links, err := records.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			txCount, _ := link.Values[3].(int64)
+			bubbleMap.Links = append(bubbleMap.Links, &entity.TokenHolderLink{
+				From:       stringValue(link.Values[0]),
+				To:         stringValue(link.Values[1]),
+				TotalValue: stringValue(link.Values[2]),
+				TxCount:    txCount,
+			})
+		}
+		return bubbleMap, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token bubble map: %w", err)
+	}
+
+	bubbleMap, _ := result.(*entity.TokenBubbleMap)
+	if bubbleMap == nil {
+		return nil, fmt.Errorf("ERC20 contract not found: %s", token)
+	}
+	return bubbleMap, nil
+}
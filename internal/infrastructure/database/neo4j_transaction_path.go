@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultPathTxLimit is the number of transaction hashes returned per hop when unset
+	defaultPathTxLimit = 10
+	// causalCandidatePage is the number of candidate paths a causal search reads at a time,
+	// since the aggregates can only rule out part of the non-causal paths
+	causalCandidatePage = 50
+)
+
+// shortestPathLength returns the length of the shortest path between the wallets, null if
+// they are not connected; the bidirectional search is cheap compared to enumerating paths
+const shortestPathLength = `
+	MATCH (a:Wallet {address: $from})
+	WHERE $network IS NULL OR a.network = $network
+	MATCH (b:Wallet {network: a.network, address: $to})
+	MATCH p = shortestPath((a)-[:%s*1..%d]->(b))
+	WHERE $token IS NULL OR all(r IN relationships(p) WHERE r.contract_address = $token)
+	RETURN min(length(p))
+`
+
+// pathSearch pages through the simple paths of one length; the relationship types and the
+// length are validated and formatted in, since Cypher cannot take them as parameters. The
+// causal condition on the aggregates is necessary but not sufficient; the transactions
+// decide.
+const pathSearch = `
+	MATCH (a:Wallet {address: $from})
+	WHERE $network IS NULL OR a.network = $network
+	MATCH (b:Wallet {network: a.network, address: $to})
+	MATCH p = (a)-[:%s*%d]->(b)
+	WITH a, p, relationships(p) as rels
+	WHERE all(n IN nodes(p) WHERE single(m IN nodes(p) WHERE m = n))
+		AND ($token IS NULL OR all(r IN rels WHERE r.contract_address = $token))
+		AND (NOT $causal OR all(i IN range(0, size(rels) - 2) WHERE rels[i + 1].last_tx >= rels[i].first_tx))
+	RETURN a.network, [n IN nodes(p) | n.address],
+		[r IN rels | [type(r), r.contract_address, r.total_value, r.tx_count, r.first_tx, r.last_tx, r.edge_key]]
+	SKIP $skip
+	LIMIT $limit
+`
+
+// hopTransactionsLatest returns the latest transactions of a hop
+const hopTransactionsLatest = `
+	MATCH (t:TxEvent {edge_key: $edge_key})
+	RETURN t.hash, t.timestamp
+	ORDER BY t.timestamp DESC
+	LIMIT $limit
+`
+
+// hopTransactionsAfter returns the earliest transactions of a hop at or after a point in time
+const hopTransactionsAfter = `
+	MATCH (t:TxEvent {edge_key: $edge_key})
+	WHERE $after IS NULL OR t.timestamp >= datetime($after)
+	RETURN t.hash, t.timestamp
+	ORDER BY t.timestamp
+	LIMIT $limit
+`
+
+// GetTransactionPath finds the k shortest value-flow paths between two wallets over the
+// relationship types of the query, with the aggregates and transactions of every hop. Paths
+// are searched by increasing length from the shortest one, so longer paths are only expanded
+// while fewer than k were found; candidates of a length are paged until k paths are found or
+// they run out.
+func (r *Neo4JTransactionRepository) GetTransactionPath(ctx context.Context, query *entity.PathQuery) ([]*entity.TransactionPath, error) {
+	maxHops := query.MaxHops
+	if maxHops < 1 {
+		maxHops = 1
+	}
+	if maxHops > entity.MaxPathHops {
+		maxHops = entity.MaxPathHops
+	}
+	k := query.K
+	if k < 1 {
+		k = 1
+	}
+	txLimit := query.TxLimit
+	if txLimit < 1 {
+		txLimit = defaultPathTxLimit
+	}
+
+	// A simple path never returns to its start
+	if strings.EqualFold(query.From, query.To) {
+		return nil, nil
+	}
+
+	parameters := map[string]interface{}{
+		"from":    query.From,
+		"to":      query.To,
+		"network": nil,
+		"token":   nil,
+		"causal":  query.Causal,
+	}
+	if query.Network != "" {
+		parameters["network"] = entity.NormalizeNetwork(query.Network)
+	}
+	if token := query.TokenAddress(); token != "" {
+		parameters["token"] = token
+	}
+	relTypes := strings.Join(query.RelationshipTypes(), "|")
+
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, fmt.Sprintf(shortestPathLength, relTypes, maxHops), parameters)
+		if err != nil {
+			return nil, err
+		}
+		record, err := records.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		shortest, ok := record.Values[0].(int64)
+		if !ok {
+			return []*entity.TransactionPath(nil), nil
+		}
+
+		var paths []*entity.TransactionPath
+		for length := int(shortest); length <= maxHops && len(paths) < k; length++ {
+			cypher := fmt.Sprintf(pathSearch, relTypes, length)
+
+			// Pages are read in one transaction, so the candidates come in the same order
+			for skip := 0; len(paths) < k; {
+				limit := k - len(paths)
+				if query.Causal {
+					limit = max(limit, causalCandidatePage)
+				}
+				parameters["skip"] = skip
+				parameters["limit"] = limit
+
+				records, err := tx.Run(ctx, cypher, parameters)
+				if err != nil {
+					return nil, err
+				}
+				rows, err := records.Collect(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, row := range rows {
+					if len(paths) == k {
+						break
+					}
+
+					path, edgeKeys := pathFromRecord(row)
+					ok, err := r.loadHopTransactions(ctx, tx, path, edgeKeys, query.Causal, txLimit)
+					if err != nil {
+						return nil, err
+					}
+					if ok {
+						paths = append(paths, path)
+					}
+				}
+
+				if len(rows) < limit {
+					break
+				}
+				skip += len(rows)
+			}
+		}
+		return paths, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction path: %w", err)
+	}
+
+	paths := result.([]*entity.TransactionPath)
+	r.logger.Debug("Found transaction paths",
+		zap.String("from", query.From),
+		zap.String("to", query.To),
+		zap.Int("max_hops", maxHops),
+		zap.Bool("causal", query.Causal),
+		zap.Int("paths", len(paths)))
+
+	return paths, nil
+}
+
+// pathFromRecord builds a path from the network, node addresses and relationship aggregates
+// returned by pathSearch, and returns the edge keys of its hops
+func pathFromRecord(record *neo4j.Record) (*entity.TransactionPath, []string) {
+	path := &entity.TransactionPath{Network: stringValue(record.Values[0])}
+	var edgeKeys []string
+	addresses, _ := record.Values[1].([]interface{})
+	rels, _ := record.Values[2].([]interface{})
+
+	for i, raw := range rels {
+		fields, _ := raw.([]interface{})
+		if len(fields) != 7 || i+1 >= len(addresses) {
+			continue
+		}
+		hop := &entity.PathHop{
+			From:            stringValue(addresses[i]),
+			To:              stringValue(addresses[i+1]),
+			RelType:         stringValue(fields[0]),
+			ContractAddress: stringValue(fields[1]),
+			TotalValue:      stringValue(fields[2]),
+		}
+		hop.TxCount, _ = fields[3].(int64)
+		hop.FirstTx, _ = fields[4].(time.Time)
+		hop.LastTx, _ = fields[5].(time.Time)
+		path.Hops = append(path.Hops, hop)
+		edgeKeys = append(edgeKeys, stringValue(fields[6]))
+	}
+	return path, edgeKeys
+}
+
+// loadHopTransactions loads the transaction hashes of every hop by its edge key. For a
+// causal path each hop lists its earliest transactions at or after the earliest usable
+// transaction of the hop before; it reports false when a hop has none, i.e. the path is not
+// causal.
+func (r *Neo4JTransactionRepository) loadHopTransactions(ctx context.Context, tx neo4j.ManagedTransaction, path *entity.TransactionPath, edgeKeys []string, causal bool, limit int) (bool, error) {
+	var after interface{}
+	for i, hop := range path.Hops {
+		query := hopTransactionsLatest
+		parameters := map[string]interface{}{"edge_key": edgeKeys[i], "limit": limit}
+		if causal {
+			query = hopTransactionsAfter
+			parameters["after"] = after
+		}
+
+		records, err := tx.Run(ctx, query, parameters)
+		if err != nil {
+			return false, fmt.Errorf("failed to read hop transactions: %w", err)
+		}
+		rows, err := records.Collect(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read hop transactions: %w", err)
+		}
+
+		for _, row := range rows {
+			hop.TxHashes = append(hop.TxHashes, stringValue(row.Values[0]))
+		}
+		if !causal {
+			continue
+		}
+		if len(rows) == 0 {
+			return false, nil
+		}
+		earliest, _ := rows[0].Values[1].(time.Time)
+		after = earliest.UTC().Format(edgeTimestampLayout)
+	}
+	return true, nil
+}
@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"crypto-bubble-map-indexer/internal/domain/entity"
 	"crypto-bubble-map-indexer/internal/domain/repository"
@@ -33,51 +35,9 @@ func (r *Neo4JTransactionRepository) CreateTransaction(ctx context.Context, tx *
 
 // CreateTransactionRelationship creates a direct relationship between wallets
 func (r *Neo4JTransactionRepository) CreateTransactionRelationship(ctx context.Context, rel *entity.TransactionRelationship) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
-
-	query := `
-		MATCH (from:Wallet {address: $from_address})
-		MATCH (to:Wallet {address: $to_address})
-		MERGE (from)-[r:SENT_TO]->(to)
-		ON CREATE SET
-			r.total_value = $value,
-			r.tx_count = 1,
-			r.first_tx = $timestamp,
-			r.last_tx = $timestamp,
-			r.tx_details = [$tx_detail]
-		ON MATCH SET
-			r.total_value = toString(toFloat(r.total_value) + toFloat($value)),
-			r.tx_count = r.tx_count + 1,
-			r.last_tx = $timestamp,
-			r.tx_details = CASE
-				WHEN r.tx_details IS NULL THEN [$tx_detail]
-				ELSE r.tx_details + $tx_detail
-			END
-	`
-
-	// Create a tx_detail string in format "hash:value:timestamp"
-	txDetail := fmt.Sprintf("%s:%s:%s",
-		rel.TxHash,
-		rel.Value,
-		rel.Timestamp.Format("2006-01-02T15:04:05.000Z"))
-
-	params := map[string]interface{}{
-		"from_address": rel.FromAddress,
-		"to_address":   rel.ToAddress,
-		"value":        rel.Value,
-		"timestamp":    rel.Timestamp,
-		"tx_detail":    txDetail,
-	}
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, params)
-	})
-
-	if err != nil {
+	if err := r.BatchCreateRelationships(ctx, []*entity.TransactionRelationship{rel}); err != nil {
 		return fmt.Errorf("failed to create transaction relationship: %w", err)
 	}
-
 	return nil
 }
 
@@ -86,22 +46,81 @@ func (r *Neo4JTransactionRepository) GetTransaction(ctx context.Context, hash st
 	return nil, fmt.Errorf("transaction nodes no longer supported")
 }
 
-// GetTransactionPath finds the path between two wallets
-func (r *Neo4JTransactionRepository) GetTransactionPath(ctx context.Context, fromAddress, toAddress string, maxHops int) ([]*entity.TransactionNode, error) {
-	// This now returns an empty result since we're not tracking Transaction nodes anymore
-	return []*entity.TransactionNode{}, nil
-}
-
-// GetTransactionsByWallet retrieves transactions for a specific wallet - now returns empty result
+// GetTransactionsByWallet retrieves the latest transactions sent or received by a wallet from
+// the TxEvent nodes of its SENT_TO relationships; block numbers come from the processed markers
 func (r *Neo4JTransactionRepository) GetTransactionsByWallet(ctx context.Context, address string, limit int) ([]*entity.TransactionNode, error) {
-	// This now returns an empty result since we're not tracking Transaction nodes anymore
-	return []*entity.TransactionNode{}, nil
+	query := `
+		MATCH (w:Wallet {address: $address})-[r:SENT_TO]-()
+		MATCH (t:TxEvent {edge_key: r.edge_key})
+		WITH t ORDER BY t.timestamp DESC LIMIT $limit
+	` + transactionNodeReturn
+
+	transactions, err := r.transactionNodes(ctx, query, map[string]interface{}{
+		"address": address,
+		"limit":   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions by wallet: %w", err)
+	}
+	return transactions, nil
 }
 
-// GetTransactionsByTimeRange retrieves transactions within a time range - now returns empty result
+// GetTransactionsByTimeRange retrieves the transactions of [startTime, endTime), oldest first
 func (r *Neo4JTransactionRepository) GetTransactionsByTimeRange(ctx context.Context, startTime, endTime string, limit int) ([]*entity.TransactionNode, error) {
-	// This now returns an empty result since we're not tracking Transaction nodes anymore
-	return []*entity.TransactionNode{}, nil
+	query := `
+		MATCH (t:TxEvent {rel_type: "SENT_TO"})
+		WHERE t.timestamp >= datetime($start_time) AND t.timestamp < datetime($end_time)
+		WITH t ORDER BY t.timestamp LIMIT $limit
+	` + transactionNodeReturn
+
+	transactions, err := r.transactionNodes(ctx, query, map[string]interface{}{
+		"start_time": startTime,
+		"end_time":   endTime,
+		"limit":      limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions by time range: %w", err)
+	}
+	return transactions, nil
+}
+
+// transactionNodeReturn completes a query that selects SENT_TO TxEvent nodes as t, in order
+const transactionNodeReturn = `
+	OPTIONAL MATCH (p:ProcessedTransaction {key: toLower(t.network) + ":" + toLower(t.hash)})
+	RETURN t.hash, t.value, t.timestamp, t.network, p.block_number
+`
+
+// transactionNodes runs a query completed by transactionNodeReturn
+func (r *Neo4JTransactionRepository) transactionNodes(ctx context.Context, query string, parameters map[string]interface{}) ([]*entity.TransactionNode, error) {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return records.Collect(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []*entity.TransactionNode
+	for _, record := range result.([]*neo4j.Record) {
+		values := record.Values
+		transaction := &entity.TransactionNode{
+			Hash:    stringValue(values[0]),
+			Value:   stringValue(values[1]),
+			Network: stringValue(values[3]),
+		}
+		transaction.Timestamp, _ = values[2].(time.Time)
+		if blockNumber, ok := values[4].(int64); ok {
+			transaction.BlockNumber = strconv.FormatInt(blockNumber, 10)
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
 }
 
 // BatchCreateTransactions creates multiple transactions in a batch - deprecated, not creating Transaction nodes anymore
@@ -110,51 +129,38 @@ func (r *Neo4JTransactionRepository) BatchCreateTransactions(ctx context.Context
 	return nil
 }
 
-// BatchCreateRelationships creates multiple relationships in a batch
+// BatchCreateRelationships creates multiple relationships in a batch. Totals are summed
+// exactly in Go; the relationships are locked while they are read and rewritten.
 func (r *Neo4JTransactionRepository) BatchCreateRelationships(ctx context.Context, relationships []*entity.TransactionRelationship) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
+	if len(relationships) == 0 {
+		return nil
+	}
 
 	query := `
-		UNWIND $relationships as rel
-		MATCH (from:Wallet {address: rel.from_address})
-		MATCH (to:Wallet {address: rel.to_address})
+		UNWIND $edges as e
+		MATCH (from:Wallet {network: e.network, address: e.from_address})
+		MATCH (to:Wallet {network: e.network, address: e.to_address})
 		MERGE (from)-[r:SENT_TO]->(to)
-		ON CREATE SET
-			r.total_value = rel.value,
-			r.tx_count = 1,
-			r.first_tx = datetime(rel.timestamp),
-			r.last_tx = datetime(rel.timestamp),
-			r.tx_details = [rel.tx_detail]
-		ON MATCH SET
-			r.total_value = toString(toFloat(r.total_value) + toFloat(rel.value)),
-			r.tx_count = r.tx_count + 1,
-			r.last_tx = datetime(rel.timestamp),
-			r.tx_details = CASE
-				WHEN r.tx_details IS NULL THEN [rel.tx_detail]
-				ELSE r.tx_details + rel.tx_detail
-			END
-	`
-
-	var relData []map[string]interface{}
-	for _, rel := range relationships {
-		// Format the timestamp as ISO-8601 string for Neo4J
-		timestampStr := rel.Timestamp.Format("2006-01-02T15:04:05.000Z")
+	` + edgeMergeSuffix
 
-		// Create a simple tx_detail string
-		txDetail := fmt.Sprintf("%s:%s:%s", rel.TxHash, rel.Value, timestampStr)
-
-		relData = append(relData, map[string]interface{}{
+	agg := newEdgeAggregator()
+	for _, rel := range relationships {
+		err := agg.add(rel.Edge(), map[string]interface{}{
 			"from_address": rel.FromAddress,
 			"to_address":   rel.ToAddress,
-			"value":        rel.Value,
-			"timestamp":    timestampStr,
-			"tx_detail":    txDetail,
+			"network":      entity.NormalizeNetwork(rel.Network),
+		}, &entity.EdgeEvent{
+			TxHash:    rel.TxHash,
+			Value:     rel.Value,
+			Timestamp: rel.Timestamp,
 		})
+		if err != nil {
+			return err
+		}
 	}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, map[string]interface{}{"relationships": relData})
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return nil, upsertEdgeTotals(ctx, tx, query, agg, false, r.client.config.RollupBucket)
 	})
 
 	if err != nil {
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+
+	"crypto-bubble-map-indexer/internal/domain/repository"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// unitOfWorkKey is the context key of the transaction bound by a unit of work
+type unitOfWorkKey struct{}
+
+// Neo4JUnitOfWork implements UnitOfWork by binding one Neo4J write transaction to the context
+type Neo4JUnitOfWork struct {
+	client *Neo4JClient
+	logger *logger.Logger
+}
+
+// NewNeo4JUnitOfWork creates a new Neo4J unit of work
+func NewNeo4JUnitOfWork(client *Neo4JClient, logger *logger.Logger) repository.UnitOfWork {
+	return &Neo4JUnitOfWork{
+		client: client,
+		logger: logger.WithComponent("neo4j-unit-of-work"),
+	}
+}
+
+// Do runs fn in a single write transaction shared by the repositories; the whole transaction
+// is retried when it fails with a transient error
+func (u *Neo4JUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(unitOfWorkKey{}).(neo4j.ManagedTransaction); ok {
+		return fn(ctx)
+	}
+
+	session := u.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	return u.client.retry.run(ctx, func() error {
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			return nil, fn(context.WithValue(ctx, unitOfWorkKey{}, tx))
+		})
+		return err
+	})
+}
+
+// executeWrite runs work in the transaction of the unit of work bound to ctx, or in a
+// write transaction of its own, retried on transient errors, outside a unit of work
+func (n *Neo4JClient) executeWrite(ctx context.Context, work neo4j.ManagedTransactionWork) (any, error) {
+	if tx, ok := ctx.Value(unitOfWorkKey{}).(neo4j.ManagedTransaction); ok {
+		return work(tx)
+	}
+
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	var result any
+	err := n.retry.run(ctx, func() error {
+		var err error
+		result, err = session.ExecuteWrite(ctx, work)
+		return err
+	})
+	return result, err
+}
+
+// executeRead runs work in the transaction of the unit of work bound to ctx, so the read sees
+// and is isolated with the writes it guards, or in a read transaction of its own outside one
+func (n *Neo4JClient) executeRead(ctx context.Context, work neo4j.ManagedTransactionWork) (any, error) {
+	if tx, ok := ctx.Value(unitOfWorkKey{}).(neo4j.ManagedTransaction); ok {
+		return work(tx)
+	}
+
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	return session.ExecuteRead(ctx, work)
+}
@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"sort"
 	"time"
 
 	"crypto-bubble-map-indexer/internal/domain/entity"
@@ -26,64 +28,208 @@ func NewNeo4JWalletRepository(client *Neo4JClient, logger *logger.Logger) reposi
 	}
 }
 
-// CreateOrUpdateWallet creates a new wallet or updates existing one
+// CreateOrUpdateWallet creates a new wallet or adds the wallet's counters to the existing one
 func (r *Neo4JWalletRepository) CreateOrUpdateWallet(ctx context.Context, wallet *entity.Wallet) error {
-	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
+	return r.BatchCreateOrUpdateWallets(ctx, []*entity.Wallet{wallet})
+}
 
-	query := `
-		MERGE (w:Wallet {address: $address})
+// BatchCreateOrUpdateWallets upserts the wallets of a batch. Each wallet carries the batch's
+// deltas: transaction count and sent/received amounts are added to the stored counters,
+// first_seen only moves back and last_seen only moves forward. The wallets are locked while
+// their totals are read, so the amounts are summed exactly in Go.
+func (r *Neo4JWalletRepository) BatchCreateOrUpdateWallets(ctx context.Context, wallets []*entity.Wallet) error {
+	if len(wallets) == 0 {
+		return nil
+	}
+
+	type walletDelta struct {
+		network      string
+		address      string
+		firstSeen    time.Time
+		lastSeen     time.Time
+		transactions int64
+		sent         *big.Int
+		received     *big.Int
+	}
+
+	// Merge duplicates so each wallet is written once
+	var keys []string
+	deltas := make(map[string]*walletDelta)
+	for _, wallet := range wallets {
+		key := entity.NetworkScopedKey(wallet.Network, wallet.Address)
+		d, ok := deltas[key]
+		if !ok {
+			d = &walletDelta{
+				network:   entity.NormalizeNetwork(wallet.Network),
+				address:   wallet.Address,
+				firstSeen: wallet.FirstSeen,
+				lastSeen:  wallet.LastSeen,
+				sent:      new(big.Int),
+				received:  new(big.Int),
+			}
+			deltas[key] = d
+			keys = append(keys, key)
+		}
+		if wallet.FirstSeen.Before(d.firstSeen) {
+			d.firstSeen = wallet.FirstSeen
+		}
+		if wallet.LastSeen.After(d.lastSeen) {
+			d.lastSeen = wallet.LastSeen
+		}
+		sent, err := entity.ParseTotal(wallet.TotalSent)
+		if err != nil {
+			return fmt.Errorf("failed to parse total sent of wallet %s: %w", wallet.Address, err)
+		}
+		received, err := entity.ParseTotal(wallet.TotalReceived)
+		if err != nil {
+			return fmt.Errorf("failed to parse total received of wallet %s: %w", wallet.Address, err)
+		}
+		d.transactions += wallet.TotalTransactions
+		d.sent.Add(d.sent, sent)
+		d.received.Add(d.received, received)
+	}
+
+	// Lock the wallets in a global order, so concurrent batches sharing wallets wait for each
+	// other instead of deadlocking
+	sort.Strings(keys)
+
+	merge := `
+		UNWIND $wallets as u
+		MERGE (w:Wallet {network: u.network, address: u.address})
 		ON CREATE SET
-			w.first_seen = datetime($first_seen),
-			w.last_seen = datetime($last_seen),
-			w.total_transactions = $total_transactions,
-			w.total_sent = $total_sent,
-			w.total_received = $total_received,
-			w.network = $network
-		ON MATCH SET
-			w.last_seen = datetime($last_seen),
-			w.total_transactions = $total_transactions,
-			w.total_sent = $total_sent,
-			w.total_received = $total_received
+			w.first_seen = datetime(u.first_seen),
+			w.last_seen = datetime(u.last_seen),
+			w.total_transactions = 0,
+			w.total_sent = "0",
+			w.total_received = "0"
+		SET w._lock = true
+		RETURN u.key, elementId(w), w.total_sent, w.total_received
+	`
+
+	update := `
+		UNWIND $wallets as u
+		MATCH (w:Wallet)
+		WHERE elementId(w) = u.id
+		SET w.total_transactions = coalesce(w.total_transactions, 0) + u.total_transactions,
+			w.total_sent = u.total_sent,
+			w.total_received = u.total_received,
+			w.first_seen = CASE WHEN w.first_seen IS NULL OR datetime(u.first_seen) < w.first_seen THEN datetime(u.first_seen) ELSE w.first_seen END,
+			w.last_seen = CASE WHEN w.last_seen IS NULL OR datetime(u.last_seen) > w.last_seen THEN datetime(u.last_seen) ELSE w.last_seen END
+		REMOVE w._lock
 	`
 
-	// Format the timestamp as ISO-8601 string for Neo4J
-	firstSeenStr := wallet.FirstSeen.Format("2006-01-02T15:04:05.000Z")
-	lastSeenStr := wallet.LastSeen.Format("2006-01-02T15:04:05.000Z")
+	params := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		d := deltas[key]
+		params = append(params, map[string]interface{}{
+			"key":     key,
+			"network": d.network,
+			"address": d.address,
+			// Format the timestamp as ISO-8601 string for Neo4J
+			"first_seen": d.firstSeen.Format("2006-01-02T15:04:05.000Z"),
+			"last_seen":  d.lastSeen.Format("2006-01-02T15:04:05.000Z"),
+		})
+	}
+
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, merge, map[string]interface{}{"wallets": params})
+		if err != nil {
+			return nil, err
+		}
+		rows, err := records.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		updates := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			d := deltas[stringValue(row.Values[0])]
+			if d == nil {
+				continue
+			}
+			sent, err := entity.ParseTotal(stringValue(row.Values[2]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse wallet total sent: %w", err)
+			}
+			received, err := entity.ParseTotal(stringValue(row.Values[3]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse wallet total received: %w", err)
+			}
+			updates = append(updates, map[string]interface{}{
+				"id":                 stringValue(row.Values[1]),
+				"total_transactions": d.transactions,
+				"total_sent":         sent.Add(sent, d.sent).String(),
+				"total_received":     received.Add(received, d.received).String(),
+				"first_seen":         d.firstSeen.Format("2006-01-02T15:04:05.000Z"),
+				"last_seen":          d.lastSeen.Format("2006-01-02T15:04:05.000Z"),
+			})
+		}
+
+		return tx.Run(ctx, update, map[string]interface{}{"wallets": updates})
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to batch create/update wallets: %w", err)
+	}
+
+	return nil
+}
 
-	params := map[string]interface{}{
-		"address":            wallet.Address,
-		"first_seen":         firstSeenStr,
-		"last_seen":          lastSeenStr,
-		"total_transactions": wallet.TotalTransactions,
-		"total_sent":         wallet.TotalSent,
-		"total_received":     wallet.TotalReceived,
-		"network":            wallet.Network,
+// LinkSameAddressWallets links each wallet to the wallets sharing its address on other networks
+func (r *Neo4JWalletRepository) LinkSameAddressWallets(ctx context.Context, wallets []*entity.Wallet) error {
+	if !r.client.config.LinkSameAddress || len(wallets) == 0 {
+		return nil
 	}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, params)
+	// The undirected MERGE keeps a single link per pair whichever network is seen first
+	query := `
+		UNWIND $wallets AS k
+		MATCH (w:Wallet {network: k.network, address: k.address})
+		MATCH (other:Wallet {address: k.address})
+		WHERE other.network <> w.network
+		MERGE (w)-[:SAME_ADDRESS_AS]-(other)
+	`
+
+	seen := make(map[string]bool, len(wallets))
+	keys := make([]map[string]interface{}, 0, len(wallets))
+	for _, wallet := range wallets {
+		key := entity.NetworkScopedKey(wallet.Network, wallet.Address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, map[string]interface{}{
+			"network": entity.NormalizeNetwork(wallet.Network),
+			"address": wallet.Address,
+		})
+	}
+
+	_, err := r.client.executeWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return tx.Run(ctx, query, map[string]interface{}{"wallets": keys})
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to create/update wallet: %w", err)
+		return fmt.Errorf("failed to link same-address wallets: %w", err)
 	}
 
 	return nil
 }
 
-// GetWallet retrieves a wallet by address
-func (r *Neo4JWalletRepository) GetWallet(ctx context.Context, address string) (*entity.Wallet, error) {
+// GetWallet retrieves the wallet of an address on a network
+func (r *Neo4JWalletRepository) GetWallet(ctx context.Context, network, address string) (*entity.Wallet, error) {
 	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH (w:Wallet {address: $address})
+		MATCH (w:Wallet {network: $network, address: $address})
 		RETURN w.address, w.first_seen, w.last_seen, w.total_transactions, w.total_sent, w.total_received, w.network
 	`
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, map[string]interface{}{"address": address})
+		return tx.Run(ctx, query, map[string]interface{}{
+			"network": entity.NormalizeNetwork(network),
+			"address": address,
+		})
 	})
 
 	if err != nil {
@@ -111,25 +257,28 @@ func (r *Neo4JWalletRepository) GetWallet(ctx context.Context, address string) (
 	return wallet, nil
 }
 
-// GetWalletStats retrieves statistics for a wallet
-func (r *Neo4JWalletRepository) GetWalletStats(ctx context.Context, address string) (*entity.WalletStats, error) {
+// GetWalletStats retrieves statistics for the wallet of an address on a network
+func (r *Neo4JWalletRepository) GetWalletStats(ctx context.Context, network, address string) (*entity.WalletStats, error) {
 	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH (w:Wallet {address: $address})
+		MATCH (w:Wallet {network: $network, address: $address})
 		OPTIONAL MATCH (w)-[:SENT_TO]->(other:Wallet)
 		WITH w, count(other) as outgoing
 		OPTIONAL MATCH (other2:Wallet)-[:SENT_TO]->(w)
 		WITH w, outgoing, count(other2) as incoming
 		OPTIONAL MATCH (w)-[r:SENT_TO]->()
-		WITH w, outgoing, incoming, sum(toFloat(r.value)) as total_volume
+		WITH w, outgoing, incoming, collect(r.total_value) as volumes
 		OPTIONAL MATCH (w)-[:SENT_TO|RECEIVED_FROM]->()
-		RETURN w.address, incoming, outgoing, total_volume, count(*) as tx_count
+		RETURN w.address, incoming, outgoing, volumes, count(*) as tx_count
 	`
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		return tx.Run(ctx, query, map[string]interface{}{"address": address})
+		return tx.Run(ctx, query, map[string]interface{}{
+			"network": entity.NormalizeNetwork(network),
+			"address": address,
+		})
 	})
 
 	if err != nil {
@@ -144,32 +293,43 @@ func (r *Neo4JWalletRepository) GetWalletStats(ctx context.Context, address stri
 	record := records.Record()
 	values := record.Values
 
+	// Exact sum of the decimal edge totals
+	totalVolume, err := sumAmounts(values[3])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum wallet volume: %w", err)
+	}
+
 	stats := &entity.WalletStats{
 		Address:             values[0].(string),
 		IncomingConnections: values[1].(int64),
 		OutgoingConnections: values[2].(int64),
-		TotalVolume:         fmt.Sprintf("%.0f", values[3].(float64)),
+		TotalVolume:         totalVolume,
 		TransactionCount:    values[4].(int64),
 	}
 
 	return stats, nil
 }
 
-// GetWalletConnections retrieves connections for a wallet
-func (r *Neo4JWalletRepository) GetWalletConnections(ctx context.Context, address string, limit int) ([]*entity.WalletConnection, error) {
+// GetWalletConnections retrieves connections for a wallet; within a window they are summed
+// from the rollups of the window's buckets
+func (r *Neo4JWalletRepository) GetWalletConnections(ctx context.Context, network, address string, window *entity.TimeWindow, limit int) ([]*entity.WalletConnection, error) {
+	if !window.IsZero() {
+		return r.getWalletConnectionsInWindow(ctx, network, address, window, limit)
+	}
+
 	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH (w:Wallet {address: $address})-[r:SENT_TO]->(other:Wallet)
-		WITH w, other, sum(toFloat(r.value)) as total_value, count(r) as tx_count, min(r.timestamp) as first_tx, max(r.timestamp) as last_tx
-		RETURN w.address, other.address, total_value, tx_count, first_tx, last_tx
-		ORDER BY total_value DESC
+		MATCH (w:Wallet {network: $network, address: $address})-[r:SENT_TO]->(other:Wallet)
+		RETURN w.address, other.address, r.total_value as total_value, r.tx_count, r.first_tx, r.last_tx
+		ORDER BY size(total_value) DESC, total_value DESC
 		LIMIT $limit
 	`
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		return tx.Run(ctx, query, map[string]interface{}{
+			"network": entity.NormalizeNetwork(network),
 			"address": address,
 			"limit":   limit,
 		})
@@ -189,7 +349,7 @@ func (r *Neo4JWalletRepository) GetWalletConnections(ctx context.Context, addres
 		connection := &entity.WalletConnection{
 			FromAddress: values[0].(string),
 			ToAddress:   values[1].(string),
-			TotalValue:  fmt.Sprintf("%.0f", values[2].(float64)),
+			TotalValue:  stringValue(values[2]),
 			TxCount:     values[3].(int64),
 			FirstTx:     values[4].(time.Time),
 			LastTx:      values[5].(time.Time),
@@ -200,22 +360,91 @@ func (r *Neo4JWalletRepository) GetWalletConnections(ctx context.Context, addres
 	return connections, nil
 }
 
-// FindConnectedWallets finds wallets connected to a given wallet within specified hops
-func (r *Neo4JWalletRepository) FindConnectedWallets(ctx context.Context, address string, maxHops int) ([]*entity.Wallet, error) {
+// getWalletConnectionsInWindow sums the rollups of a wallet in a window per counterparty; the
+// totals are compared exactly, so they are ordered in Go
+func (r *Neo4JWalletRepository) getWalletConnectionsInWindow(ctx context.Context, network, address string, window *entity.TimeWindow, limit int) ([]*entity.WalletConnection, error) {
 	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH path = (w:Wallet {address: $address})-[*1..$max_hops]-(connected:Wallet)
-		WHERE connected.address <> $address
+		MATCH (w:Wallet {network: $network, address: $address})-[b:ROLLUP]->(other:Wallet)
+		WHERE ` + rollupWindowFilter + `
+		WITH w, other, collect(b.total_value) as values, sum(b.tx_count) as tx_count,
+			min(b.first_tx) as first_tx, max(b.last_tx) as last_tx
+		RETURN w.address, other.address, values, tx_count, first_tx, last_tx
+	`
+
+	parameters := windowParams(window, r.client.config.RollupBucket)
+	parameters["network"] = entity.NormalizeNetwork(network)
+	parameters["address"] = address
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return records.Collect(ctx)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet connections: %w", err)
+	}
+
+	var connections []*entity.WalletConnection
+	totals := make(map[*entity.WalletConnection]*big.Int)
+	for _, record := range result.([]*neo4j.Record) {
+		values := record.Values
+		totalValue, err := sumAmounts(values[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum wallet connection: %w", err)
+		}
+		connection := &entity.WalletConnection{
+			FromAddress: stringValue(values[0]),
+			ToAddress:   stringValue(values[1]),
+			TotalValue:  totalValue,
+		}
+		connection.TxCount, _ = values[3].(int64)
+		connection.FirstTx, _ = values[4].(time.Time)
+		connection.LastTx, _ = values[5].(time.Time)
+		totals[connection], _ = new(big.Int).SetString(totalValue, 10)
+		connections = append(connections, connection)
+	}
+
+	sort.SliceStable(connections, func(i, j int) bool {
+		return totals[connections[i]].Cmp(totals[connections[j]]) > 0
+	})
+	if limit > 0 && len(connections) > limit {
+		connections = connections[:limit]
+	}
+
+	return connections, nil
+}
+
+// FindConnectedWallets finds wallets connected to the wallet of an address on a network within
+// specified hops. The hop bound is clamped to MaxTraversalDepth and formatted in, since Cypher does not accept it
+// as a parameter; use TraversalService for hub-aware, fan-out bounded expansion.
+func (r *Neo4JWalletRepository) FindConnectedWallets(ctx context.Context, network, address string, maxHops int) ([]*entity.Wallet, error) {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	if maxHops < 1 {
+		maxHops = 1
+	}
+	if maxHops > entity.MaxTraversalDepth {
+		maxHops = entity.MaxTraversalDepth
+	}
+
+	query := fmt.Sprintf(`
+		MATCH path = (w:Wallet {network: $network, address: $address})-[:SENT_TO|ERC20_TRANSFER|NATIVE_TRANSFER*1..%d]-(connected:Wallet)
+		WHERE connected <> w
 		RETURN DISTINCT connected.address, connected.first_seen, connected.last_seen, connected.total_transactions, connected.total_sent, connected.total_received, connected.network
 		LIMIT 100
-	`
+	`, maxHops)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		return tx.Run(ctx, query, map[string]interface{}{
-			"address":  address,
-			"max_hops": maxHops,
+			"network": entity.NormalizeNetwork(network),
+			"address": address,
 		})
 	})
 
@@ -287,13 +516,19 @@ func (r *Neo4JWalletRepository) GetTopWallets(ctx context.Context, limit int) ([
 	return wallets, nil
 }
 
-// GetBubbleWallets retrieves wallets that form bubbles (high connectivity)
-func (r *Neo4JWalletRepository) GetBubbleWallets(ctx context.Context, minConnections int, limit int) ([]*entity.Wallet, error) {
+// GetBubbleWallets retrieves the wallets of a network that form bubbles (high connectivity);
+// within a window their connections, totals and activity are summed from the rollups of the
+// window's buckets
+func (r *Neo4JWalletRepository) GetBubbleWallets(ctx context.Context, network string, minConnections int, window *entity.TimeWindow, limit int) ([]*entity.Wallet, error) {
+	if !window.IsZero() {
+		return r.getBubbleWalletsInWindow(ctx, network, minConnections, window, limit)
+	}
+
 	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
 
 	query := `
-		MATCH (w:Wallet)-[:SENT_TO]->(other:Wallet)
+		MATCH (w:Wallet {network: $network})-[:SENT_TO]->(other:Wallet)
 		WITH w, count(other) as connections
 		WHERE connections >= $min_connections
 		RETURN w.address, w.first_seen, w.last_seen, w.total_transactions, w.total_sent, w.total_received, w.network, connections
@@ -303,6 +538,7 @@ func (r *Neo4JWalletRepository) GetBubbleWallets(ctx context.Context, minConnect
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		return tx.Run(ctx, query, map[string]interface{}{
+			"network":         entity.NormalizeNetwork(network),
 			"min_connections": minConnections,
 			"limit":           limit,
 		})
@@ -333,3 +569,72 @@ func (r *Neo4JWalletRepository) GetBubbleWallets(ctx context.Context, minConnect
 
 	return wallets, nil
 }
+
+// getBubbleWalletsInWindow ranks the wallets of a network by the counterparties they sent to within a window;
+// their sent and received totals, transaction counts and first and last activity are those of
+// the rollups of the window's buckets, summed exactly in Go
+func (r *Neo4JWalletRepository) getBubbleWalletsInWindow(ctx context.Context, network string, minConnections int, window *entity.TimeWindow, limit int) ([]*entity.Wallet, error) {
+	session := r.client.GetDriver().NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	query := `
+		MATCH (w:Wallet {network: $network})-[b:ROLLUP]->(other:Wallet)
+		WHERE ` + rollupWindowFilter + `
+		WITH w, count(DISTINCT other) as connections, collect(b.total_value) as sent,
+			sum(b.tx_count) as sent_count, min(b.first_tx) as first_sent, max(b.last_tx) as last_sent
+		WHERE connections >= $min_connections
+		ORDER BY connections DESC
+		LIMIT $limit
+		OPTIONAL MATCH (w)<-[b:ROLLUP]-(:Wallet)
+		WHERE ` + rollupWindowFilter + `
+		WITH w, connections, sent, sent_count, first_sent, last_sent, collect(b.total_value) as received,
+			sum(b.tx_count) as received_count, min(b.first_tx) as first_received, max(b.last_tx) as last_received
+		RETURN w.address, w.network, connections, sent, received, sent_count + received_count,
+			CASE WHEN first_received < first_sent THEN first_received ELSE first_sent END,
+			CASE WHEN last_received > last_sent THEN last_received ELSE last_sent END
+		ORDER BY connections DESC
+	`
+
+	parameters := windowParams(window, r.client.config.RollupBucket)
+	parameters["network"] = entity.NormalizeNetwork(network)
+	parameters["min_connections"] = minConnections
+	parameters["limit"] = limit
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return records.Collect(ctx)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bubble wallets: %w", err)
+	}
+
+	var wallets []*entity.Wallet
+	for _, record := range result.([]*neo4j.Record) {
+		values := record.Values
+		totalSent, err := sumAmounts(values[3])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum wallet sent total: %w", err)
+		}
+		totalReceived, err := sumAmounts(values[4])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum wallet received total: %w", err)
+		}
+
+		wallet := &entity.Wallet{
+			Address:       stringValue(values[0]),
+			Network:       stringValue(values[1]),
+			TotalSent:     totalSent,
+			TotalReceived: totalReceived,
+		}
+		wallet.TotalTransactions, _ = values[5].(int64)
+		wallet.FirstSeen, _ = values[6].(time.Time)
+		wallet.LastSeen, _ = values[7].(time.Time)
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, nil
+}
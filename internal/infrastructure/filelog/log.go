@@ -0,0 +1,532 @@
+package filelog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned by a log after Close
+var ErrClosed = errors.New("file log closed")
+
+// ErrFull is returned by TryAppend when a record does not fit in the log
+var ErrFull = errors.New("file log full")
+
+// Record is a raw message kept in a log
+type Record struct {
+	Subject string
+	Data    []byte
+
+	offset int64 // position of the record in the file
+	end    int64 // position of the next record
+}
+
+const (
+	// minSegments is how many segments a full log spans at least, so that acknowledged
+	// records are deleted long before the pending ones reach the bound
+	minSegments = 8
+	// minSegmentBytes keeps small logs from rotating on every few records
+	minSegmentBytes = 1 << 20
+	// segmentDigits is the width of the start offset in segment file names
+	segmentDigits = 20
+)
+
+// Log is a bounded FIFO of raw messages kept in append-only segment files. Records are
+// written at the tail and read from the head; a record is only removed once it is
+// acknowledged, and acknowledgements move the commit offset over the longest acknowledged
+// prefix. Offsets count the bytes of every record ever written, and the commit offset is
+// persisted next to the segments, so after a restart only records that were never
+// acknowledged are replayed. Writing moves on to a new segment once the current one is full,
+// and a segment is deleted as soon as all of its records are acknowledged, so the files hold
+// little more than the pending records however long the log is used.
+type Log struct {
+	dir          string
+	name         string
+	maxBytes     int64
+	segmentBytes int64
+
+	mu         sync.Mutex
+	notEmpty   *sync.Cond
+	notFull    *sync.Cond
+	segments   []*segment // oldest first; records are appended to the last one
+	offsetFile *os.File
+	reader     *bufio.Reader
+	readSeg    int             // index of the segment holding the read offset
+	commitOff  int64           // offset of the oldest unacknowledged record
+	readOff    int64           // offset of the next unread record
+	writeOff   int64           // end of the last segment
+	acked      map[int64]int64 // acknowledged records above the commit offset, by offset
+	unread     int
+	reading    int // records returned by Next and not yet acknowledged
+	closed     bool
+}
+
+// segment is one file of a log, holding the records from its start offset on
+type segment struct {
+	start int64
+	size  int64
+	file  *os.File
+}
+
+// end returns the offset following the last record of the segment
+func (s *segment) end() int64 {
+	return s.start + s.size
+}
+
+// Open opens (or creates) a log holding at most maxBytes of pending records
+func Open(dir string, name string, maxBytes int64) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", dir, err)
+	}
+
+	path := filepath.Join(dir, name)
+	offsetFile, err := os.OpenFile(path+".offset", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log offset file %s: %w", path, err)
+	}
+
+	b := &Log{
+		dir:          dir,
+		name:         name,
+		maxBytes:     maxBytes,
+		segmentBytes: max(maxBytes/minSegments, minSegmentBytes),
+		offsetFile:   offsetFile,
+		acked:        make(map[int64]int64),
+	}
+	b.notEmpty = sync.NewCond(&b.mu)
+	b.notFull = sync.NewCond(&b.mu)
+
+	if err := b.recover(); err != nil {
+		for _, seg := range b.segments {
+			seg.file.Close()
+		}
+		offsetFile.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// segmentPath returns the file of the segment starting at an offset
+func (b *Log) segmentPath(start int64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%s.%0*d", b.name, segmentDigits, start))
+}
+
+// segmentStarts returns the start offsets of the segment files in order. A log written as a
+// single file by an older version becomes the segment starting at 0.
+func (b *Log) segmentStarts() ([]int64, error) {
+	legacy := filepath.Join(b.dir, b.name)
+	if _, err := os.Stat(legacy); err == nil {
+		if err := os.Rename(legacy, b.segmentPath(0)); err != nil {
+			return nil, fmt.Errorf("failed to convert log file %s: %w", legacy, err)
+		}
+	}
+
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log directory %s: %w", b.dir, err)
+	}
+	var starts []int64
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), b.name+".")
+		if !ok || len(suffix) != segmentDigits {
+			continue
+		}
+		start, err := strconv.ParseInt(suffix, 10, 64)
+		if err != nil {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts, nil
+}
+
+// recover opens the segments, drops a torn tail left by a previous run and resumes reading
+// at the persisted commit offset; an offset that is not a record boundary replays every
+// segment. Segments that were fully acknowledged before the restart are deleted.
+func (b *Log) recover() error {
+	committed, err := b.loadCommitOffset()
+	if err != nil {
+		return err
+	}
+	starts, err := b.segmentStarts()
+	if err != nil {
+		return err
+	}
+	if len(starts) == 0 {
+		starts = []int64{committed}
+	}
+
+	boundary := committed == starts[0]
+	records := 0
+	for i, start := range starts {
+		file, err := os.OpenFile(b.segmentPath(start), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open log segment: %w", err)
+		}
+		seg := &segment{start: start, file: file}
+		b.segments = append(b.segments, seg)
+		if i > 0 && start != b.segments[i-1].end() {
+			return fmt.Errorf("log segment %d does not follow the segment before it", start)
+		}
+
+		reader := bufio.NewReader(io.NewSectionReader(file, 0, 1<<62))
+		for {
+			_, size, err := readRecord(reader)
+			if err != nil {
+				break
+			}
+			seg.size += size
+			records++
+			if seg.end() == committed {
+				boundary = true
+				records = 0
+			}
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat log segment: %w", err)
+		}
+		if info.Size() != seg.size {
+			// Only the segment being written can be torn; earlier ones are synced on rotation
+			if i < len(starts)-1 {
+				return fmt.Errorf("log segment %d is corrupt", start)
+			}
+			if err := file.Truncate(seg.size); err != nil {
+				return fmt.Errorf("failed to truncate log segment: %w", err)
+			}
+		}
+	}
+	b.writeOff = b.segments[len(b.segments)-1].end()
+
+	if !boundary {
+		committed = starts[0]
+		records = 0
+		for _, seg := range b.segments {
+			reader := bufio.NewReader(io.NewSectionReader(seg.file, 0, seg.size))
+			for {
+				if _, _, err := readRecord(reader); err != nil {
+					break
+				}
+				records++
+			}
+		}
+	}
+	b.commitOff = committed
+	b.readOff = committed
+	b.unread = records
+	if err := b.dropAcknowledged(); err != nil {
+		return err
+	}
+	b.resetReader()
+	return nil
+}
+
+// loadCommitOffset reads the persisted commit offset, 0 if none was written
+func (b *Log) loadCommitOffset() (int64, error) {
+	var buf [8]byte
+	n, err := b.offsetFile.ReadAt(buf[:], 0)
+	if n < len(buf) {
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("failed to read log offset: %w", err)
+		}
+		return 0, nil
+	}
+	return int64(binary.BigEndian.Uint64(buf[:])), nil
+}
+
+// saveCommitOffset persists the commit offset; the caller holds the mutex
+func (b *Log) saveCommitOffset() error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(b.commitOff))
+	if _, err := b.offsetFile.WriteAt(buf[:], 0); err != nil {
+		return fmt.Errorf("failed to write log offset: %w", err)
+	}
+	return nil
+}
+
+// resetReader positions the reader at the read offset; the caller holds the mutex
+func (b *Log) resetReader() {
+	for b.readSeg < len(b.segments)-1 && b.readOff >= b.segments[b.readSeg].end() {
+		b.readSeg++
+	}
+	seg := b.segments[b.readSeg]
+	b.reader = bufio.NewReader(io.NewSectionReader(seg.file, b.readOff-seg.start, 1<<62))
+}
+
+// rotate syncs the segment being written and starts a new one at the write offset; the caller
+// holds the mutex
+func (b *Log) rotate() error {
+	if err := b.segments[len(b.segments)-1].file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log segment: %w", err)
+	}
+	file, err := os.OpenFile(b.segmentPath(b.writeOff), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create log segment: %w", err)
+	}
+	b.segments = append(b.segments, &segment{start: b.writeOff, file: file})
+	return nil
+}
+
+// dropAcknowledged deletes the segments whose records are all acknowledged. A drained log
+// moves on to a new segment first, so the one it wrote to is reclaimed as well. The caller
+// holds the mutex.
+func (b *Log) dropAcknowledged() error {
+	if b.commitOff == b.writeOff && b.segments[len(b.segments)-1].size > 0 {
+		if err := b.rotate(); err != nil {
+			return err
+		}
+	}
+
+	dropped := 0
+	for dropped < len(b.segments)-1 && b.segments[dropped].end() <= b.commitOff {
+		seg := b.segments[dropped]
+		seg.file.Close()
+		if err := os.Remove(b.segmentPath(seg.start)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete log segment: %w", err)
+		}
+		dropped++
+	}
+	if dropped == 0 {
+		return nil
+	}
+
+	b.segments = b.segments[dropped:]
+	b.readSeg = max(b.readSeg-dropped, 0)
+	b.resetReader()
+	return nil
+}
+
+// Append adds a record at the tail, blocking while the log is full
+func (b *Log) Append(subject string, data []byte) error {
+	record := encodeRecord(subject, data)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for !b.closed && b.pendingBytes() > 0 && b.pendingBytes()+int64(len(record)) > b.maxBytes {
+		b.notFull.Wait()
+	}
+	if b.closed {
+		return ErrClosed
+	}
+
+	return b.writeLocked(record)
+}
+
+// TryAppend adds a record at the tail, failing with ErrFull instead of blocking
+func (b *Log) TryAppend(subject string, data []byte) error {
+	record := encodeRecord(subject, data)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	if b.pendingBytes() > 0 && b.pendingBytes()+int64(len(record)) > b.maxBytes {
+		return ErrFull
+	}
+
+	return b.writeLocked(record)
+}
+
+// writeLocked writes an encoded record at the tail; the caller holds the mutex
+func (b *Log) writeLocked(record []byte) error {
+	seg := b.segments[len(b.segments)-1]
+	if seg.size > 0 && seg.size+int64(len(record)) > b.segmentBytes {
+		if err := b.rotate(); err != nil {
+			return err
+		}
+		seg = b.segments[len(b.segments)-1]
+	}
+
+	if _, err := seg.file.WriteAt(record, seg.size); err != nil {
+		return fmt.Errorf("failed to write log record: %w", err)
+	}
+	seg.size += int64(len(record))
+	b.writeOff += int64(len(record))
+	b.unread++
+	b.notEmpty.Signal()
+	return nil
+}
+
+// Sync flushes the appended records to stable storage; earlier segments were synced when
+// writing moved on from them
+func (b *Log) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	if err := b.segments[len(b.segments)-1].file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	return nil
+}
+
+// Next blocks until an unread record is available and returns it; the record stays in the
+// log until it is acknowledged
+func (b *Log) Next() (*Record, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for !b.closed && b.unread == 0 {
+		b.notEmpty.Wait()
+	}
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	// Records never span segments: move on once the current one is read
+	if b.readOff == b.segments[b.readSeg].end() && b.readSeg < len(b.segments)-1 {
+		b.resetReader()
+	}
+
+	record, size, err := readRecord(b.reader)
+	if err != nil {
+		// Read the same record again on the next call
+		b.resetReader()
+		return nil, fmt.Errorf("failed to read log record: %w", err)
+	}
+	record.offset = b.readOff
+	record.end = b.readOff + size
+	b.readOff = record.end
+	b.unread--
+	b.reading++
+
+	return record, nil
+}
+
+// Ack removes a record returned by Next. Records may be acknowledged in any order; the
+// commit offset only moves over records whose predecessors are all acknowledged.
+func (b *Log) Ack(record *Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	if _, ok := b.acked[record.offset]; ok || record.offset < b.commitOff {
+		return nil
+	}
+
+	b.acked[record.offset] = record.end
+	b.reading--
+	advanced := false
+	for {
+		end, ok := b.acked[b.commitOff]
+		if !ok {
+			break
+		}
+		delete(b.acked, b.commitOff)
+		b.commitOff = end
+		advanced = true
+	}
+	if !advanced {
+		return nil
+	}
+
+	b.notFull.Broadcast()
+	if err := b.saveCommitOffset(); err != nil {
+		return err
+	}
+	return b.dropAcknowledged()
+}
+
+// Len returns the number of records waiting to be read
+func (b *Log) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.unread
+}
+
+// Pending returns the number of records not yet acknowledged, whether they were read or not
+func (b *Log) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.unread + b.reading
+}
+
+// Bytes returns the size of the records not yet acknowledged
+func (b *Log) Bytes() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pendingBytes()
+}
+
+// pendingBytes returns the unacknowledged size; the caller holds the mutex
+func (b *Log) pendingBytes() int64 {
+	return b.writeOff - b.commitOff
+}
+
+// Close wakes up blocked callers and closes the files; unacknowledged records stay on disk
+func (b *Log) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	b.notEmpty.Broadcast()
+	b.notFull.Broadcast()
+
+	err := b.segments[len(b.segments)-1].file.Sync()
+	if offsetErr := b.offsetFile.Sync(); err == nil {
+		err = offsetErr
+	}
+	for _, seg := range b.segments {
+		seg.file.Close()
+	}
+	b.offsetFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	return nil
+}
+
+// encodeRecord encodes a record as subject length, subject, data length, data
+func encodeRecord(subject string, data []byte) []byte {
+	record := make([]byte, 0, 8+len(subject)+len(data))
+	record = binary.BigEndian.AppendUint32(record, uint32(len(subject)))
+	record = append(record, subject...)
+	record = binary.BigEndian.AppendUint32(record, uint32(len(data)))
+	record = append(record, data...)
+	return record
+}
+
+// readRecord decodes the next record and returns its encoded size
+func readRecord(r io.Reader) (*Record, int64, error) {
+	var header [4]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	subject := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, subject); err != nil {
+		return nil, 0, err
+	}
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, 0, err
+	}
+
+	size := int64(8 + len(subject) + len(data))
+	return &Record{Subject: string(subject), Data: data}, size, nil
+}
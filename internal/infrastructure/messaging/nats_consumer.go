@@ -3,34 +3,69 @@ package messaging
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"crypto-bubble-map-indexer/internal/domain/entity"
 	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/filelog"
 	"crypto-bubble-map-indexer/internal/infrastructure/logger"
 
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 )
 
-// NATSConsumer handles NATS JetStream consumption
+// fetchPauseInterval is how long the fetch loop waits before re-checking a saturated pipeline
+const fetchPauseInterval = 100 * time.Millisecond
+
+// Backoff between attempts to read a spill buffer that failed
+const (
+	spillRetryInitialBackoff = 100 * time.Millisecond
+	spillRetryMaxBackoff     = 10 * time.Second
+)
+
+// NATSConsumer handles NATS JetStream consumption and implements TransactionSource.
+// With nats.networks configured every network is consumed from its own subject
+// (<prefix>.<network>.events) into its own partition.
 type NATSConsumer struct {
-	conn      *nats.Conn
-	js        nats.JetStreamContext
-	sub       *nats.Subscription
-	config    *config.NATSConfig
-	logger    *logger.Logger
-	msgChan   chan *entity.Transaction
-	isRunning bool
+	conn       *nats.Conn
+	js         nats.JetStreamContext
+	config     *config.NATSConfig
+	logger     *logger.Logger
+	partitions []*natsPartition
+	isRunning  bool
+
+	// Fan-in of all partitions for consumers that are not network aware
+	fanInOnce sync.Once
+	fanIn     <-chan *entity.TransactionMessage
 }
 
 // NewNATSConsumer creates a new NATS consumer
 func NewNATSConsumer(cfg *config.NATSConfig, logger *logger.Logger) *NATSConsumer {
-	return &NATSConsumer{
-		config:  cfg,
-		logger:  logger.WithComponent("nats-consumer"),
-		msgChan: make(chan *entity.Transaction, cfg.MaxPendingMessages),
+	n := &NATSConsumer{
+		config: cfg,
+		logger: logger.WithComponent("nats-consumer"),
+	}
+
+	networks := cfg.NetworkList()
+	if len(networks) == 0 {
+		n.partitions = []*natsPartition{newNATSPartition(n, "", n.filterSubject(), cfg.ConsumerName)}
+		return n
 	}
+
+	for _, network := range networks {
+		subject := fmt.Sprintf("%s.%s.events", cfg.SubjectPrefix, network)
+		durable := ""
+		if cfg.ConsumerName != "" {
+			durable = cfg.ConsumerName + "-" + network
+		}
+		n.partitions = append(n.partitions, newNATSPartition(n, network, subject, durable))
+	}
+	return n
 }
 
 // Connect connects to NATS server and sets up consumer
@@ -57,6 +92,14 @@ func (n *NATSConsumer) Connect(ctx context.Context) error {
 		nats.ClosedHandler(func(nc *nats.Conn) {
 			n.logger.Info("NATS connection closed")
 		}),
+		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			// Slow consumer errors mean the client dropped messages; they must never go unnoticed
+			fields := []zap.Field{zap.Error(err)}
+			if sub != nil {
+				fields = append(fields, zap.String("subject", sub.Subject))
+			}
+			n.logger.Error("NATS asynchronous error", fields...)
+		}),
 	}
 
 	conn, err := nats.Connect(n.config.URL, opts...)
@@ -67,152 +110,314 @@ func (n *NATSConsumer) Connect(ctx context.Context) error {
 
 	n.conn = conn
 
-	// Try JetStream first, if not available fall back to core NATS
+	if !n.config.UseJetStream {
+		n.logger.Info("JetStream disabled by configuration, using core NATS")
+		return n.setupCoreNATSSubscription()
+	}
+
 	js, err := conn.JetStream()
 	if err != nil {
-		n.logger.Warn("JetStream not available, using core NATS", zap.Error(err))
-		return n.setupCoreNATSSubscription()
+		return fmt.Errorf("failed to create JetStream context: %w", err)
 	}
 
 	n.js = js
 	return n.setupJetStreamSubscription()
 }
 
-// setupJetStreamSubscription sets up JetStream subscription
+// setupJetStreamSubscription ensures a durable pull consumer exists for every partition and binds to it
 func (n *NATSConsumer) setupJetStreamSubscription() error {
-	subject := fmt.Sprintf("%s.events", n.config.SubjectPrefix)
+	// Validate the stream exists before touching consumers
+	streamInfo, err := n.js.StreamInfo(n.config.StreamName)
+	if err != nil {
+		return fmt.Errorf("failed to look up JetStream stream %q: %w", n.config.StreamName, err)
+	}
 
-	n.logger.Info("Setting up JetStream subscription",
-		zap.String("subject", subject))
+	n.logger.Info("Found JetStream stream",
+		zap.String("stream", streamInfo.Config.Name),
+		zap.Strings("subjects", streamInfo.Config.Subjects),
+		zap.Uint64("messages", streamInfo.State.Msgs))
 
-	// Use existing consumer "example-consumer" for WorkQueue streams
-	existingConsumer := "example-consumer"
+	if err := n.ensureDeadLetterStream(); err != nil {
+		return err
+	}
 
-	n.logger.Info("Using existing JetStream consumer",
-		zap.String("consumer", existingConsumer),
-		zap.String("stream", n.config.StreamName))
+	for _, p := range n.partitions {
+		consumerName, err := n.ensureConsumer(p.subject, p.durable)
+		if err != nil {
+			return err
+		}
 
-	// Use PullSubscribe with existing consumer
-	sub, err := n.js.PullSubscribe(subject, existingConsumer, nats.Bind(n.config.StreamName, existingConsumer))
-	if err != nil {
-		n.logger.Warn("Failed to bind to existing consumer, falling back to core NATS", zap.Error(err))
-		return n.setupCoreNATSSubscription()
+		sub, err := n.js.PullSubscribe(p.subject, consumerName, nats.Bind(n.config.StreamName, consumerName))
+		if err != nil {
+			return fmt.Errorf("failed to bind to JetStream consumer %q: %w", consumerName, err)
+		}
+		p.sub = sub
+
+		n.logger.Info("Bound JetStream consumer",
+			zap.String("network", p.network),
+			zap.String("subject", p.subject),
+			zap.String("consumer", consumerName))
 	}
 
-	n.sub = sub
 	n.isRunning = true
 
 	// Start message processing
-	go n.processJetStreamMessages()
+	for _, p := range n.partitions {
+		go p.processJetStreamMessages()
+	}
 
-	n.logger.Info("Successfully connected to NATS JetStream with existing consumer",
-		zap.String("subject", subject),
-		zap.String("consumer", existingConsumer))
+	n.logger.Info("Successfully connected to NATS JetStream",
+		zap.String("stream", n.config.StreamName),
+		zap.Int("partitions", len(n.partitions)))
 
 	return nil
 }
 
-// processJetStreamMessages processes messages from JetStream pull subscription
-func (n *NATSConsumer) processJetStreamMessages() {
-	n.logger.Info("Starting JetStream message processing")
+// ensureConsumer creates the durable pull consumer or updates it to match the configuration
+func (n *NATSConsumer) ensureConsumer(subject, durable string) (string, error) {
+	consumerConfig, err := n.buildConsumerConfig(subject, durable)
+	if err != nil {
+		return "", err
+	}
 
-	for n.isRunning {
-		// Fetch messages in batches
-		msgs, err := n.sub.Fetch(10, nats.MaxWait(5*1000000000)) // 5 seconds timeout
+	existing, err := n.js.ConsumerInfo(n.config.StreamName, consumerConfig.Durable)
+	switch {
+	case errors.Is(err, nats.ErrConsumerNotFound):
+		info, err := n.js.AddConsumer(n.config.StreamName, consumerConfig)
 		if err != nil {
-			if err == nats.ErrTimeout {
-				n.logger.Debug("No messages available, continuing...")
-				continue
-			}
-			n.logger.Error("Failed to fetch messages", zap.Error(err))
-			continue
+			return "", fmt.Errorf("failed to create JetStream consumer %q: %w", consumerConfig.Durable, err)
 		}
+		n.logger.Info("Created JetStream consumer",
+			zap.String("consumer", info.Name),
+			zap.String("deliver_policy", n.config.DeliverPolicy))
+		return info.Name, nil
 
-		n.logger.Debug("Fetched messages from JetStream", zap.Int("count", len(msgs)))
+	case err != nil:
+		return "", fmt.Errorf("failed to look up JetStream consumer %q: %w", consumerConfig.Durable, err)
+	}
 
-		for _, msg := range msgs {
-			n.handleMessage(msg)
+	// The starting position is fixed once a consumer exists, so keep the server's
+	// values and only update the settings JetStream allows to change.
+	if existing.Config.DeliverPolicy != consumerConfig.DeliverPolicy {
+		n.logger.Warn("Ignoring deliver policy change for existing consumer",
+			zap.String("consumer", existing.Name),
+			zap.String("configured", n.config.DeliverPolicy))
+	}
+	consumerConfig.DeliverPolicy = existing.Config.DeliverPolicy
+	consumerConfig.OptStartSeq = existing.Config.OptStartSeq
+	consumerConfig.OptStartTime = existing.Config.OptStartTime
+
+	info, err := n.js.UpdateConsumer(n.config.StreamName, consumerConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to update JetStream consumer %q: %w", consumerConfig.Durable, err)
+	}
+
+	n.logger.Info("Updated existing JetStream consumer",
+		zap.String("consumer", info.Name),
+		zap.Uint64("pending", info.NumPending))
+
+	return info.Name, nil
+}
+
+// buildConsumerConfig translates NATSConfig into a durable pull consumer configuration
+func (n *NATSConsumer) buildConsumerConfig(subject, durable string) (*nats.ConsumerConfig, error) {
+	if durable == "" {
+		return nil, fmt.Errorf("nats.consumer_name must be set when JetStream is enabled")
+	}
+
+	consumerConfig := &nats.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: subject,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       n.config.AckWait,
+		MaxDeliver:    n.config.MaxDeliver,
+		MaxAckPending: n.config.MaxAckPending,
+	}
+
+	switch strings.ToLower(n.config.DeliverPolicy) {
+	case "", "all":
+		consumerConfig.DeliverPolicy = nats.DeliverAllPolicy
+	case "last":
+		consumerConfig.DeliverPolicy = nats.DeliverLastPolicy
+	case "new":
+		consumerConfig.DeliverPolicy = nats.DeliverNewPolicy
+	case "by_start_sequence":
+		if n.config.StartSequence == 0 {
+			return nil, fmt.Errorf("nats.start_sequence must be set for deliver policy by_start_sequence")
+		}
+		consumerConfig.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		consumerConfig.OptStartSeq = n.config.StartSequence
+	case "by_start_time":
+		startTime, err := time.Parse(time.RFC3339, n.config.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nats.start_time %q: %w", n.config.StartTime, err)
 		}
+		consumerConfig.DeliverPolicy = nats.DeliverByStartTimePolicy
+		consumerConfig.OptStartTime = &startTime
+	default:
+		return nil, fmt.Errorf("unknown nats.deliver_policy %q", n.config.DeliverPolicy)
+	}
+
+	return consumerConfig, nil
+}
+
+// ensureDeadLetterStream creates the stream that retains dead-lettered messages if it is missing
+func (n *NATSConsumer) ensureDeadLetterStream() error {
+	if n.config.DeadLetterSubject == "" || n.config.DeadLetterStream == "" {
+		return nil
+	}
+
+	_, err := n.js.StreamInfo(n.config.DeadLetterStream)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return fmt.Errorf("failed to look up dead-letter stream %q: %w", n.config.DeadLetterStream, err)
+	}
+
+	_, err = n.js.AddStream(&nats.StreamConfig{
+		Name:      n.config.DeadLetterStream,
+		Subjects:  []string{n.config.DeadLetterSubject},
+		Storage:   nats.FileStorage,
+		Retention: nats.LimitsPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create dead-letter stream %q: %w", n.config.DeadLetterStream, err)
 	}
 
-	n.logger.Info("Stopped JetStream message processing")
+	n.logger.Info("Created dead-letter stream",
+		zap.String("stream", n.config.DeadLetterStream),
+		zap.String("subject", n.config.DeadLetterSubject))
+
+	return nil
+}
+
+// filterSubject returns the subject the consumer reads transactions from when no
+// networks are configured
+func (n *NATSConsumer) filterSubject() string {
+	if n.config.FilterSubject != "" {
+		return n.config.FilterSubject
+	}
+	return fmt.Sprintf("%s.events", n.config.SubjectPrefix)
 }
 
-// setupCoreNATSSubscription sets up core NATS subscription
+// setupCoreNATSSubscription sets up a core NATS subscription for every partition
 func (n *NATSConsumer) setupCoreNATSSubscription() error {
-	subject := fmt.Sprintf("%s.events", n.config.SubjectPrefix)
 	queueGroup := n.config.ConsumerGroup
 
-	n.logger.Info("Setting up core NATS subscription",
-		zap.String("subject", subject),
-		zap.String("queue_group", queueGroup))
+	// Without a spill buffer a blocked callback makes the client drop messages as a slow consumer
+	if n.config.SpillDir == "" {
+		return fmt.Errorf("core NATS requires nats.spill_dir, otherwise messages that do not fit in the pipeline are dropped")
+	}
 
-	sub, err := n.conn.QueueSubscribe(subject, queueGroup, func(msg *nats.Msg) {
-		n.handleMessage(msg)
-	})
+	for _, p := range n.partitions {
+		n.logger.Info("Setting up core NATS subscription",
+			zap.String("subject", p.subject),
+			zap.String("queue_group", queueGroup))
 
-	if err != nil {
-		n.logger.Error("Failed to subscribe to subject", zap.Error(err))
-		return fmt.Errorf("failed to subscribe: %w", err)
+		spill, err := filelog.Open(n.config.SpillDir, p.spillFileName(), n.config.SpillMaxBytes)
+		if err != nil {
+			return err
+		}
+		p.spill = spill
+		if pending := spill.Len(); pending > 0 {
+			p.logger.Info("Replaying messages spilled by a previous run", zap.Int("count", pending))
+		}
+		go p.drainSpill()
+
+		sub, err := n.conn.QueueSubscribe(p.subject, queueGroup, p.handleCoreMessage)
+		if err != nil {
+			n.logger.Error("Failed to subscribe to subject", zap.String("subject", p.subject), zap.Error(err))
+			return fmt.Errorf("failed to subscribe: %w", err)
+		}
+		p.sub = sub
 	}
 
-	n.sub = sub
 	n.isRunning = true
 
 	n.logger.Info("Successfully connected to core NATS",
-		zap.String("subject", subject),
+		zap.Int("partitions", len(n.partitions)),
 		zap.String("queue_group", queueGroup))
 
 	return nil
 }
 
-// handleMessage handles incoming NATS messages
-func (n *NATSConsumer) handleMessage(msg *nats.Msg) {
-	var tx entity.Transaction
-	if err := json.Unmarshal(msg.Data, &tx); err != nil {
-		n.logger.Error("Failed to unmarshal transaction", zap.Error(err))
-		if msg.Reply != "" {
-			msg.Respond([]byte("ERROR: Failed to unmarshal"))
-		}
-		return
+// SpillSize returns the number of core NATS messages waiting in the spill buffers
+func (n *NATSConsumer) SpillSize() int {
+	size := 0
+	for _, p := range n.partitions {
+		size += p.spillSize()
+	}
+	return size
+}
+
+// PublishDeadLetter publishes a failed message to the configured dead-letter subject. Without
+// a subject or a connection the letter cannot be kept, so an error is returned and the caller
+// holds on to the message.
+func (n *NATSConsumer) PublishDeadLetter(ctx context.Context, letter *entity.DeadLetter) error {
+	if n.config.DeadLetterSubject == "" {
+		return fmt.Errorf("failed to publish dead letter for %s: dead-letter subject not configured", letter.TxHash)
+	}
+	if n.conn == nil {
+		// Not connected (e.g. a non-NATS source is in use); keep the failure visible in the logs
+		// in case the message cannot be redelivered either
+		n.logger.Error("NATS not connected, cannot publish dead letter",
+			zap.String("stage", string(letter.Stage)),
+			zap.String("tx_hash", letter.TxHash),
+			zap.String("error", letter.Error),
+			zap.ByteString("payload", letter.Payload))
+		return fmt.Errorf("failed to publish dead letter for %s: NATS not connected", letter.TxHash)
 	}
 
-	n.logger.Debug("Processing transaction",
-		zap.String("hash", tx.Hash),
-		zap.String("from", tx.From),
-		zap.String("to", tx.To),
-		zap.String("value", tx.Value))
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
 
-	// Send to message channel
-	select {
-	case n.msgChan <- &tx:
-		n.logger.Debug("Sent transaction to processing channel", zap.String("hash", tx.Hash))
-		// Acknowledge if it's a JetStream message
-		if msg.Reply != "" {
-			msg.Ack()
+	if n.js != nil && n.config.DeadLetterStream != "" {
+		opts := []nats.PubOpt{}
+		if _, ok := ctx.Deadline(); ok {
+			opts = append(opts, nats.Context(ctx))
 		}
-	default:
-		// Channel is full
-		n.logger.Warn("Message channel is full, dropping message", zap.String("hash", tx.Hash))
-		if msg.Reply != "" {
-			msg.Nak()
+		if _, err := n.js.Publish(n.config.DeadLetterSubject, data, opts...); err != nil {
+			return fmt.Errorf("failed to publish dead letter: %w", err)
 		}
+	} else if err := n.conn.Publish(n.config.DeadLetterSubject, data); err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	n.logger.Warn("Published message to dead-letter subject",
+		zap.String("subject", n.config.DeadLetterSubject),
+		zap.String("stage", string(letter.Stage)),
+		zap.String("tx_hash", letter.TxHash),
+		zap.Uint64("attempts", letter.Attempts))
+
+	return nil
+}
+
+// StopReceiving stops fetching and closes the message channels, keeping the connection
+// open so the messages already delivered can still be acknowledged
+func (n *NATSConsumer) StopReceiving() error {
+	for _, p := range n.partitions {
+		p.stop()
 	}
+	n.logger.Info("Stopped receiving from NATS JetStream")
+	return nil
 }
 
-// Disconnect disconnects from NATS server
+// Disconnect disconnects from NATS server and closes the spill buffers
 func (n *NATSConsumer) Disconnect() error {
 	n.isRunning = false
 
-	if n.sub != nil {
-		n.sub.Unsubscribe()
-		n.sub = nil
+	for _, p := range n.partitions {
+		p.stop()
+		p.closeSpill()
 	}
+
 	if n.conn != nil {
 		n.conn.Close()
 		n.conn = nil
 	}
-	close(n.msgChan)
 	n.logger.Info("Disconnected from NATS JetStream")
 	return nil
 }
@@ -223,6 +428,178 @@ func (n *NATSConsumer) IsConnected() bool {
 }
 
 // GetMessageChannel returns the message channel
-func (n *NATSConsumer) GetMessageChannel() <-chan *entity.Transaction {
-	return n.msgChan
+func (n *NATSConsumer) GetMessageChannel() <-chan *entity.TransactionMessage {
+	return n.Messages()
+}
+
+// Start connects to NATS and begins consuming
+func (n *NATSConsumer) Start(ctx context.Context) error {
+	return n.Connect(ctx)
+}
+
+// Messages returns the stream of consumed transactions of all networks
+func (n *NATSConsumer) Messages() <-chan *entity.TransactionMessage {
+	if len(n.partitions) == 1 {
+		return n.partitions[0].msgChan
+	}
+
+	n.fanInOnce.Do(func() {
+		out := make(chan *entity.TransactionMessage)
+		var wg sync.WaitGroup
+		for _, p := range n.partitions {
+			wg.Add(1)
+			go func(in <-chan *entity.TransactionMessage) {
+				defer wg.Done()
+				for msg := range in {
+					out <- msg
+				}
+			}(p.msgChan)
+		}
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+		n.fanIn = out
+	})
+	return n.fanIn
+}
+
+// NetworkMessages returns the stream of consumed transactions of every configured network;
+// it is empty when the consumer reads a single subject
+func (n *NATSConsumer) NetworkMessages() map[string]<-chan *entity.TransactionMessage {
+	streams := make(map[string]<-chan *entity.TransactionMessage)
+	for _, p := range n.partitions {
+		if p.network != "" {
+			streams[p.network] = p.msgChan
+		}
+	}
+	return streams
+}
+
+// Stop disconnects from NATS
+func (n *NATSConsumer) Stop() error {
+	return n.Disconnect()
+}
+
+// Lag returns the messages pending on the JetStream consumers plus those buffered locally
+func (n *NATSConsumer) Lag(ctx context.Context) (uint64, error) {
+	var total uint64
+	for _, p := range n.partitions {
+		lag, err := p.lag()
+		total += lag
+		if err != nil {
+			return total, fmt.Errorf("failed to get consumer info: %w", err)
+		}
+	}
+	return total, nil
+}
+
+// NetworkLag returns the lag of every configured network
+func (n *NATSConsumer) NetworkLag(ctx context.Context) (map[string]uint64, error) {
+	lags := make(map[string]uint64)
+	var errs []string
+	for _, p := range n.partitions {
+		if p.network == "" {
+			continue
+		}
+		lag, err := p.lag()
+		lags[p.network] = lag
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.network, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return lags, fmt.Errorf("failed to get consumer info: %s", strings.Join(errs, "; "))
+	}
+	return lags, nil
+}
+
+// Settled reports whether every message of a network is acknowledged or dead-lettered and
+// none is buffered, spilled or waiting on its JetStream consumer. A single-subject consumer
+// carries every network.
+func (n *NATSConsumer) Settled(ctx context.Context, network string) (bool, error) {
+	for _, p := range n.partitions {
+		if p.network != "" && !strings.EqualFold(p.network, network) {
+			continue
+		}
+		settled, err := p.settled()
+		if err != nil {
+			return false, fmt.Errorf("failed to get consumer info: %w", err)
+		}
+		if !settled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// natsMessageHandle settles a NATS message; core NATS messages have nothing to settle
+type natsMessageHandle struct {
+	msg       *nats.Msg
+	jetStream bool
+}
+
+// isJetStream reports whether the message came from a JetStream consumer
+func (h *natsMessageHandle) isJetStream() bool {
+	return h.jetStream && h.msg.Reply != ""
+}
+
+// Ack acknowledges the message
+func (h *natsMessageHandle) Ack() error {
+	if !h.isJetStream() {
+		return nil
+	}
+	return h.msg.Ack()
+}
+
+// Nak requests redelivery of the message after the given delay; core NATS never redelivers
+func (h *natsMessageHandle) Nak(delay time.Duration) error {
+	if !h.isJetStream() {
+		return entity.ErrRedeliveryUnsupported
+	}
+	if delay > 0 {
+		return h.msg.NakWithDelay(delay)
+	}
+	return h.msg.Nak()
+}
+
+// Term stops redelivery of the message
+func (h *natsMessageHandle) Term() error {
+	if !h.isJetStream() {
+		return nil
+	}
+	return h.msg.Term()
+}
+
+// NumDelivered returns the JetStream delivery count of the message
+func (h *natsMessageHandle) NumDelivered() uint64 {
+	if !h.isJetStream() {
+		return 1
+	}
+	meta, err := h.msg.Metadata()
+	if err != nil {
+		return 1
+	}
+	return meta.NumDelivered
+}
+
+// StreamSequence returns the JetStream stream sequence of the message, 0 for core NATS
+func (h *natsMessageHandle) StreamSequence() uint64 {
+	if !h.isJetStream() {
+		return 0
+	}
+	meta, err := h.msg.Metadata()
+	if err != nil {
+		return 0
+	}
+	return meta.Sequence.Stream
+}
+
+// InProgress extends the ack deadline of a JetStream message waiting to be processed
+func (h *natsMessageHandle) InProgress() error {
+	if !h.isJetStream() {
+		return nil
+	}
+	return h.msg.InProgress()
 }
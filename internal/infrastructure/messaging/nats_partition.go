@@ -0,0 +1,425 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/infrastructure/filelog"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// natsPartition consumes one subject into its own processing channel, so that a saturated
+// network only pauses its own subscription
+type natsPartition struct {
+	consumer *NATSConsumer
+	network  string // empty for the single-subject legacy mode
+	subject  string
+	durable  string
+	sub      *nats.Subscription
+	msgChan  chan *entity.TransactionMessage
+	logger   *logger.Logger
+
+	// Flow control: senders block on msgChan until stopCh is closed
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	sendMu   sync.RWMutex
+	closed   bool
+
+	// Core NATS has no redelivery, so messages that do not fit in msgChan are spilled to disk
+	spill   *filelog.Log
+	spillMu sync.Mutex
+}
+
+// newNATSPartition creates the partition of a subject
+func newNATSPartition(consumer *NATSConsumer, network, subject, durable string) *natsPartition {
+	log := consumer.logger
+	if network != "" {
+		log = log.WithFields(map[string]interface{}{"network": network})
+	}
+	return &natsPartition{
+		consumer: consumer,
+		network:  network,
+		subject:  subject,
+		durable:  durable,
+		msgChan:  make(chan *entity.TransactionMessage, consumer.config.MaxPendingMessages),
+		logger:   log,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// spillFileName returns the spill file of the partition
+func (p *natsPartition) spillFileName() string {
+	if p.network == "" {
+		return "core-nats.spill"
+	}
+	return "core-nats-" + p.network + ".spill"
+}
+
+// processJetStreamMessages pulls messages from JetStream, sizing each fetch to the free
+// capacity of the processing channel and pausing while the pipeline is saturated
+func (p *natsPartition) processJetStreamMessages() {
+	p.logger.Info("Starting JetStream message processing", zap.String("subject", p.subject))
+
+	cfg := p.consumer.config
+	paused := false
+	for p.consumer.isRunning && !p.stopped() {
+		size := p.fetchSize()
+		if size == 0 {
+			if !paused {
+				p.logger.Warn("Processing pipeline saturated, pausing fetch",
+					zap.Int("buffered", len(p.msgChan)))
+				paused = true
+			}
+			select {
+			case <-p.stopCh:
+				return
+			case <-time.After(fetchPauseInterval):
+			}
+			continue
+		}
+		if paused {
+			p.logger.Info("Processing pipeline drained, resuming fetch", zap.Int("fetch_size", size))
+			paused = false
+		}
+
+		msgs, err := p.sub.Fetch(size, nats.MaxWait(cfg.FetchMaxWait))
+		if err != nil {
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				p.logger.Debug("No messages available, continuing...")
+				continue
+			}
+			if !p.consumer.isRunning {
+				break
+			}
+			p.logger.Error("Failed to fetch messages", zap.Error(err))
+			select {
+			case <-p.stopCh:
+				return
+			case <-time.After(cfg.ReconnectDelay):
+			}
+			continue
+		}
+
+		p.logger.Debug("Fetched messages from JetStream",
+			zap.Int("requested", size),
+			zap.Int("count", len(msgs)))
+
+		for _, msg := range msgs {
+			if !p.handleMessage(msg) {
+				// Stopped before the message was queued; let JetStream redeliver it
+				msg.Nak()
+			}
+		}
+	}
+
+	p.logger.Info("Stopped JetStream message processing", zap.String("subject", p.subject))
+}
+
+// rejectUndecodable dead-letters a message that cannot be decoded into a valid transaction; it
+// is redelivered if the dead letter cannot be published
+func (p *natsPartition) rejectUndecodable(msg *nats.Msg, handle queuedHandle, hash string, decodeErr error) {
+	letter := &entity.DeadLetter{
+		Subject:   msg.Subject,
+		Payload:   msg.Data,
+		Error:     decodeErr.Error(),
+		Stage:     entity.DeadLetterStageDecode,
+		Attempts:  handle.NumDelivered(),
+		Timestamp: time.Now().UTC(),
+		TxHash:    hash,
+		Network:   p.network,
+	}
+	if err := p.consumer.PublishDeadLetter(context.Background(), letter); err != nil {
+		p.logger.Error("Failed to dead-letter undecodable message", zap.Error(err))
+		handle.Nak(p.consumer.config.NakDelay)
+		return
+	}
+	handle.Term()
+}
+
+// fetchSize returns how many messages the processing channel can take, capped at the
+// configured fetch batch size
+func (p *natsPartition) fetchSize() int {
+	free := cap(p.msgChan) - len(p.msgChan)
+	if cap(p.msgChan) == 0 {
+		free = 1
+	}
+
+	size := p.consumer.config.FetchBatchSize
+	if size <= 0 || size > free {
+		size = free
+	}
+	return size
+}
+
+// queuedHandle settles a message queued by a partition
+type queuedHandle interface {
+	entity.MessageHandle
+	StreamSequence() uint64
+	InProgress() error
+}
+
+// handleMessage decodes an incoming NATS message and queues it for processing; it returns
+// false if the consumer stopped before the message could be queued
+func (p *natsPartition) handleMessage(msg *nats.Msg) bool {
+	return p.queueMessage(msg, &natsMessageHandle{msg: msg, jetStream: p.consumer.js != nil})
+}
+
+// queueMessage decodes a message and queues it for processing with the handle that settles it
+func (p *natsPartition) queueMessage(msg *nats.Msg, handle queuedHandle) bool {
+	var tx entity.Transaction
+	if err := json.Unmarshal(msg.Data, &tx); err != nil {
+		p.logger.Error("Failed to unmarshal transaction, dead-lettering", zap.Error(err))
+		p.rejectUndecodable(msg, handle, "", err)
+		return true
+	}
+
+	// A malformed value would fail every batch the transaction is retried with
+	if _, err := entity.ParseAmount(tx.Value); err != nil {
+		p.logger.Error("Transaction value is not a valid amount, dead-lettering",
+			zap.String("hash", tx.Hash),
+			zap.String("value", tx.Value),
+			zap.Error(err))
+		p.rejectUndecodable(msg, handle, tx.Hash, err)
+		return true
+	}
+
+	// The subject is authoritative for the network of a partition
+	if p.network != "" {
+		if tx.Network == "" {
+			tx.Network = p.network
+		} else if !strings.EqualFold(tx.Network, p.network) {
+			p.logger.Warn("Transaction network does not match its subject, using the subject",
+				zap.String("hash", tx.Hash),
+				zap.String("network", tx.Network),
+				zap.String("subject", msg.Subject))
+			tx.Network = p.network
+		}
+	}
+
+	p.logger.Debug("Processing transaction",
+		zap.String("hash", tx.Hash),
+		zap.String("from", tx.From),
+		zap.String("to", tx.To),
+		zap.String("value", tx.Value))
+
+	// Send to message channel; the message is acknowledged once its batch is persisted
+	txMsg := &entity.TransactionMessage{Transaction: &tx, Handle: handle, Subject: msg.Subject, Payload: msg.Data, Sequence: handle.StreamSequence()}
+	if !p.deliver(txMsg, handle) {
+		return false
+	}
+	p.logger.Debug("Sent transaction to processing channel", zap.String("hash", tx.Hash))
+	return true
+}
+
+// deliver blocks until the processing channel accepts the message or the consumer stops.
+// JetStream messages waiting for room are kept alive with in-progress acks.
+func (p *natsPartition) deliver(txMsg *entity.TransactionMessage, handle queuedHandle) bool {
+	p.sendMu.RLock()
+	defer p.sendMu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	// Fast path when the pipeline keeps up
+	select {
+	case p.msgChan <- txMsg:
+		return true
+	default:
+	}
+
+	keepAlive := p.consumer.config.AckWait / 2
+	if keepAlive <= 0 {
+		keepAlive = 15 * time.Second
+	}
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case p.msgChan <- txMsg:
+			return true
+		case <-p.stopCh:
+			return false
+		case <-ticker.C:
+			if err := handle.InProgress(); err != nil {
+				p.logger.Debug("Failed to extend ack deadline", zap.Error(err))
+			}
+		}
+	}
+}
+
+// handleCoreMessage queues a core NATS message, spilling it to disk when the processing
+// channel is full so that nothing is dropped under load
+func (p *natsPartition) handleCoreMessage(msg *nats.Msg) {
+	// Once anything is spilled, later messages queue behind it to preserve order
+	p.spillMu.Lock()
+	direct := p.spill.Len() == 0 && len(p.msgChan) < cap(p.msgChan)
+	if !direct {
+		if err := p.spill.Append(msg.Subject, msg.Data); err != nil {
+			p.logger.Error("Failed to spill message", zap.Error(err))
+			direct = true
+		}
+	}
+	p.spillMu.Unlock()
+
+	if direct && !p.handleMessage(msg) {
+		// Stopped while waiting for room: park the message for the next run
+		if err := p.spill.Append(msg.Subject, msg.Data); err != nil {
+			p.logger.Error("Failed to spill message during shutdown, message lost",
+				zap.String("subject", msg.Subject),
+				zap.Error(err))
+		}
+	}
+}
+
+// drainSpill feeds spilled messages back into the processing channel in order. A record only
+// leaves the spill buffer once its batch is persisted, so a crash replays it; read errors are
+// retried with backoff until the partition stops.
+func (p *natsPartition) drainSpill() {
+	backoff := spillRetryInitialBackoff
+	for {
+		record, err := p.spill.Next()
+		if errors.Is(err, filelog.ErrClosed) {
+			return
+		}
+		if err != nil {
+			p.logger.Error("Failed to read spilled message, retrying",
+				zap.Duration("backoff", backoff),
+				zap.Error(err))
+			select {
+			case <-p.stopCh:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, spillRetryMaxBackoff)
+			continue
+		}
+		backoff = spillRetryInitialBackoff
+
+		msg := &nats.Msg{Subject: record.Subject, Data: record.Data}
+		handle := &spilledMessageHandle{
+			natsMessageHandle: &natsMessageHandle{msg: msg},
+			spill:             p.spill,
+			record:            record,
+		}
+		if !p.queueMessage(msg, handle) {
+			// Stopped: the record is not acknowledged and is replayed by the next run
+			return
+		}
+	}
+}
+
+// spilledMessageHandle settles a message replayed from the spill buffer: the record is
+// acknowledged once its batch is persisted or dead-lettered
+type spilledMessageHandle struct {
+	*natsMessageHandle
+	spill  *filelog.Log
+	record *filelog.Record
+}
+
+// Ack removes the record from the spill buffer
+func (h *spilledMessageHandle) Ack() error {
+	return h.spill.Ack(h.record)
+}
+
+// Term removes the record from the spill buffer after it was dead-lettered
+func (h *spilledMessageHandle) Term() error {
+	return h.spill.Ack(h.record)
+}
+
+// spillSize returns the number of messages waiting in the spill buffer
+func (p *natsPartition) spillSize() int {
+	if p.spill == nil {
+		return 0
+	}
+	return p.spill.Len()
+}
+
+// lag returns the messages pending on the JetStream consumer plus those buffered locally
+func (p *natsPartition) lag() (uint64, error) {
+	lag := uint64(len(p.msgChan)) + uint64(p.spillSize())
+	if p.consumer.js == nil || p.sub == nil || p.stopped() {
+		return lag, nil
+	}
+
+	info, err := p.sub.ConsumerInfo()
+	if err != nil {
+		return lag, err
+	}
+
+	return lag + info.NumPending, nil
+}
+
+// settled reports whether the partition holds no message and its JetStream consumer has none
+// pending or awaiting acknowledgement
+func (p *natsPartition) settled() (bool, error) {
+	if p.stopped() || len(p.msgChan) > 0 || p.spillSize() > 0 {
+		return false, nil
+	}
+	if p.consumer.js == nil || p.sub == nil {
+		return true, nil
+	}
+
+	info, err := p.sub.ConsumerInfo()
+	if err != nil {
+		return false, err
+	}
+	return info.NumPending == 0 && info.NumAckPending == 0, nil
+}
+
+// stopped reports whether the partition has been stopped
+func (p *natsPartition) stopped() bool {
+	select {
+	case <-p.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop stops the partition; it is safe to call more than once
+func (p *natsPartition) stop() {
+	p.stopOnce.Do(p.shutdown)
+}
+
+// shutdown releases blocked senders, unsubscribes and closes the message channel; the spill
+// buffer stays open so the messages read from it can still be acknowledged
+func (p *natsPartition) shutdown() {
+	// Release blocked senders before unsubscribing so in-flight callbacks can finish
+	close(p.stopCh)
+
+	// sub stays set: the fetch loop and lag read it without locking and check stopCh instead
+	if p.sub != nil {
+		p.sub.Unsubscribe()
+	}
+
+	p.sendMu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.msgChan)
+	}
+	p.sendMu.Unlock()
+}
+
+// closeSpill closes the spill buffer once the messages read from it are settled; the ones
+// left unacknowledged are replayed on the next run
+func (p *natsPartition) closeSpill() {
+	if p.spill == nil {
+		return
+	}
+	if pending := p.spill.Pending(); pending > 0 {
+		p.logger.Info("Keeping spilled messages for the next run", zap.Int("count", pending))
+	}
+	if err := p.spill.Close(); err != nil {
+		p.logger.Warn("Failed to close spill buffer", zap.Error(err))
+	}
+}
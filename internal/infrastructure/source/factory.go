@@ -0,0 +1,31 @@
+package source
+
+import (
+	"fmt"
+
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+	"crypto-bubble-map-indexer/internal/infrastructure/messaging"
+)
+
+// Source types selectable through source.type
+const (
+	TypeNATS  = "nats"
+	TypeFile  = "file"
+	TypeStdin = "stdin"
+)
+
+// NewTransactionSource returns the transaction source selected by configuration
+func NewTransactionSource(cfg *config.Config, consumer *messaging.NATSConsumer, logger *logger.Logger) (service.TransactionSource, error) {
+	switch cfg.Source.Type {
+	case "", TypeNATS:
+		return consumer, nil
+	case TypeFile:
+		return NewFileSource(cfg.Source.Path, cfg.NATS.MaxPendingMessages, logger), nil
+	case TypeStdin:
+		return NewStdinSource(cfg.NATS.MaxPendingMessages, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown source type %q", cfg.Source.Type)
+	}
+}
@@ -0,0 +1,57 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// FileSource reads transactions from JSON-lines files, optionally gzip compressed
+type FileSource struct {
+	*localSource
+	path string
+}
+
+// NewFileSource creates a source reading a file or a directory of files in name order
+func NewFileSource(path string, bufferSize int, logger *logger.Logger) service.TransactionSource {
+	return &FileSource{
+		localSource: newLocalSource(bufferSize, logger.WithComponent("file-source")),
+		path:        path,
+	}
+}
+
+// Start begins reading the files in the background
+func (s *FileSource) Start(ctx context.Context) error {
+	if s.path == "" {
+		return fmt.Errorf("source.path must be set for the file source")
+	}
+
+	files, err := ListTransactionFiles(s.path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no transaction files found at %s", s.path)
+	}
+
+	s.logger.Info("Starting file source",
+		zap.String("path", s.path),
+		zap.Int("files", len(files)))
+
+	index := 0
+	s.run(ctx, func() (*TransactionReader, error) {
+		if index >= len(files) {
+			return nil, io.EOF
+		}
+		file := files[index]
+		index++
+		return OpenTransactionFile(file)
+	})
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// localSource is the shared machinery of sources that read transactions from local
+// streams: it emits messages, redelivers nak'ed ones in-process and closes the
+// message channel once the input is exhausted and every message is settled
+type localSource struct {
+	logger   *logger.Logger
+	msgChan  chan *entity.TransactionMessage
+	inFlight sync.WaitGroup
+	pending  atomic.Int64
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.RWMutex
+	closed   bool
+}
+
+// newLocalSource creates the shared local source state
+func newLocalSource(bufferSize int, logger *logger.Logger) *localSource {
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+	return &localSource{
+		logger:  logger,
+		msgChan: make(chan *entity.TransactionMessage, bufferSize),
+	}
+}
+
+// run reads every reader returned by next until it returns io.EOF, then waits for
+// outstanding messages to settle and closes the message channel
+func (s *localSource) run(ctx context.Context, next func() (*TransactionReader, error)) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer s.close()
+
+		for {
+			reader, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				s.logger.Error("Failed to open transaction input", zap.Error(err))
+				break
+			}
+
+			if err := s.emitAll(reader); err != nil {
+				reader.Close()
+				if errors.Is(err, context.Canceled) {
+					return
+				}
+				s.logger.Error("Failed to read transaction input",
+					zap.String("source", reader.Name()),
+					zap.Error(err))
+				break
+			}
+			reader.Close()
+		}
+
+		s.logger.Info("Transaction input exhausted, waiting for in-flight messages to settle",
+			zap.Int64("pending", s.pending.Load()))
+		s.waitSettled()
+	}()
+}
+
+// emitAll sends every transaction of the reader to the message channel
+func (s *localSource) emitAll(reader *TransactionReader) error {
+	s.logger.Info("Reading transactions", zap.String("source", reader.Name()))
+
+	for {
+		tx, raw, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		var decodeErr *DecodeError
+		if errors.As(err, &decodeErr) {
+			s.logger.Error("Skipping undecodable transaction line",
+				zap.String("source", decodeErr.Source),
+				zap.Int("line", decodeErr.Line),
+				zap.Error(decodeErr.Err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		msg := &entity.TransactionMessage{
+			Transaction: tx,
+			Subject:     reader.Name(),
+			Payload:     raw,
+		}
+		msg.Handle = &localMessageHandle{source: s, msg: msg, delivered: 1}
+
+		s.inFlight.Add(1)
+		s.pending.Add(1)
+
+		select {
+		case s.msgChan <- msg:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+// waitSettled blocks until every emitted message is settled or the source is stopped
+func (s *localSource) waitSettled() {
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-s.ctx.Done():
+	}
+}
+
+// close closes the message channel exactly once
+func (s *localSource) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.msgChan)
+	}
+}
+
+// redeliver re-emits a nak'ed message after the delay
+func (s *localSource) redeliver(msg *entity.TransactionMessage, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		if s.closed {
+			return
+		}
+		select {
+		case s.msgChan <- msg:
+		case <-s.ctx.Done():
+		}
+	})
+}
+
+// settle marks a message as finished
+func (s *localSource) settle() {
+	s.pending.Add(-1)
+	s.inFlight.Done()
+}
+
+// Messages returns the stream of transactions read from the source
+func (s *localSource) Messages() <-chan *entity.TransactionMessage {
+	return s.msgChan
+}
+
+// StopReceiving stops reading and closes the message channel; messages already emitted
+// can still be settled but are no longer redelivered
+func (s *localSource) StopReceiving() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.close()
+	return nil
+}
+
+// Stop stops reading; messages already emitted are no longer redelivered
+func (s *localSource) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+// Lag returns the number of emitted messages that are not yet settled
+func (s *localSource) Lag(ctx context.Context) (uint64, error) {
+	pending := s.pending.Load()
+	if pending < 0 {
+		return 0, nil
+	}
+	return uint64(pending), nil
+}
+
+// Settled reports whether every emitted message is settled; the transactions of all networks
+// share one stream
+func (s *localSource) Settled(ctx context.Context, network string) (bool, error) {
+	return s.pending.Load() <= 0, nil
+}
+
+// localMessageHandle settles messages of a local source; nak redelivers in-process
+type localMessageHandle struct {
+	source    *localSource
+	msg       *entity.TransactionMessage
+	delivered uint64
+	settled   atomic.Bool
+}
+
+// Ack marks the message as persisted
+func (h *localMessageHandle) Ack() error {
+	if h.settled.CompareAndSwap(false, true) {
+		h.source.settle()
+	}
+	return nil
+}
+
+// Nak redelivers the message after the delay
+func (h *localMessageHandle) Nak(delay time.Duration) error {
+	if h.settled.Load() {
+		return nil
+	}
+	h.delivered++
+	h.source.redeliver(h.msg, delay)
+	return nil
+}
+
+// Term gives up on the message
+func (h *localMessageHandle) Term() error {
+	if h.settled.CompareAndSwap(false, true) {
+		h.source.settle()
+	}
+	return nil
+}
+
+// NumDelivered returns how many times the message has been emitted
+func (h *localMessageHandle) NumDelivered() uint64 {
+	return h.delivered
+}
@@ -0,0 +1,176 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+)
+
+// gzipMagic is the two-byte header every gzip stream starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// DecodeError reports a line that could not be decoded into a transaction
+type DecodeError struct {
+	Source string
+	Line   int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s:%d: failed to decode transaction: %v", e.Source, e.Line, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// TransactionReader decodes JSON-lines transaction records, transparently gunzipping input
+type TransactionReader struct {
+	name    string
+	reader  *bufio.Reader
+	closers []io.Closer
+	line    int
+	counter *countingReader
+}
+
+// NewTransactionReader creates a reader over r, detecting gzip input by its magic bytes
+func NewTransactionReader(r io.Reader, name string) (*TransactionReader, error) {
+	counter := &countingReader{r: r}
+	buffered := bufio.NewReaderSize(counter, 1<<20)
+
+	reader := &TransactionReader{name: name, reader: buffered, counter: counter}
+
+	header, err := buffered.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	if bytes.Equal(header, gzipMagic) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream %s: %w", name, err)
+		}
+		reader.reader = bufio.NewReaderSize(gz, 1<<20)
+		reader.closers = append(reader.closers, gz)
+	}
+
+	return reader, nil
+}
+
+// OpenTransactionFile opens a JSON-lines file, optionally gzip compressed
+func OpenTransactionFile(path string) (*TransactionReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	reader, err := NewTransactionReader(file, path)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader.closers = append(reader.closers, file)
+
+	return reader, nil
+}
+
+// Next returns the next transaction and its raw line; it returns io.EOF at the end
+// of input and a *DecodeError for lines that are not valid transactions
+func (r *TransactionReader) Next() (*entity.Transaction, []byte, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if err == io.EOF {
+				return nil, nil, io.EOF
+			}
+			return nil, nil, fmt.Errorf("failed to read %s: %w", r.name, err)
+		}
+		r.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var tx entity.Transaction
+		if decodeErr := json.Unmarshal(line, &tx); decodeErr != nil {
+			return nil, line, &DecodeError{Source: r.name, Line: r.line, Err: decodeErr}
+		}
+
+		return &tx, line, nil
+	}
+}
+
+// BytesRead returns the number of (possibly compressed) input bytes consumed so far
+func (r *TransactionReader) BytesRead() int64 {
+	return r.counter.n
+}
+
+// Name returns the name of the underlying input
+func (r *TransactionReader) Name() string {
+	return r.name
+}
+
+// Close releases the underlying input
+func (r *TransactionReader) Close() error {
+	var firstErr error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ListTransactionFiles returns the transaction files at path in name order;
+// path may be a single file or a directory of .json, .jsonl and .gz files
+func ListTransactionFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".json") ||
+			strings.HasSuffix(name, ".ndjson") || strings.HasSuffix(name, ".gz") {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// countingReader counts bytes read from the wrapped reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
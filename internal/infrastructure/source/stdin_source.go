@@ -0,0 +1,38 @@
+package source
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+)
+
+// StdinSource reads JSON-lines transactions from standard input
+type StdinSource struct {
+	*localSource
+}
+
+// NewStdinSource creates a source reading from standard input
+func NewStdinSource(bufferSize int, logger *logger.Logger) service.TransactionSource {
+	return &StdinSource{
+		localSource: newLocalSource(bufferSize, logger.WithComponent("stdin-source")),
+	}
+}
+
+// Start begins reading standard input in the background
+func (s *StdinSource) Start(ctx context.Context) error {
+	s.logger.Info("Starting stdin source")
+
+	consumed := false
+	s.run(ctx, func() (*TransactionReader, error) {
+		if consumed {
+			return nil, io.EOF
+		}
+		consumed = true
+		return NewTransactionReader(os.Stdin, "stdin")
+	})
+
+	return nil
+}
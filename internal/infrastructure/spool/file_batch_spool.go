@@ -0,0 +1,127 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"crypto-bubble-map-indexer/internal/domain/entity"
+	"crypto-bubble-map-indexer/internal/domain/service"
+	"crypto-bubble-map-indexer/internal/infrastructure/config"
+	"crypto-bubble-map-indexer/internal/infrastructure/filelog"
+	"crypto-bubble-map-indexer/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// spoolFileName is the append-only log of the spooled batches
+const spoolFileName = "batches.spool"
+
+// FileBatchSpool keeps spooled batches as JSON records of an append-only file
+type FileBatchSpool struct {
+	buffer   *filelog.Log
+	head     *filelog.Record // record returned by Peek and not yet committed
+	maxBytes int64
+	logger   *logger.Logger
+}
+
+// NewFileBatchSpool opens the spool in the configured directory; it returns nil when
+// spooling is disabled
+func NewFileBatchSpool(cfg *config.Config, logger *logger.Logger) (service.BatchSpool, error) {
+	if cfg.Spool.Dir == "" {
+		return nil, nil
+	}
+
+	buffer, err := filelog.Open(cfg.Spool.Dir, spoolFileName, cfg.Spool.MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch spool: %w", err)
+	}
+
+	s := &FileBatchSpool{
+		buffer:   buffer,
+		maxBytes: cfg.Spool.MaxBytes,
+		logger:   logger.WithComponent("batch-spool"),
+	}
+	if pending := buffer.Pending(); pending > 0 {
+		s.logger.Info("Found spooled batches from a previous run", zap.Int("batches", pending))
+	}
+	return s, nil
+}
+
+// Append writes a batch at the tail of the log and syncs it to disk
+func (s *FileBatchSpool) Append(batch *entity.SpooledBatch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode spooled batch: %w", err)
+	}
+
+	if err := s.buffer.TryAppend(batch.Network, data); err != nil {
+		if errors.Is(err, filelog.ErrFull) {
+			return service.ErrSpoolFull
+		}
+		return err
+	}
+	return s.buffer.Sync()
+}
+
+// Peek returns the oldest batch; undecodable records are logged and dropped
+func (s *FileBatchSpool) Peek() (*entity.SpooledBatch, error) {
+	for s.head != nil || s.buffer.Len() > 0 {
+		if s.head == nil {
+			record, err := s.buffer.Next()
+			if err != nil {
+				return nil, err
+			}
+			s.head = record
+		}
+
+		var batch entity.SpooledBatch
+		if err := json.Unmarshal(s.head.Data, &batch); err != nil {
+			s.logger.Error("Dropping undecodable spooled batch",
+				zap.String("network", s.head.Subject),
+				zap.Error(err))
+			if err := s.Commit(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return &batch, nil
+	}
+	return nil, nil
+}
+
+// Commit removes the batch returned by the last Peek
+func (s *FileBatchSpool) Commit() error {
+	if s.head == nil {
+		return nil
+	}
+	if err := s.buffer.Ack(s.head); err != nil {
+		return err
+	}
+	s.head = nil
+	return nil
+}
+
+// Len returns the number of spooled batches, including the one held by Peek until it is
+// committed
+func (s *FileBatchSpool) Len() int {
+	return s.buffer.Pending()
+}
+
+// Bytes returns the size of the spooled batches
+func (s *FileBatchSpool) Bytes() int64 {
+	return s.buffer.Bytes()
+}
+
+// MaxBytes returns the bound of the spool
+func (s *FileBatchSpool) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Close syncs and closes the log; spooled batches are replayed on the next run
+func (s *FileBatchSpool) Close() error {
+	if pending := s.buffer.Pending(); pending > 0 {
+		s.logger.Info("Keeping spooled batches for the next run", zap.Int("batches", pending))
+	}
+	return s.buffer.Close()
+}
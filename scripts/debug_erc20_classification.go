@@ -107,7 +107,7 @@ func main() {
 				BlockNumber: "12350",
 				Network:     "ethereum",
 			},
-			expected: entity.InteractionETHTransfer,
+			expected: entity.InteractionNativeTransfer,
 		},
 	}
 
@@ -184,7 +184,7 @@ func main() {
 		entity.InteractionDeposit,
 		entity.InteractionWithdraw,
 		entity.InteractionMulticall,
-		entity.InteractionETHTransfer,
+		entity.InteractionNativeTransfer,
 		entity.InteractionUnknownContract,
 	}
 
@@ -159,7 +159,7 @@ func main() {
 		entity.InteractionDeposit,
 		entity.InteractionWithdraw,
 		entity.InteractionMulticall,
-		entity.InteractionETHTransfer,
+		entity.InteractionNativeTransfer,
 		entity.InteractionUnknownContract,
 	}
 
@@ -196,7 +196,7 @@ func getUniqueRelationshipTypes() map[string]bool {
 		entity.InteractionDeposit,
 		entity.InteractionWithdraw,
 		entity.InteractionMulticall,
-		entity.InteractionETHTransfer,
+		entity.InteractionNativeTransfer,
 		entity.InteractionUnknownContract,
 	}
 
@@ -208,7 +208,7 @@ func showRelationshipSummary(ctx context.Context, session neo4j.SessionWithConte
 	query := `
 		CALL db.relationshipTypes() YIELD relationshipType
 		WITH relationshipType
-		WHERE relationshipType IN ['ERC20_TRANSFER', 'ERC20_APPROVAL', 'DEX_SWAP', 'DEFI_OPERATION', 'LIQUIDITY_OPERATION', 'MULTICALL_OPERATION', 'ETH_TRANSFER', 'CONTRACT_INTERACTION']
+		WHERE relationshipType IN ['ERC20_TRANSFER', 'ERC20_APPROVAL', 'DEX_SWAP', 'DEFI_OPERATION', 'LIQUIDITY_OPERATION', 'MULTICALL_OPERATION', 'NATIVE_TRANSFER', 'CONTRACT_INTERACTION']
 		CALL {
 			WITH relationshipType
 			MATCH ()-[r]->()